@@ -14,6 +14,7 @@ import (
 	"github.com/haqer0002/avito-shop/internal/handlers"
 	"github.com/haqer0002/avito-shop/internal/repository/postgres"
 	"github.com/haqer0002/avito-shop/internal/service"
+	"github.com/haqer0002/avito-shop/internal/worker"
 )
 
 func main() {
@@ -39,12 +40,31 @@ func main() {
 
 	repos := postgres.NewRepository(db)
 
-	services := service.NewService(repos)
+	services := service.NewService(repos, cfg)
 	handlers := handlers.NewHandler(services)
 
+	// Фоновые задачи останавливаются вместе с сервером
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go worker.Run(workerCtx, "scheduled-transfers", cfg.SchedulerInterval, services.Schedules.ProcessDue)
+	go worker.Run(workerCtx, "fraud-analysis", cfg.Fraud.ScanInterval, services.Fraud.Analyze)
+	go worker.Run(workerCtx, "wishlist-notifications", cfg.WishlistScanInterval, services.Wishlist.Notify)
+	go worker.Run(workerCtx, "coin-expiry", cfg.CoinExpiry.CheckInterval, services.CoinExpiry.ExpireDue)
+	go worker.Run(workerCtx, "coin-expiry-warnings", cfg.CoinExpiry.CheckInterval, services.CoinExpiry.WarnDue)
+	go worker.Run(workerCtx, "hold-expiry", cfg.Escrow.ExpireInterval, services.Escrow.ExpireDue)
+	go worker.Run(workerCtx, "auction-close", cfg.AuctionCloseInterval, services.Auctions.CloseDue)
+	go worker.Run(workerCtx, "raffle-draw", cfg.Raffle.DrawInterval, services.Raffles.DrawDue)
+	go worker.Run(workerCtx, "work-anniversaries", cfg.AchievementCheckInterval, services.Achievements.AwardAnniversaries)
+	go worker.Run(workerCtx, "leaderboard-refresh", cfg.LeaderboardRefreshInterval, services.Leaderboards.Refresh)
+
+	router := handlers.InitRoutes()
+	// Изображения товаров из локального хранилища раздаются самим сервером
+	router.Static(cfg.Media.URLPrefix, cfg.Media.Dir)
+
 	srv := &http.Server{
 		Addr:    ":8080",
-		Handler: handlers.InitRoutes(),
+		Handler: router,
 	}
 
 	go func() {
@@ -61,6 +81,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,12 +17,235 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	JWTSecret  string
+
+	// PaymentRequestTTL — время жизни запроса на перевод монет
+	PaymentRequestTTL time.Duration
+
+	// SchedulerInterval — период запуска планировщика переводов
+	SchedulerInterval time.Duration
+	// ScheduleMaxAttempts — число попыток выполнить перевод до отказа
+	ScheduleMaxAttempts int
+	// ScheduleRetryBackoff — задержка перед первой повторной попыткой, далее удваивается
+	ScheduleRetryBackoff time.Duration
+
+	// ReturnWindow — сколько времени после покупки можно вернуть или обменять мерч
+	ReturnWindow time.Duration
+
+	// TradeOfferTTL — время жизни предложения обмена между пользователями
+	TradeOfferTTL time.Duration
+
+	// WishlistScanInterval — период проверки товаров из списков желаний для уведомлений
+	WishlistScanInterval time.Duration
+
+	// AuctionCloseInterval — период проверки завершившихся аукционов
+	AuctionCloseInterval time.Duration
+
+	// AchievementCheckInterval — период проверки годовщин работы для выдачи достижений
+	AchievementCheckInterval time.Duration
+
+	// LeaderboardRefreshInterval — период пересчета рейтингов переводов
+	LeaderboardRefreshInterval time.Duration
+
+	// TransferLimits — правила политики переводов
+	TransferLimits TransferLimits
+
+	// Fraud — параметры анализа подозрительных переводов
+	Fraud FraudSettings
+
+	// Media — параметры хранения изображений товаров
+	Media MediaSettings
+
+	// Market — параметры маркетплейса между пользователями
+	Market MarketSettings
+
+	// Raffle — параметры розыгрышей
+	Raffle RaffleSettings
+
+	// Escrow — параметры удержания монет
+	Escrow EscrowSettings
+
+	// CoinExpiry — политика сгорания монет
+	CoinExpiry CoinExpirySettings
+}
+
+// TransferLimits описывает ограничения на исходящие переводы монет.
+// Нулевое значение отключает соответствующее правило
+type TransferLimits struct {
+	// MaxAmount — максимальная сумма одного перевода
+	MaxAmount int64
+	// DailyCap — максимальная сумма переводов за последние 24 часа
+	DailyCap int64
+	// WeeklyCap — максимальная сумма переводов за последние 7 дней
+	WeeklyCap int64
+	// MaxRecipientsPerDay — максимальное число разных получателей за последние 24 часа
+	MaxRecipientsPerDay int
+	// NewAccountCooldown — сколько времени после регистрации переводы запрещены
+	NewAccountCooldown time.Duration
+}
+
+// FraudSettings описывает параметры анализа подозрительных переводов
+type FraudSettings struct {
+	// ScanInterval — период запуска анализа
+	ScanInterval time.Duration
+	// Window — окно поиска циклических переводов и воронок
+	Window time.Duration
+	// NewAccountAge — до какого возраста аккаунт считается новым
+	NewAccountAge time.Duration
+	// FunnelMinSenders — сколько новых аккаунтов должны перевести монеты одному получателю
+	FunnelMinSenders int
+	// BurstWindow — окно, в котором ищется всплеск активности
+	BurstWindow time.Duration
+	// BaselineWindow — период, по которому считается обычная активность пользователя
+	BaselineWindow time.Duration
+	// BurstFactor — во сколько раз активность должна превысить обычную
+	BurstFactor int
+	// BurstMinTransfers — минимальное число переводов во всплеске
+	BurstMinTransfers int
+	// HoldScore — оценка, начиная с которой исходящие переводы блокируются, 0 отключает блокировку
+	HoldScore int
+}
+
+// MediaSettings описывает хранение изображений товаров
+type MediaSettings struct {
+	// Dir — каталог локального хранилища изображений
+	Dir string
+	// URLPrefix — путь, по которому сервер раздает изображения
+	URLPrefix string
+	// ThumbnailSize — максимальная ширина и высота миниатюры в пикселях
+	ThumbnailSize int
+	// MaxImageSize — максимальный размер загружаемого изображения в байтах
+	MaxImageSize int64
+	// MaxImagePixels — максимальное число пикселей (ширина × высота) загружаемого изображения
+	MaxImagePixels int64
+}
+
+// MarketSettings описывает комиссию маркетплейса
+type MarketSettings struct {
+	// FeePercent — комиссия площадки в процентах от цены продажи, 0 отключает комиссию
+	FeePercent int
+	// FeeAccount — имя системного пользователя, на которого зачисляется комиссия
+	FeeAccount string
+}
+
+// RaffleSettings описывает проведение розыгрышей
+type RaffleSettings struct {
+	// DrawInterval — период проверки розыгрышей, время которых наступило
+	DrawInterval time.Duration
+	// SinkAccount — имя системного пользователя, на которого зачисляются монеты за билеты
+	SinkAccount string
+}
+
+// EscrowSettings описывает удержание монет под незавершенные сделки
+type EscrowSettings struct {
+	// ExpireInterval — период снятия истекших удержаний
+	ExpireInterval time.Duration
+	// AuctionHoldGrace — сколько после окончания аукциона действуют удержания под ставки,
+	// чтобы аукцион успел закрыться
+	AuctionHoldGrace time.Duration
+}
+
+// CoinExpirySettings описывает сгорание монет: монеты, начисленные в одном финансовом году,
+// сгорают через Months месяцев после его окончания
+type CoinExpirySettings struct {
+	// Months — через сколько месяцев после окончания финансового года сгорают монеты, 0 отключает сгорание
+	Months int
+	// FiscalYearStart — месяц, с которого начинается финансовый год
+	FiscalYearStart time.Month
+	// WarnBefore — за сколько времени до сгорания предупреждать пользователей
+	WarnBefore time.Duration
+	// CheckInterval — период проверки сгорающих монет
+	CheckInterval time.Duration
 }
 
 // LoadConfig загружает конфигурацию из переменных окружения
 func LoadConfig() (*Config, error) {
 	_ = godotenv.Load()
 
+	paymentRequestTTL, err := getEnvDuration("PAYMENT_REQUEST_TTL", 72*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	schedulerInterval, err := getEnvInterval("SCHEDULER_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleMaxAttempts, err := getEnvInt("SCHEDULE_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleRetryBackoff, err := getEnvDuration("SCHEDULE_RETRY_BACKOFF", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	returnWindow, err := getEnvDuration("RETURN_WINDOW", 14*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	tradeOfferTTL, err := getEnvDuration("TRADE_OFFER_TTL", 72*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlistScanInterval, err := getEnvInterval("WISHLIST_SCAN_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	auctionCloseInterval, err := getEnvInterval("AUCTION_CLOSE_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	achievementCheckInterval, err := getEnvInterval("ACHIEVEMENT_CHECK_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboardRefreshInterval, err := getEnvInterval("LEADERBOARD_REFRESH_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	transferLimits, err := loadTransferLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	fraud, err := loadFraudSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := loadMediaSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	market, err := loadMarketSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	raffle, err := loadRaffleSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	escrow, err := loadEscrowSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	coinExpiry, err := loadCoinExpirySettings()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -28,17 +253,204 @@ func LoadConfig() (*Config, error) {
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "avito_shop"),
 		JWTSecret:  getEnv("JWT_SECRET", "your_jwt_secret_key"),
+
+		PaymentRequestTTL: paymentRequestTTL,
+
+		SchedulerInterval:    schedulerInterval,
+		ScheduleMaxAttempts:  scheduleMaxAttempts,
+		ScheduleRetryBackoff: scheduleRetryBackoff,
+
+		ReturnWindow:               returnWindow,
+		TradeOfferTTL:              tradeOfferTTL,
+		WishlistScanInterval:       wishlistScanInterval,
+		AuctionCloseInterval:       auctionCloseInterval,
+		AchievementCheckInterval:   achievementCheckInterval,
+		LeaderboardRefreshInterval: leaderboardRefreshInterval,
+
+		TransferLimits: *transferLimits,
+		Fraud:          *fraud,
+		Media:          *media,
+		Market:         *market,
+		Raffle:         *raffle,
+		Escrow:         *escrow,
+		CoinExpiry:     *coinExpiry,
 	}
 
 	return config, nil
 }
 
+// loadTransferLimits загружает правила политики переводов
+func loadTransferLimits() (*TransferLimits, error) {
+	var (
+		limits TransferLimits
+		err    error
+	)
+
+	if limits.MaxAmount, err = getEnvInt64("TRANSFER_MAX_AMOUNT", 0); err != nil {
+		return nil, err
+	}
+	if limits.DailyCap, err = getEnvInt64("TRANSFER_DAILY_CAP", 0); err != nil {
+		return nil, err
+	}
+	if limits.WeeklyCap, err = getEnvInt64("TRANSFER_WEEKLY_CAP", 0); err != nil {
+		return nil, err
+	}
+	if limits.MaxRecipientsPerDay, err = getEnvInt("TRANSFER_MAX_RECIPIENTS_PER_DAY", 0); err != nil {
+		return nil, err
+	}
+	if limits.NewAccountCooldown, err = getEnvDuration("TRANSFER_NEW_ACCOUNT_COOLDOWN", 0); err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
 // GetDBConnString возвращает строку подключения к базе данных
 func (c *Config) GetDBConnString() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
 }
 
+// loadFraudSettings загружает параметры анализа подозрительных переводов
+func loadFraudSettings() (*FraudSettings, error) {
+	var (
+		fraud FraudSettings
+		err   error
+	)
+
+	if fraud.ScanInterval, err = getEnvInterval("FRAUD_SCAN_INTERVAL", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if fraud.Window, err = getEnvDuration("FRAUD_WINDOW", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if fraud.NewAccountAge, err = getEnvDuration("FRAUD_NEW_ACCOUNT_AGE", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if fraud.FunnelMinSenders, err = getEnvInt("FRAUD_FUNNEL_MIN_SENDERS", 5); err != nil {
+		return nil, err
+	}
+	if fraud.BurstWindow, err = getEnvDuration("FRAUD_BURST_WINDOW", time.Hour); err != nil {
+		return nil, err
+	}
+	if fraud.BaselineWindow, err = getEnvDuration("FRAUD_BASELINE_WINDOW", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if fraud.BurstFactor, err = getEnvInt("FRAUD_BURST_FACTOR", 5); err != nil {
+		return nil, err
+	}
+	if fraud.BurstMinTransfers, err = getEnvInt("FRAUD_BURST_MIN_TRANSFERS", 10); err != nil {
+		return nil, err
+	}
+	if fraud.HoldScore, err = getEnvInt("FRAUD_HOLD_SCORE", 80); err != nil {
+		return nil, err
+	}
+
+	return &fraud, nil
+}
+
+// loadMediaSettings загружает параметры хранения изображений товаров
+func loadMediaSettings() (*MediaSettings, error) {
+	media := MediaSettings{
+		Dir:       getEnv("MEDIA_DIR", "./media"),
+		URLPrefix: getEnv("MEDIA_URL_PREFIX", "/media"),
+	}
+
+	var err error
+	if media.ThumbnailSize, err = getEnvInt("THUMBNAIL_SIZE", 256); err != nil {
+		return nil, err
+	}
+	if media.MaxImageSize, err = getEnvInt64("MAX_IMAGE_SIZE", 5<<20); err != nil {
+		return nil, err
+	}
+	if media.MaxImagePixels, err = getEnvInt64("MAX_IMAGE_PIXELS", 25000000); err != nil {
+		return nil, err
+	}
+
+	return &media, nil
+}
+
+// loadMarketSettings загружает параметры маркетплейса
+func loadMarketSettings() (*MarketSettings, error) {
+	market := MarketSettings{
+		FeeAccount: getEnv("MARKET_FEE_ACCOUNT", "system"),
+	}
+
+	var err error
+	if market.FeePercent, err = getEnvInt("MARKET_FEE_PERCENT", 0); err != nil {
+		return nil, err
+	}
+	if market.FeePercent < 0 || market.FeePercent > 100 {
+		return nil, fmt.Errorf("MARKET_FEE_PERCENT must be between 0 and 100, got %d", market.FeePercent)
+	}
+
+	return &market, nil
+}
+
+// loadRaffleSettings загружает параметры розыгрышей
+func loadRaffleSettings() (*RaffleSettings, error) {
+	raffle := RaffleSettings{
+		SinkAccount: getEnv("RAFFLE_SINK_ACCOUNT", "system"),
+	}
+
+	var err error
+	if raffle.DrawInterval, err = getEnvInterval("RAFFLE_DRAW_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+
+	return &raffle, nil
+}
+
+// loadEscrowSettings загружает параметры удержания монет
+func loadEscrowSettings() (*EscrowSettings, error) {
+	var (
+		escrow EscrowSettings
+		err    error
+	)
+
+	if escrow.ExpireInterval, err = getEnvInterval("ESCROW_EXPIRE_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+	if escrow.AuctionHoldGrace, err = getEnvDuration("ESCROW_AUCTION_HOLD_GRACE", 24*time.Hour); err != nil {
+		return nil, err
+	}
+
+	return &escrow, nil
+}
+
+// loadCoinExpirySettings загружает политику сгорания монет
+func loadCoinExpirySettings() (*CoinExpirySettings, error) {
+	var (
+		expiry CoinExpirySettings
+		err    error
+	)
+
+	if expiry.Months, err = getEnvInt("COIN_EXPIRY_MONTHS", 0); err != nil {
+		return nil, err
+	}
+	if expiry.Months < 0 {
+		return nil, fmt.Errorf("COIN_EXPIRY_MONTHS cannot be negative, got %d", expiry.Months)
+	}
+
+	month, err := getEnvInt("COIN_FISCAL_YEAR_START", 1)
+	if err != nil {
+		return nil, err
+	}
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("COIN_FISCAL_YEAR_START must be a month number between 1 and 12, got %d", month)
+	}
+	expiry.FiscalYearStart = time.Month(month)
+
+	if expiry.WarnBefore, err = getEnvDuration("COIN_EXPIRY_WARNING", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if expiry.CheckInterval, err = getEnvInterval("COIN_EXPIRY_CHECK_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+
+	return &expiry, nil
+}
+
 // getEnv получает значение переменной окружения или возвращает значение по умолчанию
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -47,3 +459,58 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvDuration получает длительность из переменной окружения или возвращает значение по умолчанию
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+// getEnvInterval получает период фоновой задачи из переменной окружения или возвращает значение по умолчанию.
+// Период должен быть положительным, иначе воркер не сможет запуститься
+func getEnvInterval(key string, defaultValue time.Duration) (time.Duration, error) {
+	interval, err := getEnvDuration(key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", key, interval)
+	}
+	return interval, nil
+}
+
+// getEnvInt получает целое число из переменной окружения или возвращает значение по умолчанию
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer in %s: %w", key, err)
+	}
+	return number, nil
+}
+
+// getEnvInt64 получает 64-битное целое из переменной окружения или возвращает значение по умолчанию
+func getEnvInt64(key string, defaultValue int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer in %s: %w", key, err)
+	}
+	return number, nil
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Event представляет доменное событие
+type Event interface {
+	Name() string
+}
+
+// Handler обрабатывает доменное событие
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher публикует доменные события
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// Bus — синхронная шина доменных событий внутри процесса.
+// События публикуются после успешного завершения операции, поэтому ошибка обработчика
+// ее не отменяет, а только записывается в лог. Обработчик получает контекст публикации:
+// если операция выполнялась внутри внешней транзакции, обработчик работает в ней же
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus создает новый экземпляр Bus
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Subscribe подписывает обработчик на события с указанным именем
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish передает событие всем подписанным обработчикам в порядке подписки
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Name()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			log.Printf("Handling event %s failed: %v", event.Name(), err)
+		}
+	}
+}
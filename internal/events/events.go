@@ -0,0 +1,43 @@
+package events
+
+// Имена доменных событий
+const (
+	UserCreatedEvent    = "user.created"
+	CoinsSentEvent      = "coins.sent"
+	MerchBoughtEvent    = "merch.bought"
+	OrderDeliveredEvent = "order.delivered"
+)
+
+// UserCreated публикуется после регистрации пользователя
+type UserCreated struct {
+	UserID   int64
+	Username string
+}
+
+func (UserCreated) Name() string { return UserCreatedEvent }
+
+// CoinsSent публикуется после перевода монет другому пользователю
+type CoinsSent struct {
+	FromUserID int64
+	ToUserID   int64
+	Amount     int64
+}
+
+func (CoinsSent) Name() string { return CoinsSentEvent }
+
+// MerchBought публикуется после оплаты заказа мерча
+type MerchBought struct {
+	UserID  int64
+	OrderID int64
+	Total   int64
+}
+
+func (MerchBought) Name() string { return MerchBoughtEvent }
+
+// OrderDelivered публикуется после того, как заказ мерча доставлен покупателю
+type OrderDelivered struct {
+	UserID  int64
+	OrderID int64
+}
+
+func (OrderDelivered) Name() string { return OrderDeliveredEvent }
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/haqer0002/avito-shop/internal/middleware"
+	"github.com/haqer0002/avito-shop/internal/models"
+)
+
+func (h *Handler) getAuctions(c *gin.Context) {
+	auctions, err := h.services.Auctions.GetAuctions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, auctions)
+}
+
+func (h *Handler) getAuction(c *gin.Context) {
+	auctionID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auction, err := h.services.Auctions.GetAuction(c.Request.Context(), auctionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, auction)
+}
+
+func (h *Handler) placeBid(c *gin.Context) {
+	auctionID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input models.PlaceBidRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	bid, err := h.services.Auctions.PlaceBid(c.Request.Context(), userID, auctionID, input.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bid)
+}
+
+func (h *Handler) createAuction(c *gin.Context) {
+	var input models.CreateAuctionRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	auction, err := h.services.Auctions.CreateAuction(c.Request.Context(), adminID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, auction)
+}
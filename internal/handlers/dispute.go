@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/haqer0002/avito-shop/internal/middleware"
+	"github.com/haqer0002/avito-shop/internal/models"
+)
+
+func (h *Handler) openDispute(c *gin.Context) {
+	transactionID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input models.OpenDisputeRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	dispute, err := h.services.Disputes.OpenDispute(c.Request.Context(), userID, transactionID, input.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+func (h *Handler) getUserDisputes(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	disputes, err := h.services.Disputes.GetUserDisputes(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
+
+func (h *Handler) getDisputes(c *gin.Context) {
+	disputes, err := h.services.Disputes.GetDisputes(c.Request.Context(), c.DefaultQuery("status", models.DisputeOpen))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
+
+func (h *Handler) rejectDispute(c *gin.Context) {
+	disputeID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input models.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&input); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.services.Disputes.RejectDispute(c.Request.Context(), adminID, disputeID, input.Resolution)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) reverseTransaction(c *gin.Context) {
+	transactionID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input models.ReverseTransactionRequest
+	if err := c.ShouldBindJSON(&input); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	reversal, err := h.services.Disputes.ReverseTransaction(c.Request.Context(), adminID, transactionID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reversal)
+}
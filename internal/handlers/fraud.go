@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/haqer0002/avito-shop/internal/middleware"
+	"github.com/haqer0002/avito-shop/internal/models"
+)
+
+func (h *Handler) getAlerts(c *gin.Context) {
+	alerts, err := h.services.Fraud.GetAlerts(c.Request.Context(), c.DefaultQuery("status", models.AlertOpen))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+func (h *Handler) reviewAlert(c *gin.Context) {
+	alertID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input models.ReviewAlertRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, err := h.services.Fraud.ReviewAlert(c.Request.Context(), adminID, alertID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+func (h *Handler) holdUserTransfers(c *gin.Context) {
+	h.setUserHold(c, true)
+}
+
+func (h *Handler) releaseUserTransfers(c *gin.Context) {
+	h.setUserHold(c, false)
+}
+
+func (h *Handler) setUserHold(c *gin.Context, hold bool) {
+	userID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.services.Fraud.SetHold(c.Request.Context(), userID, hold)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
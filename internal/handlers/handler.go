@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/haqer0002/avito-shop/internal/middleware"
 	"github.com/haqer0002/avito-shop/internal/service"
@@ -35,15 +38,164 @@ func (h *Handler) InitRoutes() *gin.Engine {
 		user := api.Group("/user")
 		{
 			user.GET("/info", h.getUserInfo)
+			user.GET("/holds", h.getUserHolds)
+			user.GET("/achievements", h.getUserAchievements)
+			user.POST("/leaderboard/opt-out", h.leaderboardOptOut)
+			user.DELETE("/leaderboard/opt-out", h.leaderboardOptIn)
 			user.POST("/send", h.sendCoins)
+			user.POST("/transactions/:id/dispute", h.openDispute)
+			user.GET("/disputes", h.getUserDisputes)
 		}
 
 		merch := api.Group("/merch")
 		{
 			merch.POST("/buy/:item", h.buyMerch)
+			merch.POST("/gift/:item", h.giftMerch)
 			merch.GET("/list", h.getAllMerch)
+			merch.GET("/categories", h.getCategories)
+			merch.GET("/:item/prices", h.getPriceHistory)
+		}
+
+		cart := api.Group("/cart")
+		{
+			cart.GET("", h.getCart)
+			cart.POST("/items", h.addCartItem)
+			cart.PUT("/items/:item", h.setCartItem)
+			cart.DELETE("/items/:item", h.removeCartItem)
+			cart.POST("/checkout", h.checkout)
+		}
+
+		returns := api.Group("/returns")
+		{
+			returns.POST("", h.requestReturn)
+			returns.GET("", h.getUserReturns)
+		}
+
+		trades := api.Group("/trades")
+		{
+			trades.POST("", h.proposeTrade)
+			trades.GET("", h.getTrades)
+			trades.POST("/:id/accept", h.acceptTrade)
+			trades.POST("/:id/reject", h.rejectTrade)
+			trades.POST("/:id/counter", h.counterTrade)
+			trades.POST("/:id/cancel", h.cancelTrade)
+		}
+
+		market := api.Group("/market")
+		{
+			market.GET("/listings", h.getListings)
+			market.GET("/listings/mine", h.getMyListings)
+			market.POST("/listings", h.createListing)
+			market.POST("/listings/:id/buy", h.buyListing)
+			market.DELETE("/listings/:id", h.cancelListing)
+		}
+
+		auctions := api.Group("/auctions")
+		{
+			auctions.GET("", h.getAuctions)
+			auctions.GET("/:id", h.getAuction)
+			auctions.POST("/:id/bids", h.placeBid)
+		}
+
+		raffles := api.Group("/raffles")
+		{
+			raffles.GET("", h.getRaffles)
+			raffles.GET("/:id", h.getRaffle)
+			raffles.POST("/:id/tickets", h.buyTickets)
+		}
+
+		teams := api.Group("/teams")
+		{
+			teams.GET("", h.getMyTeams)
+			teams.GET("/:id", h.getTeam)
+			teams.GET("/:id/transactions", h.getTeamTransactions)
+			teams.POST("/:id/send", h.sendTeamCoins)
+			teams.POST("/:id/buy/:item", h.buyTeamMerch)
+		}
+
+		leaderboards := api.Group("/leaderboards")
+		{
+			leaderboards.GET("/:board", h.getLeaderboard)
+		}
+
+		wishlist := api.Group("/wishlist")
+		{
+			wishlist.GET("", h.getWishlist)
+			wishlist.POST("/:item", h.addWishlistItem)
+			wishlist.DELETE("/:item", h.removeWishlistItem)
+		}
+
+		notifications := api.Group("/notifications")
+		{
+			notifications.GET("", h.getNotifications)
+			notifications.POST("/:id/read", h.markNotificationRead)
+		}
+
+		orders := api.Group("/orders")
+		{
+			orders.GET("", h.getOrders)
+			orders.GET("/:id", h.getOrder)
+			orders.POST("/:id/cancel", h.cancelOrder)
+		}
+
+		requests := api.Group("/requests")
+		{
+			requests.POST("", h.createPaymentRequest)
+			requests.GET("/incoming", h.getIncomingPaymentRequests)
+			requests.GET("/outgoing", h.getOutgoingPaymentRequests)
+			requests.POST("/:id/approve", h.approvePaymentRequest)
+			requests.POST("/:id/decline", h.declinePaymentRequest)
+		}
+
+		schedules := api.Group("/schedules")
+		{
+			schedules.POST("", h.createSchedule)
+			schedules.GET("", h.getSchedules)
+			schedules.POST("/:id/pause", h.pauseSchedule)
+			schedules.POST("/:id/resume", h.resumeSchedule)
+			schedules.DELETE("/:id", h.cancelSchedule)
+		}
+
+		// Маршруты администратора
+		admin := api.Group("/admin")
+		admin.Use(middleware.AdminMiddleware(h.services.Auth))
+		{
+			admin.GET("/alerts", h.getAlerts)
+			admin.POST("/alerts/:id/review", h.reviewAlert)
+			admin.POST("/users/:id/hold", h.holdUserTransfers)
+			admin.DELETE("/users/:id/hold", h.releaseUserTransfers)
+			admin.POST("/transactions/:id/reverse", h.reverseTransaction)
+			admin.GET("/disputes", h.getDisputes)
+			admin.POST("/disputes/:id/reject", h.rejectDispute)
+			admin.POST("/orders/:id/status", h.updateOrderStatus)
+			admin.GET("/returns", h.getReturns)
+			admin.POST("/returns/:id/approve", h.approveReturn)
+			admin.POST("/returns/:id/reject", h.rejectReturn)
+			admin.PATCH("/merch/:item", h.updateMerch)
+			admin.PUT("/merch/:item/price", h.changePrice)
+			admin.POST("/merch/:item/images", h.uploadMerchImage)
+			admin.DELETE("/merch/:item/images/:id", h.deleteMerchImage)
+			admin.POST("/auctions", h.createAuction)
+			admin.POST("/raffles", h.createRaffle)
+			admin.POST("/teams", h.createTeam)
+			admin.PUT("/teams/:id/members", h.setTeamMember)
+			admin.DELETE("/teams/:id/members/:username", h.removeTeamMember)
+			admin.POST("/teams/:id/grants", h.grantTeamCoins)
+			admin.POST("/discounts", h.createDiscount)
+			admin.GET("/discounts", h.getDiscounts)
+			admin.POST("/promo-codes", h.createPromoCode)
+			admin.GET("/promo-codes", h.getPromoCodes)
 		}
 	}
 
 	return router
 }
+
+// getIDParam получает числовой идентификатор из параметра пути :id
+func getIDParam(c *gin.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid id")
+	}
+	return id, nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/haqer0002/avito-shop/internal/middleware"
+)
+
+func (h *Handler) getLeaderboard(c *gin.Context) {
+	limit, err := queryInt64(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leaderboard, err := h.services.Leaderboards.GetLeaderboard(c.Request.Context(), c.Param("board"), c.Query("period"), int(limit))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+func (h *Handler) leaderboardOptOut(c *gin.Context) {
+	h.setLeaderboardOptOut(c, true)
+}
+
+func (h *Handler) leaderboardOptIn(c *gin.Context) {
+	h.setLeaderboardOptOut(c, false)
+}
+
+func (h *Handler) setLeaderboardOptOut(c *gin.Context, optOut bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.services.Leaderboards.SetOptOut(c.Request.Context(), userID, optOut)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
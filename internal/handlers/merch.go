@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/haqer0002/avito-shop/internal/middleware"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/service"
 )
 
 func (h *Handler) buyMerch(c *gin.Context) {
@@ -14,27 +19,239 @@ func (h *Handler) buyMerch(c *gin.Context) {
 		return
 	}
 
+	// Количество необязательно, по умолчанию покупается одна единица
+	quantity := 1
+	if value := c.Query("quantity"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
+			return
+		}
+		quantity = parsed
+	}
+
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = h.services.Merch.BuyMerch(c.Request.Context(), userID, merchName)
+	order, err := h.services.Merch.BuyMerch(c.Request.Context(), userID, merchName, models.BuyOptions{
+		VariantSKU: c.Query("variant"),
+		Quantity:   quantity,
+		PromoCode:  c.Query("promo"),
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		purchaseError(c, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, order)
+}
+
+// purchaseError отвечает 403 с остатком лимита, если покупка превышает ограничение на товар
+func (h *Handler) giftMerch(c *gin.Context) {
+	var input models.GiftMerchRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	if input.Quantity == 0 {
+		input.Quantity = 1
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.services.Merch.BuyMerch(c.Request.Context(), userID, c.Param("item"), models.BuyOptions{
+		VariantSKU: input.Variant,
+		Quantity:   input.Quantity,
+		PromoCode:  input.PromoCode,
+		Recipient:  input.ToUser,
+		GiftNote:   input.Note,
+	})
+	if err != nil {
+		purchaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func purchaseError(c *gin.Context, err error) {
+	var limitErr *service.LimitExceededError
+	if errors.As(err, &limitErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": limitErr.Error(), "remaining": limitErr.Remaining})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 }
 
 func (h *Handler) getAllMerch(c *gin.Context) {
-	items, err := h.services.Merch.GetAllMerch(c.Request.Context())
+	filter, err := parseMerchFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	items, total, err := h.services.Merch.GetAllMerch(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(total))
 	c.JSON(http.StatusOK, items)
 }
+
+// parseMerchFilter читает параметры поиска по каталогу из строки запроса
+func parseMerchFilter(c *gin.Context) (models.MerchFilter, error) {
+	filter := models.MerchFilter{
+		Category: c.Query("category"),
+		Tag:      c.Query("tag"),
+		Search:   c.Query("q"),
+		Sort:     c.Query("sort"),
+	}
+
+	var err error
+	if filter.MinPrice, err = queryInt64(c, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = queryInt64(c, "max_price"); err != nil {
+		return filter, err
+	}
+
+	limit, err := queryInt64(c, "limit")
+	if err != nil {
+		return filter, err
+	}
+	offset, err := queryInt64(c, "offset")
+	if err != nil {
+		return filter, err
+	}
+	filter.Limit, filter.Offset = int(limit), int(offset)
+
+	if value := c.Query("in_stock"); value != "" {
+		filter.InStock, err = strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid in_stock")
+		}
+	}
+
+	return filter, nil
+}
+
+// queryInt64 читает необязательный неотрицательный числовой параметр запроса
+func queryInt64(c *gin.Context, name string) (int64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return parsed, nil
+}
+
+func (h *Handler) getCategories(c *gin.Context) {
+	categories, err := h.services.Merch.GetCategories(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+func (h *Handler) updateMerch(c *gin.Context) {
+	var input models.UpdateMerchRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	merch, err := h.services.Merch.UpdateMerch(c.Request.Context(), c.Param("item"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, merch)
+}
+
+func (h *Handler) getPriceHistory(c *gin.Context) {
+	history, err := h.services.Merch.GetPriceHistory(c.Request.Context(), c.Param("item"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *Handler) uploadMerchImage(c *gin.Context) {
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read image file"})
+		return
+	}
+	defer src.Close()
+
+	image, err := h.services.Merch.UploadImage(c.Request.Context(), c.Param("item"), src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
+func (h *Handler) deleteMerchImage(c *gin.Context) {
+	imageID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.Merch.DeleteImage(c.Request.Context(), c.Param("item"), imageID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) changePrice(c *gin.Context) {
+	var input models.ChangePriceRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := h.services.Merch.ChangePrice(c.Request.Context(), adminID, c.Param("item"), input.Price)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
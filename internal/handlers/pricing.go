@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/haqer0002/avito-shop/internal/models"
+)
+
+func (h *Handler) createDiscount(c *gin.Context) {
+	var input models.CreateDiscountRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	discount, err := h.services.Pricing.CreateDiscount(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, discount)
+}
+
+func (h *Handler) getDiscounts(c *gin.Context) {
+	discounts, err := h.services.Pricing.GetDiscounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, discounts)
+}
+
+func (h *Handler) createPromoCode(c *gin.Context) {
+	var input models.CreatePromoCodeRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	promo, err := h.services.Pricing.CreatePromoCode(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+func (h *Handler) getPromoCodes(c *gin.Context) {
+	promos, err := h.services.Pricing.GetPromoCodes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promos)
+}
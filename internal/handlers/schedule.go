@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/haqer0002/avito-shop/internal/middleware"
+	"github.com/haqer0002/avito-shop/internal/models"
+)
+
+func (h *Handler) createSchedule(c *gin.Context) {
+	var input models.CreateScheduledTransferRequest
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input data"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.services.Schedules.CreateSchedule(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *Handler) getSchedules(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedules, err := h.services.Schedules.GetUserSchedules(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *Handler) pauseSchedule(c *gin.Context) {
+	h.changeSchedule(c, h.services.Schedules.Pause)
+}
+
+func (h *Handler) resumeSchedule(c *gin.Context) {
+	h.changeSchedule(c, h.services.Schedules.Resume)
+}
+
+func (h *Handler) cancelSchedule(c *gin.Context) {
+	h.changeSchedule(c, h.services.Schedules.Cancel)
+}
+
+// changeSchedule применяет действие пользователя к запланированному переводу из пути
+func (h *Handler) changeSchedule(c *gin.Context, action func(ctx context.Context, userID, scheduleID int64) error) {
+	scheduleID, err := getIDParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = action(c.Request.Context(), userID, scheduleID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
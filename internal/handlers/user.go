@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"log"
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/haqer0002/avito-shop/internal/middleware"
 	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/service"
 )
 
 func (h *Handler) getUserInfo(c *gin.Context) {
@@ -46,9 +48,20 @@ func (h *Handler) sendCoins(c *gin.Context) {
 
 	err = h.services.User.SendCoins(c.Request.Context(), userID, input.ToUser, input.Amount)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		transferError(c, err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
+
+// transferError отвечает на ошибку перевода, отдельно выделяя нарушения политики переводов
+func transferError(c *gin.Context, err error) {
+	var violation *service.PolicyViolationError
+	if errors.As(err, &violation) {
+		c.JSON(http.StatusForbidden, gin.H{"error": violation.Error(), "rule": violation.Rule})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
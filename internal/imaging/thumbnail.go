@@ -0,0 +1,99 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// Регистрация поддерживаемых форматов для image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// ErrUnsupportedFormat возвращается для файлов, которые не являются изображением поддерживаемого формата
+var ErrUnsupportedFormat = errors.New("unsupported image format, use jpeg, png or gif")
+
+// Probe определяет формат изображения (jpeg, png, gif) и его размеры в пикселях без полного декодирования
+func Probe(r io.Reader) (format string, width, height int, err error) {
+	config, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return "", 0, 0, ErrUnsupportedFormat
+	}
+	return format, config.Width, config.Height, nil
+}
+
+// Thumbnail уменьшает изображение так, чтобы оно помещалось в квадрат size x size
+// с сохранением пропорций, и кодирует результат в JPEG.
+// Изображения меньше заданного размера не увеличиваются
+func Thumbnail(r io.Reader, size int) ([]byte, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	bounds := src.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), size)
+
+	dst := downscale(src, width, height)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fit вычисляет размеры, вписанные в квадрат size x size
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// downscale уменьшает изображение усреднением пикселей исходной области,
+// прозрачные области накладываются на белый фон
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					// Значения предумножены на альфу, добавляем белый фон
+					white := 0xffff - uint64(ca)
+					r += uint64(cr) + white
+					g += uint64(cg) + white
+					b += uint64(cb) + white
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+
+	return dst
+}
@@ -47,6 +47,32 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware пропускает только администраторов. Должен идти после AuthMiddleware
+func AdminMiddleware(authService service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		isAdmin, err := authService.IsAdmin(c.Request.Context(), userID)
+		if err != nil {
+			log.Printf("Error checking admin role for user %d: %v", userID, err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !isAdmin {
+			log.Printf("User %d is not an admin", userID)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func GetUserID(c *gin.Context) (int64, error) {
 	id, ok := c.Get(userCtx)
 	if !ok {
@@ -1,16 +1,53 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
 
-// User представляет пользователя системы
+	"github.com/lib/pq"
+)
+
+// User представляет пользователя системы.
+// Coins — общий баланс, HeldCoins — часть баланса, удержанная под незавершенные сделки
 type User struct {
-	ID       int64  `json:"id" db:"id"`
-	Username string `json:"username" db:"username"`
-	Password string `json:"-" db:"password"`
-	Coins    int64  `json:"coins" db:"coins"`
+	ID        int64     `json:"id" db:"id"`
+	Username  string    `json:"username" db:"username"`
+	Password  string    `json:"-" db:"password"`
+	Coins     int64     `json:"coins" db:"coins"`
+	HeldCoins int64     `json:"held_coins" db:"held_coins"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	IsAdmin   bool      `json:"-" db:"is_admin"`
+	OnHold    bool      `json:"-" db:"transfers_on_hold"`
+	IsSystem  bool      `json:"-" db:"is_system"`
 }
 
-// Transaction представляет транзакцию между пользователями
+// AvailableCoins возвращает монеты, которые пользователь может потратить
+func (u *User) AvailableCoins() int64 {
+	return u.Coins - u.HeldCoins
+}
+
+// Типы транзакций
+const (
+	TransactionTransfer  = "transfer"
+	TransactionReversal  = "reversal"
+	TransactionRefund    = "refund"
+	TransactionExchange  = "exchange"
+	TransactionTrade     = "trade"
+	TransactionSale      = "market_sale"
+	TransactionFee       = "market_fee"
+	TransactionBid       = "auction_bid"
+	TransactionBidRefund = "auction_refund"
+	TransactionRaffle    = "raffle_ticket"
+	TransactionExpiry    = "expiry"
+	TransactionBonus     = "achievement_bonus"
+	TransactionTeamGrant = "team_grant"
+	TransactionTeamBuy   = "team_purchase"
+)
+
+// Transaction представляет транзакцию между пользователями.
+// Нулевой FromUserID или ToUserID означает магазин
 type Transaction struct {
 	ID          int64     `json:"id" db:"id"`
 	FromUserID  int64     `json:"from_user_id" db:"from_user_id"`
@@ -18,6 +55,16 @@ type Transaction struct {
 	Amount      int64     `json:"amount" db:"amount"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	Description string    `json:"description" db:"description"`
+	Kind        string    `json:"kind" db:"kind"`
+	ReversalOf  *int64    `json:"reversal_of,omitempty" db:"reversal_of"`
+	Reversed    bool      `json:"reversed" db:"reversed"`
+}
+
+// TransferStats представляет сводку исходящих переводов пользователя за период
+type TransferStats struct {
+	Total        int64 `db:"total"`
+	Recipients   int   `db:"recipients"`
+	HasRecipient bool  `db:"has_recipient"`
 }
 
 // MerchItem представляет товар в магазине
@@ -25,27 +72,276 @@ type MerchItem struct {
 	ID    int64  `json:"id" db:"id"`
 	Name  string `json:"name" db:"name"`
 	Price int64  `json:"price" db:"price"`
+	Stock *int   `json:"stock,omitempty" db:"stock"`
+
+	// DisplayName — название для витрины, Name остается идентификатором товара в URL
+	DisplayName string  `json:"displayName" db:"display_name"`
+	Description *string `json:"description,omitempty" db:"description"`
+
+	Category *string        `json:"category,omitempty" db:"category"`
+	Tags     pq.StringArray `json:"tags" db:"tags"`
+
+	PurchaseLimit       *int    `json:"purchaseLimit,omitempty" db:"purchase_limit"`
+	PurchaseLimitPeriod *string `json:"purchaseLimitPeriod,omitempty" db:"purchase_limit_period"`
+
+	HasVariants bool           `json:"-" db:"has_variants"`
+	Variants    []MerchVariant `json:"variants,omitempty" db:"-"`
+	Images      []MerchImage   `json:"images" db:"-"`
 }
 
-// UserMerch представляет купленный пользователем мерч
-type UserMerch struct {
+// MerchImage представляет изображение товара и его миниатюру в хранилище объектов
+type MerchImage struct {
+	ID           int64     `json:"id" db:"id"`
+	MerchID      int64     `json:"merchId" db:"merch_id"`
+	Key          string    `json:"-" db:"key"`
+	ThumbnailKey string    `json:"-" db:"thumbnail_key"`
+	ContentType  string    `json:"contentType" db:"content_type"`
+	Position     int       `json:"position" db:"position"`
+	URL          string    `json:"url" db:"-"`
+	ThumbnailURL string    `json:"thumbnailUrl" db:"-"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Порядок сортировки каталога
+const (
+	MerchSortPriceAsc  = "price_asc"
+	MerchSortPriceDesc = "price_desc"
+	MerchSortNameAsc   = "name_asc"
+	MerchSortNameDesc  = "name_desc"
+)
+
+// MerchFilter представляет параметры поиска по каталогу
+type MerchFilter struct {
+	Category string
+	Tag      string
+	MinPrice int64
+	MaxPrice int64
+	InStock  bool
+	Search   string
+	Sort     string
+	Limit    int
+	Offset   int
+}
+
+// MerchCategory представляет категорию товаров
+type MerchCategory struct {
+	Name  string `json:"name" db:"name"`
+	Title string `json:"title" db:"title"`
+}
+
+// UpdateMerchRequest представляет запрос администратора на изменение карточки товара.
+// Незаданные поля не меняются
+type UpdateMerchRequest struct {
+	DisplayName *string  `json:"displayName"`
+	Description *string  `json:"description"`
+	Category    *string  `json:"category"`
+	Tags        []string `json:"tags"`
+}
+
+// MerchVariant представляет вариант товара, например размер или цвет.
+// Если Price не задана, используется цена товара
+type MerchVariant struct {
+	ID         int64      `json:"id" db:"id"`
+	MerchID    int64      `json:"merchId" db:"merch_id"`
+	SKU        string     `json:"sku" db:"sku"`
+	Attributes Attributes `json:"attributes" db:"attributes"`
+	Price      *int64     `json:"price,omitempty" db:"price"`
+	Stock      *int       `json:"stock,omitempty" db:"stock"`
+}
+
+// Attributes представляет атрибуты варианта товара, хранящиеся в JSONB
+type Attributes map[string]string
+
+// Value сериализует атрибуты в JSON для записи в БД
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan читает атрибуты из JSONB
+func (a *Attributes) Scan(src interface{}) error {
+	if src == nil {
+		*a = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for attributes")
+	}
+
+	return json.Unmarshal(data, a)
+}
+
+// Периоды ограничения покупок
+const (
+	PurchaseLimitDay   = "day"
+	PurchaseLimitWeek  = "week"
+	PurchaseLimitMonth = "month"
+)
+
+// PriceChange представляет изменение цены товара
+type PriceChange struct {
 	ID        int64     `json:"id" db:"id"`
-	UserID    int64     `json:"user_id" db:"user_id"`
 	MerchID   int64     `json:"merch_id" db:"merch_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	OldPrice  *int64    `json:"old_price,omitempty" db:"old_price"`
+	NewPrice  int64     `json:"new_price" db:"new_price"`
+	ChangedBy *int64    `json:"changed_by,omitempty" db:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// ChangePriceRequest представляет запрос администратора на изменение цены товара
+type ChangePriceRequest struct {
+	Price int64 `json:"price" binding:"required,gt=0"`
+}
+
+// CartItem представляет позицию в корзине пользователя
+type CartItem struct {
+	MerchID    int64   `json:"merchId" db:"merch_id"`
+	Name       string  `json:"name" db:"name"`
+	VariantID  *int64  `json:"-" db:"variant_id"`
+	VariantSKU *string `json:"variant,omitempty" db:"variant_sku"`
+	Price      int64   `json:"price" db:"price"`
+	Quantity   int     `json:"quantity" db:"quantity"`
+	Subtotal   int64   `json:"subtotal" db:"-"`
+}
+
+// Cart представляет корзину пользователя с итоговой суммой
+type Cart struct {
+	Items []CartItem `json:"items"`
+	Total int64      `json:"total"`
+}
+
+// WishlistItem представляет товар в списке желаний пользователя.
+// SalePrice задана, если на товар действует скидка, CoinsNeeded — сколько монет не хватает на покупку
+type WishlistItem struct {
+	UserID      int64     `json:"-" db:"user_id"`
+	MerchID     int64     `json:"merchId" db:"merch_id"`
+	Name        string    `json:"name" db:"name"`
+	DisplayName string    `json:"displayName" db:"display_name"`
+	Category    *string   `json:"-" db:"category"`
+	Price       int64     `json:"price" db:"price"`
+	SalePrice   *int64    `json:"salePrice,omitempty" db:"-"`
+	InStock     bool      `json:"inStock" db:"in_stock"`
+	CoinsNeeded int64     `json:"coinsNeeded" db:"-"`
+	AddedAt     time.Time `json:"addedAt" db:"added_at"`
+
+	NotifiedDiscountID *int64 `json:"-" db:"notified_discount_id"`
+}
+
+// Wishlist представляет список желаний пользователя и его доступный баланс
+type Wishlist struct {
+	Balance int64          `json:"balance"`
+	Items   []WishlistItem `json:"items"`
+}
+
+// Типы уведомлений
+const (
+	NotificationBackInStock = "back_in_stock"
+	NotificationOnSale      = "on_sale"
+	NotificationOutbid      = "outbid"
+	NotificationAuctionWon  = "auction_won"
+	NotificationRaffleWon   = "raffle_won"
+	NotificationExpiring    = "coins_expiring"
+	NotificationAchievement = "achievement"
+)
+
+// Notification представляет уведомление пользователя
+type Notification struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	Kind      string     `json:"kind" db:"kind"`
+	MerchID   *int64     `json:"merch_id,omitempty" db:"merch_id"`
+	Message   string     `json:"message" db:"message"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty" db:"read_at"`
+}
+
+// AddCartItemRequest представляет запрос на добавление товара в корзину
+type AddCartItemRequest struct {
+	Item     string `json:"item" binding:"required"`
+	Variant  string `json:"variant"`
+	Quantity int    `json:"quantity" binding:"required,gt=0"`
 }
 
-// InfoResponse представляет ответ на запрос информации о пользователе
+// SetCartItemRequest представляет запрос на изменение количества товара в корзине
+type SetCartItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+// Статусы купленного мерча
+const (
+	UserMerchOwned     = "owned"
+	UserMerchCancelled = "cancelled"
+	UserMerchReturned  = "returned"
+	UserMerchListed    = "listed"
+)
+
+// UserMerch представляет купленный пользователем мерч
+type UserMerch struct {
+	ID         int64      `json:"id" db:"id"`
+	UserID     int64      `json:"user_id" db:"user_id"`
+	MerchID    int64      `json:"merch_id" db:"merch_id"`
+	VariantID  *int64     `json:"variant_id,omitempty" db:"variant_id"`
+	VariantSKU *string    `json:"variant_sku,omitempty" db:"variant_sku"`
+	Attributes Attributes `json:"attributes,omitempty" db:"attributes"`
+	PricePaid  int64      `json:"price_paid" db:"price_paid"`
+	OrderID    *int64     `json:"order_id,omitempty" db:"order_id"`
+	GivenBy    *int64     `json:"given_by,omitempty" db:"given_by"`
+	GiftNote   *string    `json:"gift_note,omitempty" db:"gift_note"`
+	Status     string     `json:"status" db:"status"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	TradedAt   *time.Time `json:"traded_at,omitempty" db:"traded_at"`
+}
+
+// InfoResponse представляет ответ на запрос информации о пользователе.
+// Coins — общий баланс, из него AvailableCoins можно потратить, а HeldCoins удержаны.
+// ExpiringSoon показывает, какие монеты баланса скоро сгорят
 type InfoResponse struct {
-	Coins       int64                  `json:"coins"`
-	Inventory   []InventoryItem        `json:"inventory"`
-	CoinHistory CoinTransactionHistory `json:"coinHistory"`
+	Coins          int64                  `json:"coins"`
+	AvailableCoins int64                  `json:"availableCoins"`
+	HeldCoins      int64                  `json:"heldCoins"`
+	ExpiringSoon   []ExpiringCoins        `json:"expiringSoon"`
+	Inventory      []InventoryItem        `json:"inventory"`
+	CoinHistory    CoinTransactionHistory `json:"coinHistory"`
+	Gifts          GiftHistory            `json:"gifts"`
+}
+
+// GiftHistory представляет подарки, полученные и отправленные пользователем
+type GiftHistory struct {
+	Received []Gift `json:"received"`
+	Sent     []Gift `json:"sent"`
+}
+
+// Gift представляет мерч, подаренный одним пользователем другому в рамках заказа
+type Gift struct {
+	OrderID    *int64    `json:"orderId,omitempty" db:"order_id"`
+	FromUserID int64     `json:"-" db:"from_user_id"`
+	FromUser   string    `json:"fromUser,omitempty" db:"from_user"`
+	ToUserID   int64     `json:"-" db:"to_user_id"`
+	ToUser     string    `json:"toUser,omitempty" db:"to_user"`
+	Item       string    `json:"item" db:"item"`
+	Variant    *string   `json:"variant,omitempty" db:"variant"`
+	Quantity   int       `json:"quantity" db:"quantity"`
+	Note       *string   `json:"note,omitempty" db:"note"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
 }
 
 // InventoryItem представляет предмет в инвентаре пользователя
 type InventoryItem struct {
-	Type     string `json:"type"`
-	Quantity int    `json:"quantity"`
+	Type       string     `json:"type"`
+	Variant    string     `json:"variant,omitempty"`
+	Attributes Attributes `json:"attributes,omitempty"`
+	Quantity   int        `json:"quantity"`
+	// ItemIDs — идентификаторы отдельных предметов, по ним оформляется возврат
+	ItemIDs []int64 `json:"itemIds"`
 }
 
 // CoinTransactionHistory представляет историю транзакций пользователя
@@ -56,9 +352,11 @@ type CoinTransactionHistory struct {
 
 // CoinTransaction представляет отдельную транзакцию
 type CoinTransaction struct {
+	ID       int64  `json:"id,omitempty"`
 	FromUser string `json:"fromUser,omitempty"`
 	ToUser   string `json:"toUser,omitempty"`
 	Amount   int64  `json:"amount"`
+	Kind     string `json:"kind,omitempty"`
 }
 
 // AuthRequest представляет запрос на аутентификацию
@@ -77,3 +375,677 @@ type SendCoinRequest struct {
 	ToUser string `json:"toUser" binding:"required"`
 	Amount int64  `json:"amount" binding:"required,gt=0"`
 }
+
+// Статусы запроса на перевод монет
+const (
+	PaymentRequestPending  = "pending"
+	PaymentRequestApproved = "approved"
+	PaymentRequestDeclined = "declined"
+	PaymentRequestExpired  = "expired"
+)
+
+// PaymentRequest представляет запрос на перевод монет от одного пользователя другому
+type PaymentRequest struct {
+	ID          int64      `json:"id" db:"id"`
+	RequesterID int64      `json:"requester_id" db:"requester_id"`
+	Requester   string     `json:"requester" db:"requester"`
+	PayerID     int64      `json:"payer_id" db:"payer_id"`
+	Payer       string     `json:"payer" db:"payer"`
+	Amount      int64      `json:"amount" db:"amount"`
+	Comment     string     `json:"comment" db:"comment"`
+	Status      string     `json:"status" db:"status"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// CreatePaymentRequestRequest представляет запрос на создание запроса на перевод
+type CreatePaymentRequestRequest struct {
+	FromUser string `json:"fromUser" binding:"required"`
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Comment  string `json:"comment"`
+}
+
+// Периодичность запланированного перевода
+const (
+	RecurrenceOnce    = "once"
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// Статусы запланированного перевода
+const (
+	ScheduleActive    = "active"
+	SchedulePaused    = "paused"
+	ScheduleCancelled = "cancelled"
+	ScheduleCompleted = "completed"
+	ScheduleFailed    = "failed"
+)
+
+// ScheduledTransfer представляет запланированный или регулярный перевод монет
+type ScheduledTransfer struct {
+	ID         int64      `json:"id" db:"id"`
+	FromUserID int64      `json:"from_user_id" db:"from_user_id"`
+	ToUserID   int64      `json:"to_user_id" db:"to_user_id"`
+	ToUsername string     `json:"to_user" db:"to_username"`
+	Amount     int64      `json:"amount" db:"amount"`
+	Comment    string     `json:"comment" db:"comment"`
+	Recurrence string     `json:"recurrence" db:"recurrence"`
+	Status     string     `json:"status" db:"status"`
+	NextRunAt  time.Time  `json:"next_run_at" db:"next_run_at"`
+	AnchorAt   time.Time  `json:"-" db:"anchor_at"`
+	Attempts   int        `json:"attempts" db:"attempts"`
+	LastError  string     `json:"last_error,omitempty" db:"last_error"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateScheduledTransferRequest представляет запрос на создание запланированного перевода
+type CreateScheduledTransferRequest struct {
+	ToUser     string    `json:"toUser" binding:"required"`
+	Amount     int64     `json:"amount" binding:"required,gt=0"`
+	Comment    string    `json:"comment"`
+	RunAt      time.Time `json:"runAt" binding:"required"`
+	Recurrence string    `json:"recurrence"`
+}
+
+// Правила обнаружения подозрительных операций
+const (
+	FraudRuleCircular = "circular_transfers"
+	FraudRuleFunnel   = "new_account_funnel"
+	FraudRuleBurst    = "activity_burst"
+)
+
+// Статусы оповещения о подозрительной операции
+const (
+	AlertOpen      = "open"
+	AlertResolved  = "resolved"
+	AlertDismissed = "dismissed"
+)
+
+// FraudSignal представляет найденный подозрительный паттерн до оценки
+type FraudSignal struct {
+	UserID        int64   `db:"user_id"`
+	TransactionID int64   `db:"transaction_id"`
+	Value         float64 `db:"value"`
+	Baseline      float64 `db:"baseline"`
+}
+
+// Alert представляет оповещение о подозрительной операции
+type Alert struct {
+	ID            int64      `json:"id" db:"id"`
+	UserID        int64      `json:"user_id" db:"user_id"`
+	Username      string     `json:"username" db:"username"`
+	TransactionID *int64     `json:"transaction_id,omitempty" db:"transaction_id"`
+	Rule          string     `json:"rule" db:"rule"`
+	Score         int        `json:"score" db:"score"`
+	Details       string     `json:"details" db:"details"`
+	Status        string     `json:"status" db:"status"`
+	HoldApplied   bool       `json:"hold_applied" db:"hold_applied"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ReviewedBy    *int64     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// ReviewAlertRequest представляет решение администратора по оповещению
+type ReviewAlertRequest struct {
+	Status      string `json:"status" binding:"required,oneof=resolved dismissed"`
+	ReleaseHold bool   `json:"releaseHold"`
+}
+
+// Статусы спора по переводу
+const (
+	DisputeOpen     = "open"
+	DisputeResolved = "resolved"
+	DisputeRejected = "rejected"
+)
+
+// Dispute представляет спор пользователя по отправленному переводу
+type Dispute struct {
+	ID            int64      `json:"id" db:"id"`
+	TransactionID int64      `json:"transaction_id" db:"transaction_id"`
+	OpenedBy      int64      `json:"opened_by" db:"opened_by"`
+	ToUser        string     `json:"to_user" db:"to_user"`
+	Amount        int64      `json:"amount" db:"amount"`
+	Reason        string     `json:"reason" db:"reason"`
+	Status        string     `json:"status" db:"status"`
+	Resolution    string     `json:"resolution,omitempty" db:"resolution"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ResolvedBy    *int64     `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// OpenDisputeRequest представляет запрос на открытие спора
+type OpenDisputeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ResolveDisputeRequest представляет решение администратора по спору
+type ResolveDisputeRequest struct {
+	Resolution string `json:"resolution"`
+}
+
+// Типы и статусы заявки на возврат
+const (
+	ReturnKindReturn   = "return"
+	ReturnKindExchange = "exchange"
+
+	ReturnPending  = "pending"
+	ReturnApproved = "approved"
+	ReturnRejected = "rejected"
+)
+
+// MerchReturn представляет заявку на возврат купленного мерча за монеты или на обмен на другой товар.
+// Refund — сумма возврата, Charge — доплата за обмен на более дорогой товар
+type MerchReturn struct {
+	ID                int64      `json:"id" db:"id"`
+	UserMerchID       int64      `json:"user_merch_id" db:"user_merch_id"`
+	UserID            int64      `json:"user_id" db:"user_id"`
+	MerchName         string     `json:"merch_name" db:"merch_name"`
+	Kind              string     `json:"kind" db:"kind"`
+	ExchangeMerchID   *int64     `json:"exchange_merch_id,omitempty" db:"exchange_merch_id"`
+	ExchangeVariantID *int64     `json:"exchange_variant_id,omitempty" db:"exchange_variant_id"`
+	Reason            string     `json:"reason" db:"reason"`
+	Status            string     `json:"status" db:"status"`
+	Refund            int64      `json:"refund" db:"refund"`
+	Charge            int64      `json:"charge" db:"charge"`
+	TransactionID     *int64     `json:"transaction_id,omitempty" db:"transaction_id"`
+	Resolution        string     `json:"resolution,omitempty" db:"resolution"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	ResolvedBy        *int64     `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// CreateReturnRequest представляет заявку пользователя на возврат или обмен предмета из инвентаря.
+// Если задан ExchangeFor, предмет обменивается на указанный товар
+type CreateReturnRequest struct {
+	ItemID          int64  `json:"itemId" binding:"required"`
+	Reason          string `json:"reason"`
+	ExchangeFor     string `json:"exchangeFor"`
+	ExchangeVariant string `json:"exchangeVariant"`
+}
+
+// ReverseTransactionRequest представляет запрос администратора на отмену транзакции
+type ReverseTransactionRequest struct {
+	Reason        string `json:"reason"`
+	AllowNegative bool   `json:"allowNegative"`
+}
+
+// Статусы заказа
+const (
+	OrderPlaced         = "placed"
+	OrderConfirmed      = "confirmed"
+	OrderReadyForPickup = "ready_for_pickup"
+	OrderShipped        = "shipped"
+	OrderDelivered      = "delivered"
+	OrderCancelled      = "cancelled"
+)
+
+// Order представляет заказ на покупку мерча
+type Order struct {
+	ID          int64       `json:"id" db:"id"`
+	UserID      int64       `json:"user_id" db:"user_id"`
+	Status      string      `json:"status" db:"status"`
+	Total       int64       `json:"total" db:"total"`
+	PromoCodeID *int64      `json:"promo_code_id,omitempty" db:"promo_code_id"`
+	RecipientID *int64      `json:"recipient_id,omitempty" db:"recipient_id"`
+	GiftNote    *string     `json:"gift_note,omitempty" db:"gift_note"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+	Items       []OrderItem `json:"items" db:"-"`
+}
+
+// OrderItem представляет позицию заказа
+type OrderItem struct {
+	ID         int64      `json:"id" db:"id"`
+	OrderID    int64      `json:"order_id" db:"order_id"`
+	MerchID    int64      `json:"merch_id" db:"merch_id"`
+	MerchName  string     `json:"merch_name" db:"merch_name"`
+	VariantID  *int64     `json:"variant_id,omitempty" db:"variant_id"`
+	Attributes Attributes `json:"attributes,omitempty" db:"attributes"`
+	Quantity   int        `json:"quantity" db:"quantity"`
+	Price      int64      `json:"price" db:"price"`
+	BasePrice  int64      `json:"base_price" db:"base_price"`
+	Discount   int64      `json:"discount" db:"discount"`
+	DiscountID *int64     `json:"discount_id,omitempty" db:"discount_id"`
+	Category   string     `json:"-" db:"-"`
+}
+
+// Discount представляет скидку, действующую в заданный период.
+// Если не указаны ни товар, ни категория, скидка действует на весь каталог
+type Discount struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	MerchID   *int64    `json:"merch_id,omitempty" db:"merch_id"`
+	Category  *string   `json:"category,omitempty" db:"category"`
+	Percent   *int      `json:"percent,omitempty" db:"percent"`
+	Amount    *int64    `json:"amount,omitempty" db:"amount"`
+	StartsAt  time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt    time.Time `json:"ends_at" db:"ends_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// PromoCode представляет промокод с ограничениями на число использований
+type PromoCode struct {
+	ID             int64      `json:"id" db:"id"`
+	Code           string     `json:"code" db:"code"`
+	MerchID        *int64     `json:"merch_id,omitempty" db:"merch_id"`
+	Category       *string    `json:"category,omitempty" db:"category"`
+	Percent        *int       `json:"percent,omitempty" db:"percent"`
+	Amount         *int64     `json:"amount,omitempty" db:"amount"`
+	MaxUses        *int       `json:"max_uses,omitempty" db:"max_uses"`
+	MaxUsesPerUser *int       `json:"max_uses_per_user,omitempty" db:"max_uses_per_user"`
+	Used           int        `json:"used" db:"used"`
+	StartsAt       *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateDiscountRequest представляет запрос администратора на создание скидки
+type CreateDiscountRequest struct {
+	Name     string    `json:"name" binding:"required"`
+	Item     string    `json:"item"`
+	Category string    `json:"category"`
+	Percent  int       `json:"percent" binding:"gte=0,lte=100"`
+	Amount   int64     `json:"amount" binding:"gte=0"`
+	StartsAt time.Time `json:"startsAt" binding:"required"`
+	EndsAt   time.Time `json:"endsAt" binding:"required"`
+}
+
+// CreatePromoCodeRequest представляет запрос администратора на создание промокода
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" binding:"required"`
+	Item           string     `json:"item"`
+	Category       string     `json:"category"`
+	Percent        int        `json:"percent" binding:"gte=0,lte=100"`
+	Amount         int64      `json:"amount" binding:"gte=0"`
+	MaxUses        int        `json:"maxUses" binding:"gte=0"`
+	MaxUsesPerUser int        `json:"maxUsesPerUser" binding:"gte=0"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+}
+
+// BuyOptions представляет параметры покупки товара.
+// Если задан Recipient, товар покупается в подарок этому пользователю
+type BuyOptions struct {
+	VariantSKU string
+	Quantity   int
+	PromoCode  string
+	Recipient  string
+	GiftNote   string
+}
+
+// GiftMerchRequest представляет запрос на покупку товара в подарок
+type GiftMerchRequest struct {
+	ToUser    string `json:"toUser" binding:"required"`
+	Note      string `json:"note"`
+	Quantity  int    `json:"quantity"`
+	Variant   string `json:"variant"`
+	PromoCode string `json:"promoCode"`
+}
+
+// UpdateOrderStatusRequest представляет запрос администратора на смену статуса заказа
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// Статусы предложения обмена между пользователями
+const (
+	TradePending   = "pending"
+	TradeAccepted  = "accepted"
+	TradeRejected  = "rejected"
+	TradeCountered = "countered"
+	TradeCancelled = "cancelled"
+	TradeExpired   = "expired"
+)
+
+// Trade представляет предложение обмена: инициатор отдает предметы и монеты
+// в обмен на предметы и монеты получателя
+type Trade struct {
+	ID             int64       `json:"id" db:"id"`
+	ProposerID     int64       `json:"proposer_id" db:"proposer_id"`
+	Proposer       string      `json:"proposer" db:"proposer"`
+	RecipientID    int64       `json:"recipient_id" db:"recipient_id"`
+	Recipient      string      `json:"recipient" db:"recipient"`
+	OfferedCoins   int64       `json:"offered_coins" db:"offered_coins"`
+	RequestedCoins int64       `json:"requested_coins" db:"requested_coins"`
+	OfferedItems   []TradeItem `json:"offered_items" db:"-"`
+	RequestedItems []TradeItem `json:"requested_items" db:"-"`
+	Message        string      `json:"message" db:"message"`
+	Status         string      `json:"status" db:"status"`
+	CounterOf      *int64      `json:"counter_of,omitempty" db:"counter_of"`
+	HoldID         *int64      `json:"-" db:"hold_id"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time   `json:"expires_at" db:"expires_at"`
+	ResolvedAt     *time.Time  `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// TradeItem представляет предмет из инвентаря, участвующий в обмене
+type TradeItem struct {
+	TradeID     int64   `json:"-" db:"trade_id"`
+	UserMerchID int64   `json:"item_id" db:"user_merch_id"`
+	OwnerID     int64   `json:"owner_id" db:"owner_id"`
+	Item        string  `json:"item" db:"item"`
+	Variant     *string `json:"variant,omitempty" db:"variant"`
+}
+
+// TradeTerms представляет условия обмена с точки зрения инициатора:
+// что он отдает (Offer*) и что хочет получить (Request*)
+type TradeTerms struct {
+	OfferItems   []int64 `json:"offerItems"`
+	OfferCoins   int64   `json:"offerCoins" binding:"gte=0"`
+	RequestItems []int64 `json:"requestItems"`
+	RequestCoins int64   `json:"requestCoins" binding:"gte=0"`
+	Message      string  `json:"message"`
+}
+
+// CreateTradeRequest представляет предложение обмена другому пользователю
+type CreateTradeRequest struct {
+	ToUser string `json:"toUser" binding:"required"`
+	TradeTerms
+}
+
+// Статусы объявления на маркетплейсе
+const (
+	ListingActive    = "active"
+	ListingSold      = "sold"
+	ListingCancelled = "cancelled"
+)
+
+// MarketListing представляет объявление о продаже предмета из инвентаря другим пользователям.
+// Fee — комиссия площадки, удержанная с продавца
+type MarketListing struct {
+	ID          int64      `json:"id" db:"id"`
+	UserMerchID int64      `json:"item_id" db:"user_merch_id"`
+	SellerID    int64      `json:"seller_id" db:"seller_id"`
+	Seller      string     `json:"seller" db:"seller"`
+	Item        string     `json:"item" db:"item"`
+	Variant     *string    `json:"variant,omitempty" db:"variant"`
+	Price       int64      `json:"price" db:"price"`
+	Fee         int64      `json:"fee" db:"fee"`
+	Status      string     `json:"status" db:"status"`
+	BuyerID     *int64     `json:"buyer_id,omitempty" db:"buyer_id"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+// MarketFilter описывает параметры поиска активных объявлений
+type MarketFilter struct {
+	Item     string
+	MaxPrice int64
+	Limit    int
+	Offset   int
+}
+
+// CreateListingRequest представляет запрос на выставление предмета на продажу
+type CreateListingRequest struct {
+	ItemID int64 `json:"itemId" binding:"required"`
+	Price  int64 `json:"price" binding:"required,gt=0"`
+}
+
+// Статусы аукциона и ставки
+const (
+	AuctionScheduled = "scheduled"
+	AuctionOpen      = "open"
+	AuctionClosed    = "closed"
+
+	BidActive  = "active"
+	BidOutbid  = "outbid"
+	BidWon     = "won"
+	BidExpired = "expired"
+)
+
+// Auction представляет аукцион на несколько единиц товара. Побеждают Quantity самых высоких ставок,
+// каждый победитель платит свою ставку. MinimumBid — сумма, с которой сейчас можно войти в число победителей
+type Auction struct {
+	ID         int64        `json:"id" db:"id"`
+	MerchID    int64        `json:"merch_id" db:"merch_id"`
+	Item       string       `json:"item" db:"item"`
+	VariantID  *int64       `json:"variant_id,omitempty" db:"variant_id"`
+	Variant    *string      `json:"variant,omitempty" db:"variant"`
+	Quantity   int          `json:"quantity" db:"quantity"`
+	MinBid     int64        `json:"min_bid" db:"min_bid"`
+	MinimumBid int64        `json:"minimum_bid" db:"-"`
+	ActiveBids int          `json:"active_bids" db:"active_bids"`
+	LowestBid  *int64       `json:"-" db:"lowest_bid"`
+	StartsAt   time.Time    `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time    `json:"ends_at" db:"ends_at"`
+	Status     string       `json:"status" db:"status"`
+	CreatedBy  *int64       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	ClosedAt   *time.Time   `json:"closed_at,omitempty" db:"closed_at"`
+	Bids       []AuctionBid `json:"bids,omitempty" db:"-"`
+}
+
+// AuctionBid представляет ставку пользователя на аукционе
+type AuctionBid struct {
+	ID        int64     `json:"id" db:"id"`
+	AuctionID int64     `json:"auction_id" db:"auction_id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	User      string    `json:"user" db:"username"`
+	Amount    int64     `json:"amount" db:"amount"`
+	Status    string    `json:"status" db:"status"`
+	HoldID    *int64    `json:"-" db:"hold_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateAuctionRequest представляет запрос администратора на создание аукциона.
+// Если StartsAt не задан, аукцион начинается сразу
+type CreateAuctionRequest struct {
+	Item     string     `json:"item" binding:"required"`
+	Variant  string     `json:"variant"`
+	Quantity int        `json:"quantity" binding:"required,gt=0"`
+	MinBid   int64      `json:"minBid" binding:"required,gt=0"`
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   time.Time  `json:"endsAt" binding:"required"`
+}
+
+// PlaceBidRequest представляет ставку или повышение ставки на аукционе
+type PlaceBidRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+// Статусы розыгрыша
+const (
+	RaffleOpen  = "open"
+	RaffleDrawn = "drawn"
+)
+
+// Raffle представляет розыгрыш нескольких единиц товара среди купивших билеты.
+// SeedHash публикуется заранее, Seed раскрывается только после розыгрыша.
+// MyTickets и WinChance рассчитываются для пользователя, запросившего розыгрыш
+type Raffle struct {
+	ID          int64          `json:"id" db:"id"`
+	MerchID     int64          `json:"merch_id" db:"merch_id"`
+	Item        string         `json:"item" db:"item"`
+	VariantID   *int64         `json:"variant_id,omitempty" db:"variant_id"`
+	Variant     *string        `json:"variant,omitempty" db:"variant"`
+	Quantity    int            `json:"quantity" db:"quantity"`
+	TicketPrice int64          `json:"ticket_price" db:"ticket_price"`
+	TicketsSold int            `json:"tickets_sold" db:"tickets_sold"`
+	DrawAt      time.Time      `json:"draw_at" db:"draw_at"`
+	SeedHash    string         `json:"seed_hash" db:"seed_hash"`
+	Seed        *string        `json:"seed,omitempty" db:"seed"`
+	Status      string         `json:"status" db:"status"`
+	CreatedBy   *int64         `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	DrawnAt     *time.Time     `json:"drawn_at,omitempty" db:"drawn_at"`
+	MyTickets   int            `json:"my_tickets" db:"-"`
+	WinChance   float64        `json:"win_chance" db:"-"`
+	Entries     []RaffleEntry  `json:"entries,omitempty" db:"-"`
+	Winners     []RaffleWinner `json:"winners,omitempty" db:"-"`
+}
+
+// RaffleEntry представляет покупку билетов с номерами с FirstTicket по FirstTicket+Tickets-1
+type RaffleEntry struct {
+	ID          int64     `json:"id" db:"id"`
+	RaffleID    int64     `json:"raffle_id" db:"raffle_id"`
+	UserID      int64     `json:"user_id" db:"user_id"`
+	User        string    `json:"user" db:"username"`
+	Tickets     int       `json:"tickets" db:"tickets"`
+	FirstTicket int       `json:"first_ticket" db:"first_ticket"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// RaffleWinner представляет победителя розыгрыша и выигравший билет.
+// Position — порядковый номер победителя при розыгрыше, начиная с 1
+type RaffleWinner struct {
+	RaffleID int64  `json:"raffle_id" db:"raffle_id"`
+	UserID   int64  `json:"user_id" db:"user_id"`
+	User     string `json:"user" db:"username"`
+	Ticket   int    `json:"ticket" db:"ticket"`
+	Position int    `json:"position" db:"position"`
+}
+
+// CreateRaffleRequest представляет запрос администратора на создание розыгрыша
+type CreateRaffleRequest struct {
+	Item        string    `json:"item" binding:"required"`
+	Variant     string    `json:"variant"`
+	Quantity    int       `json:"quantity" binding:"required,gt=0"`
+	TicketPrice int64     `json:"ticketPrice" binding:"required,gt=0"`
+	DrawAt      time.Time `json:"drawAt" binding:"required"`
+}
+
+// BuyTicketsRequest представляет покупку билетов розыгрыша
+type BuyTicketsRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+// Статусы удержания монет
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+// CoinHold представляет удержание монет под незавершенную сделку. Удержанные монеты остаются
+// в общем балансе пользователя, но недоступны для трат, пока удержание не списано или не снято
+type CoinHold struct {
+	ID         int64      `json:"id" db:"id"`
+	UserID     int64      `json:"user_id" db:"user_id"`
+	Amount     int64      `json:"amount" db:"amount"`
+	Reason     string     `json:"reason" db:"reason"`
+	Status     string     `json:"status" db:"status"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// CoinLot представляет партию монет, начисленных одним зачислением. Remaining — сколько монет
+// партии еще не потрачено; траты расходуют самые старые партии первыми
+type CoinLot struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	Amount    int64      `json:"amount" db:"amount"`
+	Remaining int64      `json:"remaining" db:"remaining"`
+	GrantedAt time.Time  `json:"granted_at" db:"granted_at"`
+	WarnedAt  *time.Time `json:"warned_at,omitempty" db:"warned_at"`
+}
+
+// ExpiringCoins показывает, сколько монет баланса сгорит в указанный момент
+type ExpiringCoins struct {
+	Amount    int64     `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Achievement представляет достижение из каталога. Bonus — монеты, начисляемые вместе со значком
+type Achievement struct {
+	Code        string `json:"code" db:"code"`
+	Title       string `json:"title" db:"title"`
+	Description string `json:"description" db:"description"`
+	Bonus       int64  `json:"bonus" db:"bonus"`
+}
+
+// UserAchievement представляет выданное пользователю достижение. Level больше единицы
+// у повторяющихся достижений, например номер годовщины работы
+type UserAchievement struct {
+	UserID      int64     `json:"-" db:"user_id"`
+	Code        string    `json:"code" db:"code"`
+	Title       string    `json:"title" db:"title"`
+	Description string    `json:"description" db:"description"`
+	Level       int       `json:"level" db:"level"`
+	Bonus       int64     `json:"bonus" db:"bonus"`
+	AwardedAt   time.Time `json:"awarded_at" db:"awarded_at"`
+}
+
+// WorkAnniversary показывает, сколько полных лет пользователь работает в компании
+type WorkAnniversary struct {
+	UserID int64 `db:"user_id"`
+	Years  int   `db:"years"`
+}
+
+// Рейтинги переводов
+const (
+	LeaderboardSenders    = "senders"
+	LeaderboardReceivers  = "receivers"
+	LeaderboardRecipients = "recipients"
+)
+
+// Периоды рейтингов
+const (
+	LeaderboardWeek  = "week"
+	LeaderboardMonth = "month"
+	LeaderboardAll   = "all"
+)
+
+// Leaderboard представляет рейтинг пользователей по переводам за период
+type Leaderboard struct {
+	Board   string             `json:"board"`
+	Period  string             `json:"period"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// LeaderboardEntry представляет строку рейтинга. Value — подаренные или полученные монеты
+// либо число разных получателей, в зависимости от рейтинга
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank" db:"rank"`
+	Username string `json:"username" db:"username"`
+	Value    int64  `json:"value" db:"value"`
+}
+
+// Роли участников команды
+const (
+	TeamRoleMember  = "member"
+	TeamRoleManager = "manager"
+)
+
+// Team представляет команду с общим кошельком. Balance — монеты на кошельке команды,
+// Role — роль пользователя, запросившего команду
+type Team struct {
+	ID           int64        `json:"id" db:"id"`
+	Name         string       `json:"name" db:"name"`
+	WalletUserID int64        `json:"-" db:"wallet_user_id"`
+	Balance      int64        `json:"balance" db:"balance"`
+	Role         string       `json:"role,omitempty" db:"role"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	Members      []TeamMember `json:"members,omitempty" db:"-"`
+}
+
+// TeamMember представляет участника команды
+type TeamMember struct {
+	TeamID   int64     `json:"-" db:"team_id"`
+	UserID   int64     `json:"user_id" db:"user_id"`
+	Username string    `json:"username" db:"username"`
+	Role     string    `json:"role" db:"role"`
+	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// CreateTeamRequest представляет запрос администратора на создание команды
+type CreateTeamRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// SetTeamMemberRequest представляет запрос администратора на добавление участника или смену его роли
+type SetTeamMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role"`
+}
+
+// TeamGrantRequest представляет запрос администратора на пополнение кошелька команды
+type TeamGrantRequest struct {
+	Amount  int64  `json:"amount" binding:"required,gt=0"`
+	Comment string `json:"comment"`
+}
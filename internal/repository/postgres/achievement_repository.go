@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// AchievementRepository реализует интерфейс repository.AchievementRepository
+type AchievementRepository struct {
+	db *sqlx.DB
+}
+
+// NewAchievementRepository создает новый экземпляр AchievementRepository
+func NewAchievementRepository(db *sqlx.DB) *AchievementRepository {
+	return &AchievementRepository{
+		db: db,
+	}
+}
+
+// GetByCode получает достижение из каталога по коду
+func (r *AchievementRepository) GetByCode(ctx context.Context, code string) (*models.Achievement, error) {
+	achievement := &models.Achievement{}
+	query := `
+		SELECT code, title, description, bonus
+		FROM achievements
+		WHERE code = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, achievement, query, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("achievement not found")
+		}
+		return nil, err
+	}
+
+	return achievement, nil
+}
+
+// Award выдает достижение пользователю. Возвращает false, если оно уже было выдано
+func (r *AchievementRepository) Award(ctx context.Context, userID int64, code string, level int) (bool, error) {
+	query := `
+		INSERT INTO user_achievements (user_id, code, level)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, code, level)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// GetUserAchievements получает достижения пользователя, последние выданные первыми
+func (r *AchievementRepository) GetUserAchievements(ctx context.Context, userID int64) ([]models.UserAchievement, error) {
+	query := `
+		SELECT ua.user_id, ua.code, a.title, a.description, ua.level, a.bonus, ua.awarded_at
+		FROM user_achievements ua
+		JOIN achievements a ON a.code = ua.code
+		WHERE ua.user_id = $1
+		ORDER BY ua.awarded_at DESC, ua.code, ua.level DESC`
+
+	achievements := make([]models.UserAchievement, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &achievements, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
+
+// GetDueAnniversaries получает пользователей, у которых наступила годовщина работы,
+// за которую еще не выдано достижение code. Началом работы считается дата регистрации,
+// системные аккаунты, в том числе кошельки команд, не учитываются
+func (r *AchievementRepository) GetDueAnniversaries(ctx context.Context, code string, limit int) ([]models.WorkAnniversary, error) {
+	query := `
+		SELECT user_id, years
+		FROM (
+			SELECT id AS user_id, EXTRACT(YEAR FROM AGE(NOW(), created_at))::INT AS years
+			FROM users
+			WHERE NOT is_system
+		) u
+		WHERE years > 0 AND NOT EXISTS (
+			SELECT 1
+			FROM user_achievements ua
+			WHERE ua.user_id = u.user_id AND ua.code = $1 AND ua.level = u.years
+		)
+		ORDER BY user_id
+		LIMIT $2`
+
+	var anniversaries []models.WorkAnniversary
+	err := conn(ctx, r.db).SelectContext(ctx, &anniversaries, query, code, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return anniversaries, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const alertColumns = `
+		a.id, a.user_id, u.username, a.transaction_id, a.rule, a.score, a.details,
+		a.status, a.hold_applied, a.created_at, a.reviewed_by, a.reviewed_at`
+
+// AlertRepository реализует интерфейс repository.AlertRepository
+type AlertRepository struct {
+	db *sqlx.DB
+}
+
+// NewAlertRepository создает новый экземпляр AlertRepository
+func NewAlertRepository(db *sqlx.DB) *AlertRepository {
+	return &AlertRepository{
+		db: db,
+	}
+}
+
+// Create создает оповещение. Возвращает false, если у пользователя уже есть
+// открытое оповещение по тому же правилу или если перевод, вызвавший оповещение,
+// был совершен до последнего рассмотрения такого оповещения администратором
+func (r *AlertRepository) Create(ctx context.Context, alert *models.Alert) (bool, error) {
+	query := `
+		INSERT INTO alerts (user_id, transaction_id, rule, score, details, hold_applied)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM alerts r
+			JOIN transactions t ON t.id = $2
+			WHERE r.user_id = $1 AND r.rule = $3 AND r.status <> 'open'
+				AND r.reviewed_at >= t.created_at
+		)
+		ON CONFLICT (user_id, rule) WHERE status = 'open' DO NOTHING
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		alert.UserID,
+		alert.TransactionID,
+		alert.Rule,
+		alert.Score,
+		alert.Details,
+		alert.HoldApplied,
+	).Scan(&alert.ID, &alert.Status, &alert.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetByStatus получает оповещения с указанным статусом, пустой статус — все оповещения
+func (r *AlertRepository) GetByStatus(ctx context.Context, status string) ([]models.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts a
+		JOIN users u ON u.id = a.user_id
+		WHERE $1 = '' OR a.status = $1
+		ORDER BY a.score DESC, a.created_at DESC`
+
+	alerts := make([]models.Alert, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &alerts, query, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// Review закрывает открытое оповещение решением администратора
+func (r *AlertRepository) Review(ctx context.Context, id, reviewerID int64, status string) (*models.Alert, error) {
+	alert := &models.Alert{}
+	query := `
+		UPDATE alerts a
+		SET status = $3, reviewed_by = $2, reviewed_at = NOW()
+		FROM users u
+		WHERE a.id = $1 AND a.status = 'open' AND u.id = a.user_id
+		RETURNING ` + alertColumns
+
+	err := conn(ctx, r.db).GetContext(ctx, alert, query, id, reviewerID, status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("alert not found or already reviewed")
+		}
+		return nil, err
+	}
+
+	return alert, nil
+}
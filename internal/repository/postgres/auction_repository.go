@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// auctionColumns — общий список колонок с вычисленным статусом еще не начавшихся аукционов
+// и сводкой по активным ставкам
+const auctionColumns = `
+		a.id, a.merch_id, m.name AS item, a.variant_id, v.sku AS variant, a.quantity, a.min_bid,
+		(SELECT COUNT(*) FROM auction_bids b WHERE b.auction_id = a.id AND b.status = 'active') AS active_bids,
+		(SELECT MIN(b.amount) FROM auction_bids b WHERE b.auction_id = a.id AND b.status = 'active') AS lowest_bid,
+		a.starts_at, a.ends_at,
+		CASE WHEN a.status = 'open' AND a.starts_at > NOW() THEN 'scheduled' ELSE a.status END AS status,
+		a.created_by, a.created_at, a.closed_at`
+
+const auctionJoins = `
+		JOIN merch_items m ON m.id = a.merch_id
+		LEFT JOIN merch_variants v ON v.id = a.variant_id`
+
+// AuctionRepository реализует интерфейс repository.AuctionRepository
+type AuctionRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuctionRepository создает новый экземпляр AuctionRepository
+func NewAuctionRepository(db *sqlx.DB) *AuctionRepository {
+	return &AuctionRepository{
+		db: db,
+	}
+}
+
+// Create создает аукцион
+func (r *AuctionRepository) Create(ctx context.Context, auction *models.Auction) error {
+	query := `
+		INSERT INTO auctions (merch_id, variant_id, quantity, min_bid, starts_at, ends_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		auction.MerchID,
+		auction.VariantID,
+		auction.Quantity,
+		auction.MinBid,
+		auction.StartsAt,
+		auction.EndsAt,
+		auction.CreatedBy,
+	).Scan(&auction.ID, &auction.Status, &auction.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByID получает аукцион по идентификатору
+func (r *AuctionRepository) GetByID(ctx context.Context, id int64) (*models.Auction, error) {
+	auction := &models.Auction{}
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions a` + auctionJoins + `
+		WHERE a.id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, auction, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("auction not found")
+		}
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+// GetByIDForUpdate получает аукцион и блокирует его до конца транзакции.
+// Блокировка аукциона упорядочивает конкурирующие ставки
+func (r *AuctionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Auction, error) {
+	auction := &models.Auction{}
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions a` + auctionJoins + `
+		WHERE a.id = $1
+		FOR UPDATE OF a`
+
+	err := conn(ctx, r.db).GetContext(ctx, auction, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("auction not found")
+		}
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+// GetOpen получает идущие и еще не начавшиеся аукционы, ближайшие к завершению первыми
+func (r *AuctionRepository) GetOpen(ctx context.Context) ([]models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions a` + auctionJoins + `
+		WHERE a.status = 'open'
+		ORDER BY a.ends_at`
+
+	auctions := make([]models.Auction, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &auctions, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return auctions, nil
+}
+
+// LockDue блокирует завершившиеся, но еще не закрытые аукционы. Строки, уже
+// заблокированные другим экземпляром приложения, пропускаются.
+// Должен вызываться внутри транзакции
+func (r *AuctionRepository) LockDue(ctx context.Context, limit int) ([]models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions a` + auctionJoins + `
+		WHERE a.status = 'open' AND a.ends_at <= NOW()
+		ORDER BY a.ends_at
+		LIMIT $1
+		FOR UPDATE OF a SKIP LOCKED`
+
+	var auctions []models.Auction
+	err := conn(ctx, r.db).SelectContext(ctx, &auctions, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return auctions, nil
+}
+
+// Close помечает аукцион закрытым
+func (r *AuctionRepository) Close(ctx context.Context, id int64) error {
+	query := `
+		UPDATE auctions
+		SET status = 'closed', closed_at = NOW()
+		WHERE id = $1 AND status = 'open'`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	return err
+}
+
+// GetBids получает все ставки аукциона от самой высокой к самой низкой.
+// При равных суммах выше стоит ставка, сделанная раньше
+func (r *AuctionRepository) GetBids(ctx context.Context, auctionID int64) ([]models.AuctionBid, error) {
+	query := `
+		SELECT b.id, b.auction_id, b.user_id, u.username, b.amount, b.status, b.hold_id, b.created_at, b.updated_at
+		FROM auction_bids b
+		JOIN users u ON u.id = b.user_id
+		WHERE b.auction_id = $1
+		ORDER BY b.amount DESC, b.updated_at ASC, b.id ASC`
+
+	bids := make([]models.AuctionBid, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &bids, query, auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+// SaveBid создает ставку пользователя или заменяет его прежнюю ставку новой суммой и удержанием
+func (r *AuctionRepository) SaveBid(ctx context.Context, bid *models.AuctionBid) error {
+	query := `
+		INSERT INTO auction_bids (auction_id, user_id, amount, hold_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (auction_id, user_id)
+		DO UPDATE SET amount = EXCLUDED.amount, hold_id = EXCLUDED.hold_id, status = 'active', updated_at = NOW()
+		RETURNING id, status, created_at, updated_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		bid.AuctionID,
+		bid.UserID,
+		bid.Amount,
+		bid.HoldID,
+	).Scan(&bid.ID, &bid.Status, &bid.CreatedAt, &bid.UpdatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetBidStatus меняет статус ставки
+func (r *AuctionRepository) SetBidStatus(ctx context.Context, bidID int64, status string) error {
+	query := `UPDATE auction_bids SET status = $2 WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, bidID, status)
+	return err
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// CartRepository реализует интерфейс repository.CartRepository
+type CartRepository struct {
+	db *sqlx.DB
+}
+
+// NewCartRepository создает новый экземпляр CartRepository
+func NewCartRepository(db *sqlx.DB) *CartRepository {
+	return &CartRepository{
+		db: db,
+	}
+}
+
+// GetItems получает позиции корзины с текущими ценами товаров и их вариантов
+func (r *CartRepository) GetItems(ctx context.Context, userID int64) ([]models.CartItem, error) {
+	query := `
+		SELECT ci.merch_id, m.name, ci.variant_id, v.sku AS variant_sku,
+		       COALESCE(v.price, m.price) AS price, ci.quantity
+		FROM cart_items ci
+		JOIN merch_items m ON m.id = ci.merch_id
+		LEFT JOIN merch_variants v ON v.id = ci.variant_id
+		WHERE ci.user_id = $1
+		ORDER BY ci.added_at`
+
+	items := make([]models.CartItem, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &items, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// AddItem добавляет товар или его вариант в корзину или увеличивает его количество
+func (r *CartRepository) AddItem(ctx context.Context, userID, merchID int64, variantID *int64, quantity int) error {
+	query := `
+		INSERT INTO cart_items (user_id, merch_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, merch_id, (COALESCE(variant_id, 0)))
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, merchID, variantID, quantity)
+	return err
+}
+
+// SetQuantity устанавливает количество товара или его варианта в корзине
+func (r *CartRepository) SetQuantity(ctx context.Context, userID, merchID int64, variantID *int64, quantity int) error {
+	query := `
+		INSERT INTO cart_items (user_id, merch_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, merch_id, (COALESCE(variant_id, 0)))
+		DO UPDATE SET quantity = EXCLUDED.quantity`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, merchID, variantID, quantity)
+	return err
+}
+
+// RemoveItem удаляет товар или его вариант из корзины
+func (r *CartRepository) RemoveItem(ctx context.Context, userID, merchID int64, variantID *int64) error {
+	query := `DELETE FROM cart_items WHERE user_id = $1 AND merch_id = $2 AND variant_id IS NOT DISTINCT FROM $3`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, merchID, variantID)
+	return err
+}
+
+// Clear очищает корзину пользователя
+func (r *CartRepository) Clear(ctx context.Context, userID int64) error {
+	query := `DELETE FROM cart_items WHERE user_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID)
+	return err
+}
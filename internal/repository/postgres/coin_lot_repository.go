@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// CoinLotRepository реализует интерфейс repository.CoinLotRepository
+type CoinLotRepository struct {
+	db *sqlx.DB
+}
+
+// NewCoinLotRepository создает новый экземпляр CoinLotRepository
+func NewCoinLotRepository(db *sqlx.DB) *CoinLotRepository {
+	return &CoinLotRepository{
+		db: db,
+	}
+}
+
+// GetActive получает партии пользователя с ненулевым остатком, самые старые первыми
+func (r *CoinLotRepository) GetActive(ctx context.Context, userID int64) ([]models.CoinLot, error) {
+	query := `
+		SELECT id, user_id, amount, remaining, granted_at, warned_at
+		FROM coin_lots
+		WHERE user_id = $1 AND remaining > 0
+		ORDER BY granted_at, id`
+
+	lots := make([]models.CoinLot, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &lots, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lots, nil
+}
+
+// GetUsersWithExpired получает пользователей, у которых остались монеты из партий,
+// начисленных раньше grantedBefore. Пользователи, все монеты которых удержаны, пропускаются:
+// списать у них пока нечего, и иначе они занимали бы каждую выборку
+func (r *CoinLotRepository) GetUsersWithExpired(ctx context.Context, grantedBefore time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT DISTINCT l.user_id
+		FROM coin_lots l
+		JOIN users u ON u.id = l.user_id
+		WHERE l.remaining > 0 AND l.granted_at < $1 AND u.coins > u.held_coins
+		ORDER BY l.user_id
+		LIMIT $2`
+
+	var userIDs []int64
+	err := conn(ctx, r.db).SelectContext(ctx, &userIDs, query, grantedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// SumRemaining возвращает остаток монет пользователя в партиях, начисленных раньше grantedBefore
+func (r *CoinLotRepository) SumRemaining(ctx context.Context, userID int64, grantedBefore time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(remaining), 0)
+		FROM coin_lots
+		WHERE user_id = $1 AND remaining > 0 AND granted_at < $2`
+
+	var total int64
+	err := conn(ctx, r.db).GetContext(ctx, &total, query, userID, grantedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// MarkWarned отмечает партии, начисленные раньше grantedBefore, о сгорании которых
+// пользователь еще не предупрежден, и возвращает их. Партии, уже обрабатываемые
+// другим экземпляром приложения, пропускаются
+func (r *CoinLotRepository) MarkWarned(ctx context.Context, grantedBefore time.Time, limit int) ([]models.CoinLot, error) {
+	query := `
+		UPDATE coin_lots
+		SET warned_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM coin_lots
+			WHERE remaining > 0 AND warned_at IS NULL AND granted_at < $1
+			ORDER BY granted_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, amount, remaining, granted_at, warned_at`
+
+	var lots []models.CoinLot
+	err := conn(ctx, r.db).SelectContext(ctx, &lots, query, grantedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return lots, nil
+}
+
+// addCoinLot заводит партию под зачисленные монеты. Если баланс был отрицательным,
+// зачисление сначала покрывает долг, и в партию попадает только остаток
+func addCoinLot(ctx context.Context, db *sqlx.DB, userID, amount, balance int64) error {
+	remaining := amount
+	if balance < amount {
+		remaining = max(balance, 0)
+	}
+
+	query := `
+		INSERT INTO coin_lots (user_id, amount, remaining)
+		VALUES ($1, $2, $3)`
+
+	_, err := conn(ctx, db).ExecContext(ctx, query, userID, amount, remaining)
+	return err
+}
+
+// consumeCoinLots расходует amount монет из партий пользователя, начиная с самых старых,
+// и запоминает, сколько взято из каждой партии, чтобы возврат монет вернул их в те же партии.
+// Вызывается после списания с баланса, когда строка пользователя уже заблокирована
+func consumeCoinLots(ctx context.Context, db *sqlx.DB, userID, amount int64) error {
+	query := `
+		WITH spent AS (
+			UPDATE coin_lots l
+			SET remaining = l.remaining - LEAST(l.remaining, $2 - f.before)
+			FROM (
+				SELECT id, remaining, SUM(remaining) OVER (ORDER BY granted_at, id) - remaining AS before
+				FROM coin_lots
+				WHERE user_id = $1 AND remaining > 0
+			) f
+			WHERE l.id = f.id AND f.before < $2
+			RETURNING l.id, LEAST(f.remaining, $2 - f.before) AS amount
+		)
+		INSERT INTO coin_lot_spends (lot_id, user_id, amount)
+		SELECT id, $1, amount
+		FROM spent`
+
+	_, err := conn(ctx, db).ExecContext(ctx, query, userID, amount)
+	return err
+}
+
+// restoreCoinLots возвращает amount зачисленных монет в партии, из которых они были
+// потрачены в транзакции, начатой в spentAt, поэтому возврат не продлевает срок жизни монет.
+// Монеты, для которых списание не найдено, образуют новую партию
+func restoreCoinLots(ctx context.Context, db *sqlx.DB, userID, amount, balance int64, spentAt time.Time) error {
+	remaining := amount
+	if balance < amount {
+		remaining = max(balance, 0)
+	}
+
+	query := `
+		WITH restored AS (
+			UPDATE coin_lot_spends s
+			SET amount = s.amount - LEAST(s.amount, $3 - f.before)
+			FROM (
+				SELECT s.id, s.amount, SUM(s.amount) OVER (ORDER BY l.granted_at, s.id) - s.amount AS before
+				FROM coin_lot_spends s
+				JOIN coin_lots l ON l.id = s.lot_id
+				WHERE s.user_id = $1 AND s.spent_at = $2 AND s.amount > 0
+			) f
+			WHERE s.id = f.id AND f.before < $3
+			RETURNING s.lot_id, LEAST(f.amount, $3 - f.before) AS amount
+		), lots AS (
+			UPDATE coin_lots l
+			SET remaining = l.remaining + r.amount
+			FROM (SELECT lot_id, SUM(amount) AS amount FROM restored GROUP BY lot_id) r
+			WHERE l.id = r.lot_id
+			RETURNING r.amount
+		)
+		SELECT COALESCE(SUM(amount), 0) FROM lots`
+
+	var restored int64
+	if remaining > 0 {
+		err := conn(ctx, db).GetContext(ctx, &restored, query, userID, spentAt, remaining)
+		if err != nil {
+			return err
+		}
+	}
+
+	if restored == amount {
+		return nil
+	}
+
+	insert := `
+		INSERT INTO coin_lots (user_id, amount, remaining)
+		VALUES ($1, $2, $3)`
+
+	_, err := conn(ctx, db).ExecContext(ctx, insert, userID, amount-restored, remaining-restored)
+	return err
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// DiscountRepository реализует интерфейс repository.DiscountRepository
+type DiscountRepository struct {
+	db *sqlx.DB
+}
+
+// NewDiscountRepository создает новый экземпляр DiscountRepository
+func NewDiscountRepository(db *sqlx.DB) *DiscountRepository {
+	return &DiscountRepository{
+		db: db,
+	}
+}
+
+// Create создает скидку
+func (r *DiscountRepository) Create(ctx context.Context, discount *models.Discount) error {
+	query := `
+		INSERT INTO discounts (name, merch_id, category, percent, amount, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		discount.Name,
+		discount.MerchID,
+		discount.Category,
+		discount.Percent,
+		discount.Amount,
+		discount.StartsAt,
+		discount.EndsAt,
+	).Scan(&discount.ID, &discount.CreatedAt)
+}
+
+// GetActive получает скидки, действующие в момент at
+func (r *DiscountRepository) GetActive(ctx context.Context, at time.Time) ([]models.Discount, error) {
+	query := `
+		SELECT id, name, merch_id, category, percent, amount, starts_at, ends_at, created_at
+		FROM discounts
+		WHERE starts_at <= $1 AND ends_at > $1`
+
+	discounts := make([]models.Discount, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &discounts, query, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return discounts, nil
+}
+
+// GetAll получает все скидки, начиная с последних
+func (r *DiscountRepository) GetAll(ctx context.Context) ([]models.Discount, error) {
+	query := `
+		SELECT id, name, merch_id, category, percent, amount, starts_at, ends_at, created_at
+		FROM discounts
+		ORDER BY starts_at DESC`
+
+	discounts := make([]models.Discount, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &discounts, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return discounts, nil
+}
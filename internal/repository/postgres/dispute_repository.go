@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const disputeColumns = `
+		d.id, d.transaction_id, d.opened_by, COALESCE(u.username, '') AS to_user, t.amount,
+		d.reason, d.status, d.resolution, d.created_at, d.resolved_by, d.resolved_at`
+
+const disputeJoins = `
+		JOIN transactions t ON t.id = d.transaction_id
+		LEFT JOIN users u ON u.id = t.to_user_id`
+
+// DisputeRepository реализует интерфейс repository.DisputeRepository
+type DisputeRepository struct {
+	db *sqlx.DB
+}
+
+// NewDisputeRepository создает новый экземпляр DisputeRepository
+func NewDisputeRepository(db *sqlx.DB) *DisputeRepository {
+	return &DisputeRepository{
+		db: db,
+	}
+}
+
+// Create открывает спор по транзакции
+func (r *DisputeRepository) Create(ctx context.Context, dispute *models.Dispute) error {
+	query := `
+		INSERT INTO disputes (transaction_id, opened_by, reason)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		dispute.TransactionID,
+		dispute.OpenedBy,
+		dispute.Reason,
+	).Scan(&dispute.ID, &dispute.Status, &dispute.CreatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return errors.New("dispute for this transaction is already open")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetUserDisputes получает споры, открытые пользователем
+func (r *DisputeRepository) GetUserDisputes(ctx context.Context, userID int64) ([]models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes d` + disputeJoins + `
+		WHERE d.opened_by = $1
+		ORDER BY d.created_at DESC`
+
+	disputes := make([]models.Dispute, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &disputes, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+// GetByStatus получает очередь споров с указанным статусом, пустой статус — все споры
+func (r *DisputeRepository) GetByStatus(ctx context.Context, status string) ([]models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes d` + disputeJoins + `
+		WHERE $1 = '' OR d.status = $1
+		ORDER BY d.created_at ASC`
+
+	disputes := make([]models.Dispute, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &disputes, query, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+// Resolve закрывает открытый спор
+func (r *DisputeRepository) Resolve(ctx context.Context, id, adminID int64, status, resolution string) error {
+	query := `
+		UPDATE disputes
+		SET status = $3, resolution = $4, resolved_by = $2, resolved_at = NOW()
+		WHERE id = $1 AND status = 'open'`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, adminID, status, resolution)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("dispute not found or already resolved")
+	}
+
+	return nil
+}
+
+// ResolveByTransaction закрывает открытый спор по транзакции, если он есть
+func (r *DisputeRepository) ResolveByTransaction(ctx context.Context, transactionID, adminID int64, status, resolution string) error {
+	query := `
+		UPDATE disputes
+		SET status = $3, resolution = $4, resolved_by = $2, resolved_at = NOW()
+		WHERE transaction_id = $1 AND status = 'open'`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, transactionID, adminID, status, resolution)
+	return err
+}
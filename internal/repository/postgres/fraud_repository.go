@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// FraudRepository реализует интерфейс repository.FraudRepository.
+// Запросы учитывают только переводы между пользователями (см. peerTransferKinds), без отмен и служебных операций
+type FraudRepository struct {
+	db *sqlx.DB
+}
+
+// NewFraudRepository создает новый экземпляр FraudRepository
+func NewFraudRepository(db *sqlx.DB) *FraudRepository {
+	return &FraudRepository{
+		db: db,
+	}
+}
+
+// FindCircularTransfers ищет переводы, после которых монеты вернулись отправителю
+// через одного или двух посредников. Value — длина цикла
+func (r *FraudRepository) FindCircularTransfers(ctx context.Context, since time.Time) ([]models.FraudSignal, error) {
+	query := `
+		SELECT DISTINCT ON (t1.id)
+			t1.from_user_id AS user_id,
+			t1.id AS transaction_id,
+			CASE WHEN t2.to_user_id = t1.from_user_id THEN 2 ELSE 3 END::float8 AS value,
+			0::float8 AS baseline
+		FROM transactions t1
+		JOIN transactions t2 ON t2.from_user_id = t1.to_user_id AND t2.created_at >= t1.created_at
+			AND t2.kind IN ` + peerTransferKinds + `
+		LEFT JOIN transactions t3 ON t3.from_user_id = t2.to_user_id AND t3.to_user_id = t1.from_user_id
+			AND t3.created_at >= t2.created_at AND t3.kind IN ` + peerTransferKinds + `
+		WHERE t1.created_at >= $1 AND t1.kind IN ` + peerTransferKinds + `
+			AND t1.from_user_id <> t1.to_user_id
+			AND (t2.to_user_id = t1.from_user_id OR (t3.id IS NOT NULL AND t2.to_user_id <> t1.to_user_id))
+		ORDER BY t1.id, 3`
+
+	var signals []models.FraudSignal
+	err := conn(ctx, r.db).SelectContext(ctx, &signals, query, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return signals, nil
+}
+
+// FindNewAccountFunnels ищет получателей, которым переводят монеты многие новые аккаунты.
+// Value — число разных новых отправителей
+func (r *FraudRepository) FindNewAccountFunnels(ctx context.Context, since, accountsCreatedAfter time.Time, minSenders int) ([]models.FraudSignal, error) {
+	query := `
+		SELECT
+			t.to_user_id AS user_id,
+			MAX(t.id) AS transaction_id,
+			COUNT(DISTINCT t.from_user_id)::float8 AS value,
+			0::float8 AS baseline
+		FROM transactions t
+		JOIN users s ON s.id = t.from_user_id
+		WHERE t.created_at >= $1 AND t.kind IN ` + peerTransferKinds + ` AND s.created_at >= $2
+		GROUP BY t.to_user_id
+		HAVING COUNT(DISTINCT t.from_user_id) >= $3`
+
+	var signals []models.FraudSignal
+	err := conn(ctx, r.db).SelectContext(ctx, &signals, query, since, accountsCreatedAfter, minSenders)
+	if err != nil {
+		return nil, err
+	}
+
+	return signals, nil
+}
+
+// FindBursts считает исходящие переводы пользователей начиная с since и за базовый
+// период [baselineSince, since). Value — число недавних переводов, Baseline — базовых
+func (r *FraudRepository) FindBursts(ctx context.Context, since, baselineSince time.Time, minTransfers int) ([]models.FraudSignal, error) {
+	query := `
+		WITH recent AS (
+			SELECT from_user_id, COUNT(*) AS cnt, MAX(id) AS last_id
+			FROM transactions
+			WHERE created_at >= $1 AND kind IN ` + peerTransferKinds + `
+			GROUP BY from_user_id
+			HAVING COUNT(*) >= $3
+		), baseline AS (
+			SELECT from_user_id, COUNT(*) AS cnt
+			FROM transactions
+			WHERE created_at >= $2 AND created_at < $1 AND kind IN ` + peerTransferKinds + `
+			GROUP BY from_user_id
+		)
+		SELECT
+			r.from_user_id AS user_id,
+			r.last_id AS transaction_id,
+			r.cnt::float8 AS value,
+			COALESCE(b.cnt, 0)::float8 AS baseline
+		FROM recent r
+		LEFT JOIN baseline b ON b.from_user_id = r.from_user_id`
+
+	var signals []models.FraudSignal
+	err := conn(ctx, r.db).SelectContext(ctx, &signals, query, since, baselineSince, minTransfers)
+	if err != nil {
+		return nil, err
+	}
+
+	return signals, nil
+}
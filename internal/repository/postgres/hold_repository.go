@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const holdColumns = `id, user_id, amount, reason, status, expires_at, created_at, resolved_at`
+
+// HoldRepository реализует интерфейс repository.HoldRepository
+type HoldRepository struct {
+	db *sqlx.DB
+}
+
+// NewHoldRepository создает новый экземпляр HoldRepository
+func NewHoldRepository(db *sqlx.DB) *HoldRepository {
+	return &HoldRepository{
+		db: db,
+	}
+}
+
+// Create удерживает монеты пользователя, если их хватает на доступном балансе
+func (r *HoldRepository) Create(ctx context.Context, hold *models.CoinHold) error {
+	reserve := `
+		UPDATE users
+		SET held_coins = held_coins + $1
+		WHERE id = $2 AND coins - held_coins >= $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, reserve, hold.Amount, hold.UserID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("insufficient funds")
+	}
+
+	query := `
+		INSERT INTO coin_holds (user_id, amount, reason, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		hold.UserID,
+		hold.Amount,
+		hold.Reason,
+		hold.ExpiresAt,
+	).Scan(&hold.ID, &hold.Status, &hold.CreatedAt)
+}
+
+// GetByID получает удержание по идентификатору
+func (r *HoldRepository) GetByID(ctx context.Context, id int64) (*models.CoinHold, error) {
+	hold := &models.CoinHold{}
+	query := `
+		SELECT ` + holdColumns + `
+		FROM coin_holds
+		WHERE id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, hold, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("hold not found")
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// GetActive получает активные удержания пользователя, ближайшие к истечению первыми
+func (r *HoldRepository) GetActive(ctx context.Context, userID int64) ([]models.CoinHold, error) {
+	query := `
+		SELECT ` + holdColumns + `
+		FROM coin_holds
+		WHERE user_id = $1 AND status = 'active'
+		ORDER BY expires_at`
+
+	holds := make([]models.CoinHold, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &holds, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+// Capture списывает удержанные монеты с баланса пользователя, расходуя самые старые партии
+func (r *HoldRepository) Capture(ctx context.Context, id int64) (*models.CoinHold, error) {
+	hold, err := r.resolve(ctx, id, models.HoldCaptured)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE users
+		SET coins = coins - $1, held_coins = held_coins - $1
+		WHERE id = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, hold.Amount, hold.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := consumeCoinLots(ctx, r.db, hold.UserID, hold.Amount); err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// Resolve снимает удержание со статусом released или expired, возвращая монеты в доступный баланс
+func (r *HoldRepository) Resolve(ctx context.Context, id int64, status string) (*models.CoinHold, error) {
+	hold, err := r.resolve(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE users
+		SET held_coins = held_coins - $1
+		WHERE id = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, hold.Amount, hold.UserID); err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// resolve переводит активное удержание в конечный статус
+func (r *HoldRepository) resolve(ctx context.Context, id int64, status string) (*models.CoinHold, error) {
+	hold := &models.CoinHold{}
+	query := `
+		UPDATE coin_holds
+		SET status = $2, resolved_at = NOW()
+		WHERE id = $1 AND status = 'active'
+		RETURNING ` + holdColumns
+
+	err := conn(ctx, r.db).GetContext(ctx, hold, query, id, status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("hold is not active")
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// LockExpired блокирует активные удержания с истекшим сроком. Строки, уже заблокированные
+// другим экземпляром приложения, пропускаются.
+// Должен вызываться внутри транзакции
+func (r *HoldRepository) LockExpired(ctx context.Context, limit int) ([]models.CoinHold, error) {
+	query := `
+		SELECT ` + holdColumns + `
+		FROM coin_holds
+		WHERE status = 'active' AND expires_at <= NOW()
+		ORDER BY expires_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	var holds []models.CoinHold
+	err := conn(ctx, r.db).SelectContext(ctx, &holds, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// leaderboardColumns сопоставляет рейтинг с колонкой представления leaderboard_stats
+var leaderboardColumns = map[string]string{
+	models.LeaderboardSenders:    "given",
+	models.LeaderboardReceivers:  "received",
+	models.LeaderboardRecipients: "recipients",
+}
+
+// LeaderboardRepository реализует интерфейс repository.LeaderboardRepository
+type LeaderboardRepository struct {
+	db *sqlx.DB
+}
+
+// NewLeaderboardRepository создает новый экземпляр LeaderboardRepository
+func NewLeaderboardRepository(db *sqlx.DB) *LeaderboardRepository {
+	return &LeaderboardRepository{
+		db: db,
+	}
+}
+
+// GetTop получает первые limit строк рейтинга за период. Пользователи, скрывшие себя
+// из рейтингов, и кошельки команд не показываются и не занимают места
+func (r *LeaderboardRepository) GetTop(ctx context.Context, board, period string, limit int) ([]models.LeaderboardEntry, error) {
+	column, ok := leaderboardColumns[board]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard %q", board)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT RANK() OVER (ORDER BY s.%[1]s DESC) AS rank, u.username, s.%[1]s AS value
+		FROM leaderboard_stats s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.period = $1 AND s.%[1]s > 0 AND NOT u.leaderboard_opt_out
+			AND NOT EXISTS (SELECT 1 FROM teams t WHERE t.wallet_user_id = u.id)
+		ORDER BY s.%[1]s DESC, u.username
+		LIMIT $2`, column)
+
+	entries := make([]models.LeaderboardEntry, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &entries, query, period, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Refresh пересчитывает агрегаты рейтингов. Пересчет не блокирует чтение рейтингов
+func (r *LeaderboardRepository) Refresh(ctx context.Context) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_stats`)
+	return err
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const listingColumns = `
+		l.id, l.user_merch_id, l.seller_id, s.username AS seller, m.name AS item, v.sku AS variant,
+		l.price, l.fee, l.status, l.buyer_id, l.created_at, l.closed_at`
+
+const listingJoins = `
+		JOIN users s ON s.id = l.seller_id
+		JOIN user_merch um ON um.id = l.user_merch_id
+		JOIN merch_items m ON m.id = um.merch_id
+		LEFT JOIN merch_variants v ON v.id = um.variant_id`
+
+// MarketRepository реализует интерфейс repository.MarketRepository
+type MarketRepository struct {
+	db *sqlx.DB
+}
+
+// NewMarketRepository создает новый экземпляр MarketRepository
+func NewMarketRepository(db *sqlx.DB) *MarketRepository {
+	return &MarketRepository{
+		db: db,
+	}
+}
+
+// Create создает объявление о продаже предмета
+func (r *MarketRepository) Create(ctx context.Context, listing *models.MarketListing) error {
+	query := `
+		INSERT INTO market_listings (user_merch_id, seller_id, price)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		listing.UserMerchID,
+		listing.SellerID,
+		listing.Price,
+	).Scan(&listing.ID, &listing.Status, &listing.CreatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return errors.New("item is already listed")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByIDForUpdate получает объявление и блокирует его до конца транзакции
+func (r *MarketRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.MarketListing, error) {
+	listing := &models.MarketListing{}
+	query := `
+		SELECT ` + listingColumns + `
+		FROM market_listings l` + listingJoins + `
+		WHERE l.id = $1
+		FOR UPDATE OF l`
+
+	err := conn(ctx, r.db).GetContext(ctx, listing, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("listing not found")
+		}
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+// Find получает страницу активных объявлений по фильтру, самые дешевые первыми,
+// и общее число подходящих объявлений
+func (r *MarketRepository) Find(ctx context.Context, filter models.MarketFilter) ([]models.MarketListing, int, error) {
+	conditions := []string{"l.status = 'active'"}
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Item != "" {
+		conditions = append(conditions, "m.name = "+arg(filter.Item))
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, "l.price <= "+arg(filter.MaxPrice))
+	}
+
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	query := `
+		SELECT ` + listingColumns + `, COUNT(*) OVER() AS total
+		FROM market_listings l` + listingJoins + `
+		` + where + `
+		ORDER BY l.price ASC, l.created_at ASC
+		LIMIT ` + arg(filter.Limit) + ` OFFSET ` + arg(filter.Offset)
+
+	var rows []struct {
+		models.MarketListing
+		Total int `db:"total"`
+	}
+	err := conn(ctx, r.db).SelectContext(ctx, &rows, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	listings := make([]models.MarketListing, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		listings = append(listings, row.MarketListing)
+		total = row.Total
+	}
+
+	// Страница за пределами результатов не содержит строк с общим числом
+	if len(rows) == 0 && filter.Offset > 0 {
+		countQuery := `SELECT COUNT(*) FROM market_listings l` + listingJoins + ` ` + where
+		err := conn(ctx, r.db).GetContext(ctx, &total, countQuery, args[:len(args)-2]...)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return listings, total, nil
+}
+
+// GetUserListings получает все объявления продавца
+func (r *MarketRepository) GetUserListings(ctx context.Context, sellerID int64) ([]models.MarketListing, error) {
+	query := `
+		SELECT ` + listingColumns + `
+		FROM market_listings l` + listingJoins + `
+		WHERE l.seller_id = $1
+		ORDER BY l.created_at DESC`
+
+	listings := make([]models.MarketListing, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &listings, query, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return listings, nil
+}
+
+// Close закрывает активное объявление продажей или отменой
+func (r *MarketRepository) Close(ctx context.Context, listing *models.MarketListing) error {
+	query := `
+		UPDATE market_listings
+		SET status = $2, buyer_id = $3, fee = $4, closed_at = NOW()
+		WHERE id = $1 AND status = 'active'
+		RETURNING closed_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		listing.ID,
+		listing.Status,
+		listing.BuyerID,
+		listing.Fee,
+	).Scan(&listing.ClosedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("listing not found or already closed")
+		}
+		return err
+	}
+
+	return nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/lib/pq"
 )
 
 // MerchRepository реализует интерфейс repository.MerchRepository
@@ -21,15 +24,26 @@ func NewMerchRepository(db *sqlx.DB) *MerchRepository {
 	}
 }
 
+const merchColumns = `id, name, price, stock, display_name, description, category, tags, purchase_limit, purchase_limit_period,
+		EXISTS (SELECT 1 FROM merch_variants v WHERE v.merch_id = merch_items.id) AS has_variants`
+
+// merchInStock — условие наличия товара в строке merch_items.
+// У товара с вариантами в наличии должен быть хотя бы один вариант
+const merchInStock = `CASE
+			WHEN EXISTS (SELECT 1 FROM merch_variants v WHERE v.merch_id = merch_items.id)
+			THEN EXISTS (SELECT 1 FROM merch_variants v WHERE v.merch_id = merch_items.id AND (v.stock IS NULL OR v.stock > 0))
+			ELSE merch_items.stock IS NULL OR merch_items.stock > 0
+		END`
+
 // GetByName получает мерч по названию
 func (r *MerchRepository) GetByName(ctx context.Context, name string) (*models.MerchItem, error) {
 	item := &models.MerchItem{}
 	query := `
-		SELECT id, name, price
+		SELECT ` + merchColumns + `
 		FROM merch_items
 		WHERE name = $1`
 
-	err := r.db.GetContext(ctx, item, query, name)
+	err := conn(ctx, r.db).GetContext(ctx, item, query, name)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("merch item not found")
@@ -40,18 +54,368 @@ func (r *MerchRepository) GetByName(ctx context.Context, name string) (*models.M
 	return item, nil
 }
 
-// GetAll получает все доступные товары
-func (r *MerchRepository) GetAll(ctx context.Context) ([]models.MerchItem, error) {
-	var items []models.MerchItem
+// GetByID получает мерч по идентификатору
+func (r *MerchRepository) GetByID(ctx context.Context, id int64) (*models.MerchItem, error) {
+	item := &models.MerchItem{}
 	query := `
-		SELECT id, name, price
+		SELECT ` + merchColumns + `
 		FROM merch_items
-		ORDER BY price ASC`
+		WHERE id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, item, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("merch item not found")
+		}
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// merchSortOrders сопоставляет порядок сортировки каталога с выражением ORDER BY
+var merchSortOrders = map[string]string{
+	models.MerchSortPriceAsc:  "price ASC, id",
+	models.MerchSortPriceDesc: "price DESC, id",
+	models.MerchSortNameAsc:   "name ASC",
+	models.MerchSortNameDesc:  "name DESC",
+}
+
+// Find получает страницу каталога по фильтру и общее число подходящих товаров
+func (r *MerchRepository) Find(ctx context.Context, filter models.MerchFilter) ([]models.MerchItem, int, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "category = "+arg(filter.Category))
+	}
+	if filter.Tag != "" {
+		// Оператор @> использует GIN-индекс по tags, в отличие от = ANY(tags)
+		conditions = append(conditions, "tags @> ARRAY["+arg(filter.Tag)+"]::text[]")
+	}
+	if filter.MinPrice > 0 {
+		conditions = append(conditions, "price >= "+arg(filter.MinPrice))
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, "price <= "+arg(filter.MaxPrice))
+	}
+	if filter.Search != "" {
+		pattern := arg("%" + escapeLike(filter.Search) + "%")
+		conditions = append(conditions, "(name ILIKE "+pattern+" OR display_name ILIKE "+pattern+")")
+	}
+	if filter.InStock {
+		conditions = append(conditions, merchInStock)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	order, ok := merchSortOrders[filter.Sort]
+	if !ok {
+		order = merchSortOrders[models.MerchSortPriceAsc]
+	}
+
+	query := `
+		SELECT ` + merchColumns + `, COUNT(*) OVER() AS total
+		FROM merch_items
+		` + where + `
+		ORDER BY ` + order + `
+		LIMIT ` + arg(filter.Limit) + ` OFFSET ` + arg(filter.Offset)
+
+	var rows []struct {
+		models.MerchItem
+		Total int `db:"total"`
+	}
+	err := conn(ctx, r.db).SelectContext(ctx, &rows, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]models.MerchItem, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		items = append(items, row.MerchItem)
+		total = row.Total
+	}
+
+	// Страница за пределами результатов не содержит строк с общим числом
+	if len(rows) == 0 && filter.Offset > 0 {
+		countQuery := `SELECT COUNT(*) FROM merch_items ` + where
+		err := conn(ctx, r.db).GetContext(ctx, &total, countQuery, args[:len(args)-2]...)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return items, total, nil
+}
+
+// GetCategories получает все категории товаров
+func (r *MerchRepository) GetCategories(ctx context.Context) ([]models.MerchCategory, error) {
+	query := `
+		SELECT name, title
+		FROM merch_categories
+		ORDER BY name`
+
+	categories := make([]models.MerchCategory, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &categories, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// UpdateDetails меняет отображаемое название, описание, категорию и теги товара
+func (r *MerchRepository) UpdateDetails(ctx context.Context, merch *models.MerchItem) error {
+	query := `
+		UPDATE merch_items
+		SET display_name = $2, description = $3, category = $4, tags = $5
+		WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		merch.ID,
+		merch.DisplayName,
+		merch.Description,
+		merch.Category,
+		pq.Array(merch.Tags),
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return errors.New("category not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// DecrementStock списывает товар со склада. Для товаров без учета остатков ничего не меняет.
+// Возвращает ошибку, если на складе недостаточно товара
+func (r *MerchRepository) DecrementStock(ctx context.Context, merchID int64, quantity int) error {
+	query := `
+		UPDATE merch_items
+		SET stock = stock - $2
+		WHERE id = $1 AND (stock IS NULL OR stock >= $2)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, merchID, quantity)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("not enough stock")
+	}
+
+	return nil
+}
+
+// Restock возвращает товар на склад
+func (r *MerchRepository) Restock(ctx context.Context, merchID int64, quantity int) error {
+	query := `
+		UPDATE merch_items
+		SET stock = stock + $2
+		WHERE id = $1 AND stock IS NOT NULL`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, merchID, quantity)
+	return err
+}
+
+// GetVariants получает варианты указанных товаров
+func (r *MerchRepository) GetVariants(ctx context.Context, merchIDs []int64) ([]models.MerchVariant, error) {
+	query := `
+		SELECT id, merch_id, sku, attributes, price, stock
+		FROM merch_variants
+		WHERE merch_id = ANY($1)
+		ORDER BY merch_id, id`
+
+	variants := make([]models.MerchVariant, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &variants, query, pq.Array(merchIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// GetVariantBySKU получает вариант товара по артикулу
+func (r *MerchRepository) GetVariantBySKU(ctx context.Context, sku string) (*models.MerchVariant, error) {
+	variant := &models.MerchVariant{}
+	query := `
+		SELECT id, merch_id, sku, attributes, price, stock
+		FROM merch_variants
+		WHERE sku = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, variant, query, sku)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("merch variant not found")
+		}
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// DecrementVariantStock списывает вариант товара со склада.
+// Возвращает ошибку, если на складе недостаточно товара
+func (r *MerchRepository) DecrementVariantStock(ctx context.Context, variantID int64, quantity int) error {
+	query := `
+		UPDATE merch_variants
+		SET stock = stock - $2
+		WHERE id = $1 AND (stock IS NULL OR stock >= $2)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, variantID, quantity)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("not enough stock")
+	}
+
+	return nil
+}
+
+// RestockVariant возвращает вариант товара на склад
+func (r *MerchRepository) RestockVariant(ctx context.Context, variantID int64, quantity int) error {
+	query := `
+		UPDATE merch_variants
+		SET stock = stock + $2
+		WHERE id = $1 AND stock IS NOT NULL`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, variantID, quantity)
+	return err
+}
+
+// UpdatePrice меняет цену товара и возвращает прежнюю цену
+func (r *MerchRepository) UpdatePrice(ctx context.Context, merchID, price int64) (int64, error) {
+	query := `
+		UPDATE merch_items m
+		SET price = $2
+		FROM (SELECT id, price FROM merch_items WHERE id = $1 FOR UPDATE) old
+		WHERE m.id = old.id
+		RETURNING old.price`
+
+	var oldPrice int64
+	err := conn(ctx, r.db).GetContext(ctx, &oldPrice, query, merchID, price)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("merch item not found")
+		}
+		return 0, err
+	}
+
+	return oldPrice, nil
+}
+
+// AddPriceChange записывает изменение цены в историю
+func (r *MerchRepository) AddPriceChange(ctx context.Context, change *models.PriceChange) error {
+	query := `
+		INSERT INTO merch_price_history (merch_id, old_price, new_price, changed_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, changed_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		change.MerchID,
+		change.OldPrice,
+		change.NewPrice,
+		change.ChangedBy,
+	).Scan(&change.ID, &change.ChangedAt)
+}
 
-	err := r.db.SelectContext(ctx, &items, query)
+// GetPriceHistory получает историю цен товара в хронологическом порядке
+func (r *MerchRepository) GetPriceHistory(ctx context.Context, merchID int64) ([]models.PriceChange, error) {
+	query := `
+		SELECT id, merch_id, old_price, new_price, changed_by, changed_at
+		FROM merch_price_history
+		WHERE merch_id = $1
+		ORDER BY changed_at, id`
+
+	history := make([]models.PriceChange, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &history, query, merchID)
 	if err != nil {
 		return nil, err
 	}
 
-	return items, nil
+	return history, nil
+}
+
+// AddImage добавляет изображение в конец списка изображений товара
+func (r *MerchRepository) AddImage(ctx context.Context, image *models.MerchImage) error {
+	query := `
+		INSERT INTO merch_images (merch_id, key, thumbnail_key, content_type, position)
+		VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(position) + 1, 0) FROM merch_images WHERE merch_id = $1))
+		RETURNING id, position, created_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		image.MerchID,
+		image.Key,
+		image.ThumbnailKey,
+		image.ContentType,
+	).Scan(&image.ID, &image.Position, &image.CreatedAt)
+}
+
+// GetImages получает изображения указанных товаров в порядке показа
+func (r *MerchRepository) GetImages(ctx context.Context, merchIDs []int64) ([]models.MerchImage, error) {
+	query := `
+		SELECT id, merch_id, key, thumbnail_key, content_type, position, created_at
+		FROM merch_images
+		WHERE merch_id = ANY($1)
+		ORDER BY merch_id, position, id`
+
+	images := make([]models.MerchImage, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &images, query, pq.Array(merchIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// GetImage получает изображение по идентификатору
+func (r *MerchRepository) GetImage(ctx context.Context, imageID int64) (*models.MerchImage, error) {
+	image := &models.MerchImage{}
+	query := `
+		SELECT id, merch_id, key, thumbnail_key, content_type, position, created_at
+		FROM merch_images
+		WHERE id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, image, query, imageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("merch image not found")
+		}
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// DeleteImage удаляет запись об изображении
+func (r *MerchRepository) DeleteImage(ctx context.Context, imageID int64) error {
+	query := `DELETE FROM merch_images WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, imageID)
+	return err
 }
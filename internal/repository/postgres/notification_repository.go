@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// NotificationRepository реализует интерфейс repository.NotificationRepository
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationRepository создает новый экземпляр NotificationRepository
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+// Create создает уведомление
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, kind, merch_id, message)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		notification.UserID,
+		notification.Kind,
+		notification.MerchID,
+		notification.Message,
+	).Scan(&notification.ID, &notification.CreatedAt)
+}
+
+// GetUserNotifications получает уведомления пользователя, начиная с новых
+func (r *NotificationRepository) GetUserNotifications(ctx context.Context, userID int64, unreadOnly bool) ([]models.Notification, error) {
+	query := `
+		SELECT id, user_id, kind, merch_id, message, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC`
+
+	notifications := make([]models.Notification, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &notifications, query, userID, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, notificationID int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, notificationID, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("notification not found")
+	}
+
+	return nil
+}
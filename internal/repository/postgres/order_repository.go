@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// OrderRepository реализует интерфейс repository.OrderRepository
+type OrderRepository struct {
+	db *sqlx.DB
+}
+
+// NewOrderRepository создает новый экземпляр OrderRepository
+func NewOrderRepository(db *sqlx.DB) *OrderRepository {
+	return &OrderRepository{
+		db: db,
+	}
+}
+
+// Create создает заказ вместе с его позициями. Должен вызываться внутри транзакции
+func (r *OrderRepository) Create(ctx context.Context, order *models.Order) error {
+	query := `
+		INSERT INTO orders (user_id, status, total, promo_code_id, recipient_id, gift_note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		order.UserID,
+		order.Status,
+		order.Total,
+		order.PromoCodeID,
+		order.RecipientID,
+		order.GiftNote,
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO order_items (order_id, merch_id, variant_id, quantity, price, base_price, discount, discount_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = order.ID
+
+		err := conn(ctx, r.db).QueryRowContext(ctx, itemQuery,
+			item.OrderID,
+			item.MerchID,
+			item.VariantID,
+			item.Quantity,
+			item.Price,
+			item.BasePrice,
+			item.Discount,
+			item.DiscountID,
+		).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUserOrders получает заказы пользователя с позициями
+func (r *OrderRepository) GetUserOrders(ctx context.Context, userID int64) ([]models.Order, error) {
+	query := `
+		SELECT id, user_id, status, total, promo_code_id, recipient_id, gift_note, created_at, updated_at
+		FROM orders
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	orders := make([]models.Order, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &orders, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetByID получает заказ с позициями
+func (r *OrderRepository) GetByID(ctx context.Context, id int64) (*models.Order, error) {
+	return r.getByID(ctx, id, "")
+}
+
+// GetByIDForUpdate получает заказ с позициями и блокирует его до конца транзакции
+func (r *OrderRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Order, error) {
+	return r.getByID(ctx, id, "FOR UPDATE")
+}
+
+func (r *OrderRepository) getByID(ctx context.Context, id int64, lock string) (*models.Order, error) {
+	order := &models.Order{}
+	query := `
+		SELECT id, user_id, status, total, promo_code_id, recipient_id, gift_note, created_at, updated_at
+		FROM orders
+		WHERE id = $1 ` + lock
+
+	err := conn(ctx, r.db).GetContext(ctx, order, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+
+	orders := []models.Order{*order}
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return &orders[0], nil
+}
+
+// UpdateStatus меняет статус заказа
+func (r *OrderRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	query := `
+		UPDATE orders
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id, status)
+	return err
+}
+
+// loadItems загружает позиции для списка заказов одним запросом
+func (r *OrderRepository) loadItems(ctx context.Context, orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+
+	query := `
+		SELECT oi.id, oi.order_id, oi.merch_id, m.name AS merch_name,
+		       oi.variant_id, v.attributes, oi.quantity, oi.price,
+		       oi.base_price, oi.discount, oi.discount_id
+		FROM order_items oi
+		JOIN merch_items m ON m.id = oi.merch_id
+		LEFT JOIN merch_variants v ON v.id = oi.variant_id
+		WHERE oi.order_id = ANY($1)
+		ORDER BY oi.id`
+
+	var items []models.OrderItem
+	err := conn(ctx, r.db).SelectContext(ctx, &items, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	byOrder := make(map[int64][]models.OrderItem, len(orders))
+	for _, item := range items {
+		byOrder[item.OrderID] = append(byOrder[item.OrderID], item)
+	}
+
+	for i := range orders {
+		orders[i].Items = byOrder[orders[i].ID]
+		if orders[i].Items == nil {
+			orders[i].Items = make([]models.OrderItem, 0)
+		}
+	}
+
+	return nil
+}
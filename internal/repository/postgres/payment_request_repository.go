@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// paymentRequestColumns — общий список колонок с вычисленным статусом просроченных запросов
+const paymentRequestColumns = `
+		pr.id, pr.requester_id, r.username AS requester, pr.payer_id, p.username AS payer,
+		pr.amount, pr.comment,
+		CASE WHEN pr.status = 'pending' AND pr.expires_at <= NOW() THEN 'expired' ELSE pr.status END AS status,
+		pr.created_at, pr.expires_at, pr.resolved_at`
+
+// PaymentRequestRepository реализует интерфейс repository.PaymentRequestRepository
+type PaymentRequestRepository struct {
+	db *sqlx.DB
+}
+
+// NewPaymentRequestRepository создает новый экземпляр PaymentRequestRepository
+func NewPaymentRequestRepository(db *sqlx.DB) *PaymentRequestRepository {
+	return &PaymentRequestRepository{
+		db: db,
+	}
+}
+
+// Create создает новый запрос на перевод монет
+func (r *PaymentRequestRepository) Create(ctx context.Context, request *models.PaymentRequest) error {
+	query := `
+		INSERT INTO payment_requests (requester_id, payer_id, amount, comment, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		request.RequesterID,
+		request.PayerID,
+		request.Amount,
+		request.Comment,
+		request.Status,
+		request.ExpiresAt,
+	).Scan(&request.ID, &request.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Resolve переводит ожидающий и не просроченный запрос плательщика в указанный статус
+func (r *PaymentRequestRepository) Resolve(ctx context.Context, id, payerID int64, status string) (*models.PaymentRequest, error) {
+	request := &models.PaymentRequest{}
+	query := `
+		UPDATE payment_requests pr
+		SET status = $3, resolved_at = NOW()
+		FROM users r, users p
+		WHERE pr.id = $1 AND pr.payer_id = $2
+			AND pr.status = 'pending' AND pr.expires_at > NOW()
+			AND r.id = pr.requester_id AND p.id = pr.payer_id
+		RETURNING ` + paymentRequestColumns
+
+	err := conn(ctx, r.db).GetContext(ctx, request, query, id, payerID, status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("payment request not found or already resolved")
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// GetIncoming получает запросы, адресованные пользователю как плательщику
+func (r *PaymentRequestRepository) GetIncoming(ctx context.Context, payerID int64) ([]models.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM payment_requests pr
+		JOIN users r ON r.id = pr.requester_id
+		JOIN users p ON p.id = pr.payer_id
+		WHERE pr.payer_id = $1
+		ORDER BY pr.created_at DESC`
+
+	requests := make([]models.PaymentRequest, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &requests, query, payerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// GetOutgoing получает запросы, созданные пользователем
+func (r *PaymentRequestRepository) GetOutgoing(ctx context.Context, requesterID int64) ([]models.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM payment_requests pr
+		JOIN users r ON r.id = pr.requester_id
+		JOIN users p ON p.id = pr.payer_id
+		WHERE pr.requester_id = $1
+		ORDER BY pr.created_at DESC`
+
+	requests := make([]models.PaymentRequest, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &requests, query, requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
@@ -28,17 +28,58 @@ func NewPostgresDB(connStr string) (*sqlx.DB, error) {
 // NewRepository создает новый экземпляр репозитория
 func NewRepository(db *sqlx.DB) *repository.Repository {
 	return &repository.Repository{
-		Users:        NewUserRepository(db),
-		Transactions: NewTransactionRepository(db),
-		Merch:        NewMerchRepository(db),
-		UserMerch:    NewUserMerchRepository(db),
+		Transactor:      NewTransactor(db),
+		Users:           NewUserRepository(db),
+		Transactions:    NewTransactionRepository(db),
+		Merch:           NewMerchRepository(db),
+		UserMerch:       NewUserMerchRepository(db),
+		Orders:          NewOrderRepository(db),
+		Returns:         NewReturnRepository(db),
+		Trades:          NewTradeRepository(db),
+		Market:          NewMarketRepository(db),
+		Auctions:        NewAuctionRepository(db),
+		Raffles:         NewRaffleRepository(db),
+		Holds:           NewHoldRepository(db),
+		CoinLots:        NewCoinLotRepository(db),
+		Achievements:    NewAchievementRepository(db),
+		Leaderboards:    NewLeaderboardRepository(db),
+		Teams:           NewTeamRepository(db),
+		Carts:           NewCartRepository(db),
+		Wishlists:       NewWishlistRepository(db),
+		Notifications:   NewNotificationRepository(db),
+		Discounts:       NewDiscountRepository(db),
+		PromoCodes:      NewPromoCodeRepository(db),
+		PaymentRequests: NewPaymentRequestRepository(db),
+		Schedules:       NewScheduledTransferRepository(db),
+		Fraud:           NewFraudRepository(db),
+		Alerts:          NewAlertRepository(db),
+		Disputes:        NewDisputeRepository(db),
 	}
 }
 
 // Repository объединяет все репозитории
 type Repository struct {
-	Users        *UserRepository
-	Transactions *TransactionRepository
-	Merch        *MerchRepository
-	UserMerch    *UserMerchRepository
+	Transactor      *Transactor
+	Users           *UserRepository
+	Transactions    *TransactionRepository
+	Merch           *MerchRepository
+	UserMerch       *UserMerchRepository
+	Orders          *OrderRepository
+	Returns         *ReturnRepository
+	Trades          *TradeRepository
+	Market          *MarketRepository
+	Auctions        *AuctionRepository
+	Raffles         *RaffleRepository
+	Holds           *HoldRepository
+	CoinLots        *CoinLotRepository
+	Carts           *CartRepository
+	Wishlists       *WishlistRepository
+	Notifications   *NotificationRepository
+	Discounts       *DiscountRepository
+	PromoCodes      *PromoCodeRepository
+	PaymentRequests *PaymentRequestRepository
+	Schedules       *ScheduledTransferRepository
+	Fraud           *FraudRepository
+	Alerts          *AlertRepository
+	Disputes        *DisputeRepository
 }
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PromoCodeRepository реализует интерфейс repository.PromoCodeRepository
+type PromoCodeRepository struct {
+	db *sqlx.DB
+}
+
+// NewPromoCodeRepository создает новый экземпляр PromoCodeRepository
+func NewPromoCodeRepository(db *sqlx.DB) *PromoCodeRepository {
+	return &PromoCodeRepository{
+		db: db,
+	}
+}
+
+const promoCodeColumns = `id, code, merch_id, category, percent, amount, max_uses, max_uses_per_user,
+		used, starts_at, ends_at, created_at`
+
+// Create создает промокод
+func (r *PromoCodeRepository) Create(ctx context.Context, promo *models.PromoCode) error {
+	query := `
+		INSERT INTO promo_codes (code, merch_id, category, percent, amount, max_uses, max_uses_per_user, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, used, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		promo.Code,
+		promo.MerchID,
+		promo.Category,
+		promo.Percent,
+		promo.Amount,
+		promo.MaxUses,
+		promo.MaxUsesPerUser,
+		promo.StartsAt,
+		promo.EndsAt,
+	).Scan(&promo.ID, &promo.Used, &promo.CreatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return errors.New("promo code already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetAll получает все промокоды, начиная с последних
+func (r *PromoCodeRepository) GetAll(ctx context.Context) ([]models.PromoCode, error) {
+	query := `
+		SELECT ` + promoCodeColumns + `
+		FROM promo_codes
+		ORDER BY created_at DESC`
+
+	promos := make([]models.PromoCode, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &promos, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return promos, nil
+}
+
+// GetByCodeForUpdate получает промокод и блокирует его до конца транзакции,
+// чтобы параллельные покупки не превысили лимит использований
+func (r *PromoCodeRepository) GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error) {
+	promo := &models.PromoCode{}
+	query := `
+		SELECT ` + promoCodeColumns + `
+		FROM promo_codes
+		WHERE code = $1
+		FOR UPDATE`
+
+	err := conn(ctx, r.db).GetContext(ctx, promo, query, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("promo code not found")
+		}
+		return nil, err
+	}
+
+	return promo, nil
+}
+
+// CountUserRedemptions считает использования промокода пользователем
+func (r *PromoCodeRepository) CountUserRedemptions(ctx context.Context, promoCodeID, userID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM promo_redemptions
+		WHERE promo_code_id = $1 AND user_id = $2`
+
+	var count int
+	err := conn(ctx, r.db).GetContext(ctx, &count, query, promoCodeID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Redeem записывает использование промокода в заказе. Должен вызываться внутри транзакции
+func (r *PromoCodeRepository) Redeem(ctx context.Context, promoCodeID, userID, orderID int64) error {
+	query := `
+		INSERT INTO promo_redemptions (promo_code_id, user_id, order_id)
+		VALUES ($1, $2, $3)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, promoCodeID, userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `UPDATE promo_codes SET used = used + 1 WHERE id = $1`, promoCodeID)
+	return err
+}
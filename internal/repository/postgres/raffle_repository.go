@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// raffleColumns — общий список колонок розыгрыша без seed
+const raffleColumns = `
+		r.id, r.merch_id, m.name AS item, r.variant_id, v.sku AS variant, r.quantity, r.ticket_price,
+		r.tickets_sold, r.draw_at, r.seed_hash, r.status, r.created_by, r.created_at, r.drawn_at`
+
+// raffleRevealedSeed раскрывает seed только после розыгрыша
+const raffleRevealedSeed = `,
+		CASE WHEN r.status = 'drawn' THEN r.seed END AS seed`
+
+const raffleJoins = `
+		JOIN merch_items m ON m.id = r.merch_id
+		LEFT JOIN merch_variants v ON v.id = r.variant_id`
+
+// RaffleRepository реализует интерфейс repository.RaffleRepository
+type RaffleRepository struct {
+	db *sqlx.DB
+}
+
+// NewRaffleRepository создает новый экземпляр RaffleRepository
+func NewRaffleRepository(db *sqlx.DB) *RaffleRepository {
+	return &RaffleRepository{
+		db: db,
+	}
+}
+
+// Create создает розыгрыш вместе с секретным seed
+func (r *RaffleRepository) Create(ctx context.Context, raffle *models.Raffle) error {
+	query := `
+		INSERT INTO raffles (merch_id, variant_id, quantity, ticket_price, draw_at, seed, seed_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		raffle.MerchID,
+		raffle.VariantID,
+		raffle.Quantity,
+		raffle.TicketPrice,
+		raffle.DrawAt,
+		raffle.Seed,
+		raffle.SeedHash,
+		raffle.CreatedBy,
+	).Scan(&raffle.ID, &raffle.Status, &raffle.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByID получает розыгрыш по идентификатору
+func (r *RaffleRepository) GetByID(ctx context.Context, id int64) (*models.Raffle, error) {
+	raffle := &models.Raffle{}
+	query := `
+		SELECT ` + raffleColumns + raffleRevealedSeed + `
+		FROM raffles r` + raffleJoins + `
+		WHERE r.id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, raffle, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("raffle not found")
+		}
+		return nil, err
+	}
+
+	return raffle, nil
+}
+
+// GetOpen получает еще не разыгранные розыгрыши, ближайшие первыми
+func (r *RaffleRepository) GetOpen(ctx context.Context) ([]models.Raffle, error) {
+	query := `
+		SELECT ` + raffleColumns + raffleRevealedSeed + `
+		FROM raffles r` + raffleJoins + `
+		WHERE r.status = 'open'
+		ORDER BY r.draw_at`
+
+	raffles := make([]models.Raffle, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &raffles, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return raffles, nil
+}
+
+// AddEntry записывает покупку билетов и присваивает им следующие номера.
+// Обновление счетчика блокирует строку розыгрыша, поэтому параллельные покупки
+// получают непересекающиеся номера
+func (r *RaffleRepository) AddEntry(ctx context.Context, entry *models.RaffleEntry) error {
+	query := `
+		UPDATE raffles
+		SET tickets_sold = tickets_sold + $2
+		WHERE id = $1 AND status = 'open' AND draw_at > NOW()
+		RETURNING tickets_sold - $2 + 1`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, entry.RaffleID, entry.Tickets).Scan(&entry.FirstTicket)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("raffle is closed for entries")
+		}
+		return err
+	}
+
+	entryQuery := `
+		INSERT INTO raffle_entries (raffle_id, user_id, tickets, first_ticket)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, entryQuery,
+		entry.RaffleID,
+		entry.UserID,
+		entry.Tickets,
+		entry.FirstTicket,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// GetEntries получает покупки билетов в порядке номеров
+func (r *RaffleRepository) GetEntries(ctx context.Context, raffleID int64) ([]models.RaffleEntry, error) {
+	query := `
+		SELECT e.id, e.raffle_id, e.user_id, u.username, e.tickets, e.first_ticket, e.created_at
+		FROM raffle_entries e
+		JOIN users u ON u.id = e.user_id
+		WHERE e.raffle_id = $1
+		ORDER BY e.first_ticket`
+
+	entries := make([]models.RaffleEntry, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &entries, query, raffleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// LockDue блокирует розыгрыши, время которых наступило, вместе с секретным seed. Строки,
+// уже заблокированные другим экземпляром приложения, пропускаются.
+// Должен вызываться внутри транзакции
+func (r *RaffleRepository) LockDue(ctx context.Context, limit int) ([]models.Raffle, error) {
+	query := `
+		SELECT ` + raffleColumns + `, r.seed
+		FROM raffles r` + raffleJoins + `
+		WHERE r.status = 'open' AND r.draw_at <= NOW()
+		ORDER BY r.draw_at
+		LIMIT $1
+		FOR UPDATE OF r SKIP LOCKED`
+
+	var raffles []models.Raffle
+	err := conn(ctx, r.db).SelectContext(ctx, &raffles, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return raffles, nil
+}
+
+// AddWinner записывает победителя розыгрыша
+func (r *RaffleRepository) AddWinner(ctx context.Context, winner *models.RaffleWinner) error {
+	query := `
+		INSERT INTO raffle_winners (raffle_id, user_id, ticket, position)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, winner.RaffleID, winner.UserID, winner.Ticket, winner.Position)
+	return err
+}
+
+// GetWinners получает победителей розыгрыша в порядке выбора
+func (r *RaffleRepository) GetWinners(ctx context.Context, raffleID int64) ([]models.RaffleWinner, error) {
+	query := `
+		SELECT w.raffle_id, w.user_id, u.username, w.ticket, w.position
+		FROM raffle_winners w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.raffle_id = $1
+		ORDER BY w.position`
+
+	winners := make([]models.RaffleWinner, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &winners, query, raffleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return winners, nil
+}
+
+// MarkDrawn помечает розыгрыш проведенным, после чего seed становится публичным
+func (r *RaffleRepository) MarkDrawn(ctx context.Context, id int64) error {
+	query := `
+		UPDATE raffles
+		SET status = 'drawn', drawn_at = NOW()
+		WHERE id = $1 AND status = 'open'`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	return err
+}
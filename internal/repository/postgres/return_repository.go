@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const returnColumns = `
+		r.id, r.user_merch_id, r.user_id, m.name AS merch_name, r.kind, r.exchange_merch_id, r.exchange_variant_id,
+		r.reason, r.status, r.refund, r.charge, r.transaction_id, r.resolution, r.created_at, r.resolved_by, r.resolved_at`
+
+const returnJoins = `
+		JOIN user_merch um ON um.id = r.user_merch_id
+		JOIN merch_items m ON m.id = um.merch_id`
+
+// ReturnRepository реализует интерфейс repository.ReturnRepository
+type ReturnRepository struct {
+	db *sqlx.DB
+}
+
+// NewReturnRepository создает новый экземпляр ReturnRepository
+func NewReturnRepository(db *sqlx.DB) *ReturnRepository {
+	return &ReturnRepository{
+		db: db,
+	}
+}
+
+// Create создает заявку на возврат или обмен
+func (r *ReturnRepository) Create(ctx context.Context, ret *models.MerchReturn) error {
+	query := `
+		INSERT INTO merch_returns (user_merch_id, user_id, kind, exchange_merch_id, exchange_variant_id, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		ret.UserMerchID,
+		ret.UserID,
+		ret.Kind,
+		ret.ExchangeMerchID,
+		ret.ExchangeVariantID,
+		ret.Reason,
+	).Scan(&ret.ID, &ret.Status, &ret.CreatedAt)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return errors.New("return for this item is already requested")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByIDForUpdate получает заявку и блокирует ее до конца транзакции
+func (r *ReturnRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.MerchReturn, error) {
+	ret := &models.MerchReturn{}
+	query := `
+		SELECT ` + returnColumns + `
+		FROM merch_returns r` + returnJoins + `
+		WHERE r.id = $1
+		FOR UPDATE OF r`
+
+	err := conn(ctx, r.db).GetContext(ctx, ret, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("return request not found")
+		}
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// GetUserReturns получает заявки пользователя
+func (r *ReturnRepository) GetUserReturns(ctx context.Context, userID int64) ([]models.MerchReturn, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM merch_returns r` + returnJoins + `
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC`
+
+	returns := make([]models.MerchReturn, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &returns, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+// GetByStatus получает очередь заявок с указанным статусом, пустой статус — все заявки
+func (r *ReturnRepository) GetByStatus(ctx context.Context, status string) ([]models.MerchReturn, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM merch_returns r` + returnJoins + `
+		WHERE $1 = '' OR r.status = $1
+		ORDER BY r.created_at ASC`
+
+	returns := make([]models.MerchReturn, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &returns, query, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+// Resolve сохраняет решение по заявке, находящейся на рассмотрении
+func (r *ReturnRepository) Resolve(ctx context.Context, ret *models.MerchReturn) error {
+	query := `
+		UPDATE merch_returns
+		SET status = $2, refund = $3, charge = $4, transaction_id = $5, resolution = $6,
+			resolved_by = $7, resolved_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING resolved_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		ret.ID,
+		ret.Status,
+		ret.Refund,
+		ret.Charge,
+		ret.TransactionID,
+		ret.Resolution,
+		ret.ResolvedBy,
+	).Scan(&ret.ResolvedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("return request not found or already resolved")
+		}
+		return err
+	}
+
+	return nil
+}
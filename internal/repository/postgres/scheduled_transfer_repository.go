@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const scheduledTransferColumns = `
+		st.id, st.from_user_id, st.to_user_id, u.username AS to_username, st.amount, st.comment,
+		st.recurrence, st.status, st.next_run_at, st.anchor_at, st.attempts, st.last_error, st.last_run_at, st.created_at`
+
+// ScheduledTransferRepository реализует интерфейс repository.ScheduledTransferRepository
+type ScheduledTransferRepository struct {
+	db *sqlx.DB
+}
+
+// NewScheduledTransferRepository создает новый экземпляр ScheduledTransferRepository
+func NewScheduledTransferRepository(db *sqlx.DB) *ScheduledTransferRepository {
+	return &ScheduledTransferRepository{
+		db: db,
+	}
+}
+
+// Create создает запланированный перевод
+func (r *ScheduledTransferRepository) Create(ctx context.Context, schedule *models.ScheduledTransfer) error {
+	query := `
+		INSERT INTO scheduled_transfers (from_user_id, to_user_id, amount, comment, recurrence, status, next_run_at, anchor_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		schedule.FromUserID,
+		schedule.ToUserID,
+		schedule.Amount,
+		schedule.Comment,
+		schedule.Recurrence,
+		schedule.Status,
+		schedule.NextRunAt,
+		schedule.AnchorAt,
+	).Scan(&schedule.ID, &schedule.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserSchedules получает все запланированные переводы пользователя
+func (r *ScheduledTransferRepository) GetUserSchedules(ctx context.Context, userID int64) ([]models.ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers st
+		JOIN users u ON u.id = st.to_user_id
+		WHERE st.from_user_id = $1
+		ORDER BY st.created_at DESC`
+
+	schedules := make([]models.ScheduledTransfer, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &schedules, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// SetStatus меняет статус перевода пользователя, если текущий статус входит в fromStatuses
+func (r *ScheduledTransferRepository) SetStatus(ctx context.Context, id, userID int64, fromStatuses []string, status string) error {
+	query := `
+		UPDATE scheduled_transfers
+		SET status = $3
+		WHERE id = $1 AND from_user_id = $2 AND status = ANY($4)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, userID, status, pq.Array(fromStatuses))
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("scheduled transfer not found or has invalid status")
+	}
+
+	return nil
+}
+
+// LockDue блокирует активные переводы, которые пора выполнить. Строки, уже
+// заблокированные другим экземпляром приложения, пропускаются.
+// Должен вызываться внутри транзакции
+func (r *ScheduledTransferRepository) LockDue(ctx context.Context, limit int) ([]models.ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers st
+		JOIN users u ON u.id = st.to_user_id
+		WHERE st.status = 'active' AND st.next_run_at <= NOW()
+		ORDER BY st.next_run_at
+		LIMIT $1
+		FOR UPDATE OF st SKIP LOCKED`
+
+	var schedules []models.ScheduledTransfer
+	err := conn(ctx, r.db).SelectContext(ctx, &schedules, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// Update сохраняет результат выполнения перевода
+func (r *ScheduledTransferRepository) Update(ctx context.Context, schedule *models.ScheduledTransfer) error {
+	query := `
+		UPDATE scheduled_transfers
+		SET status = $2, next_run_at = $3, attempts = $4, last_error = $5, last_run_at = $6
+		WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		schedule.ID,
+		schedule.Status,
+		schedule.NextRunAt,
+		schedule.Attempts,
+		schedule.LastError,
+		schedule.LastRunAt,
+	)
+
+	return err
+}
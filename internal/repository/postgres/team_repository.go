@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// TeamRepository реализует интерфейс repository.TeamRepository
+type TeamRepository struct {
+	db *sqlx.DB
+}
+
+// NewTeamRepository создает новый экземпляр TeamRepository
+func NewTeamRepository(db *sqlx.DB) *TeamRepository {
+	return &TeamRepository{
+		db: db,
+	}
+}
+
+// Create создает команду с уже созданным аккаунтом кошелька
+func (r *TeamRepository) Create(ctx context.Context, team *models.Team) error {
+	query := `
+		INSERT INTO teams (name, wallet_user_id)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, team.Name, team.WalletUserID).Scan(&team.ID, &team.CreatedAt)
+}
+
+// GetByID получает команду вместе с балансом ее кошелька
+func (r *TeamRepository) GetByID(ctx context.Context, id int64) (*models.Team, error) {
+	team := &models.Team{}
+	query := `
+		SELECT t.id, t.name, t.wallet_user_id, u.coins AS balance, t.created_at
+		FROM teams t
+		JOIN users u ON u.id = t.wallet_user_id
+		WHERE t.id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, team, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("team not found")
+		}
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// GetUserTeams получает команды пользователя вместе с его ролью в каждой
+func (r *TeamRepository) GetUserTeams(ctx context.Context, userID int64) ([]models.Team, error) {
+	query := `
+		SELECT t.id, t.name, t.wallet_user_id, u.coins AS balance, m.role, t.created_at
+		FROM team_members m
+		JOIN teams t ON t.id = m.team_id
+		JOIN users u ON u.id = t.wallet_user_id
+		WHERE m.user_id = $1
+		ORDER BY t.name`
+
+	teams := make([]models.Team, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &teams, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// GetMembers получает участников команды, менеджеров первыми
+func (r *TeamRepository) GetMembers(ctx context.Context, teamID int64) ([]models.TeamMember, error) {
+	query := `
+		SELECT m.team_id, m.user_id, u.username, m.role, m.joined_at
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY m.role = 'manager' DESC, u.username`
+
+	members := make([]models.TeamMember, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &members, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// GetMember получает участника команды
+func (r *TeamRepository) GetMember(ctx context.Context, teamID, userID int64) (*models.TeamMember, error) {
+	member := &models.TeamMember{}
+	query := `
+		SELECT m.team_id, m.user_id, u.username, m.role, m.joined_at
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1 AND m.user_id = $2`
+
+	err := conn(ctx, r.db).GetContext(ctx, member, query, teamID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("not a team member")
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// SetMember добавляет пользователя в команду или меняет его роль
+func (r *TeamRepository) SetMember(ctx context.Context, member *models.TeamMember) error {
+	query := `
+		INSERT INTO team_members (team_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING joined_at`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, member.TeamID, member.UserID, member.Role).Scan(&member.JoinedAt)
+}
+
+// RemoveMember исключает пользователя из команды
+func (r *TeamRepository) RemoveMember(ctx context.Context, teamID, userID int64) error {
+	query := `
+		DELETE FROM team_members
+		WHERE team_id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, teamID, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("not a team member")
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// tradeColumns — общий список колонок с вычисленным статусом просроченных предложений
+const tradeColumns = `
+		t.id, t.proposer_id, p.username AS proposer, t.recipient_id, r.username AS recipient,
+		t.offered_coins, t.requested_coins, t.message,
+		CASE WHEN t.status = 'pending' AND t.expires_at <= NOW() THEN 'expired' ELSE t.status END AS status,
+		t.counter_of, t.hold_id, t.created_at, t.expires_at, t.resolved_at`
+
+const tradeJoins = `
+		JOIN users p ON p.id = t.proposer_id
+		JOIN users r ON r.id = t.recipient_id`
+
+// TradeRepository реализует интерфейс repository.TradeRepository
+type TradeRepository struct {
+	db *sqlx.DB
+}
+
+// NewTradeRepository создает новый экземпляр TradeRepository
+func NewTradeRepository(db *sqlx.DB) *TradeRepository {
+	return &TradeRepository{
+		db: db,
+	}
+}
+
+// Create создает предложение обмена вместе с предметами обеих сторон
+func (r *TradeRepository) Create(ctx context.Context, trade *models.Trade) error {
+	query := `
+		INSERT INTO trades (proposer_id, recipient_id, offered_coins, requested_coins, message, counter_of, hold_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, status, created_at`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		trade.ProposerID,
+		trade.RecipientID,
+		trade.OfferedCoins,
+		trade.RequestedCoins,
+		trade.Message,
+		trade.CounterOf,
+		trade.HoldID,
+		trade.ExpiresAt,
+	).Scan(&trade.ID, &trade.Status, &trade.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO trade_items (trade_id, user_merch_id, owner_id)
+		VALUES ($1, $2, $3)`
+
+	for _, items := range [][]models.TradeItem{trade.OfferedItems, trade.RequestedItems} {
+		for i := range items {
+			items[i].TradeID = trade.ID
+			_, err := conn(ctx, r.db).ExecContext(ctx, itemQuery, trade.ID, items[i].UserMerchID, items[i].OwnerID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetByIDForUpdate получает предложение обмена и блокирует его до конца транзакции
+func (r *TradeRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Trade, error) {
+	trade := &models.Trade{}
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades t` + tradeJoins + `
+		WHERE t.id = $1
+		FOR UPDATE OF t`
+
+	err := conn(ctx, r.db).GetContext(ctx, trade, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("trade not found")
+		}
+		return nil, err
+	}
+
+	if err := r.attachItems(ctx, []*models.Trade{trade}); err != nil {
+		return nil, err
+	}
+
+	return trade, nil
+}
+
+// GetUserTrades получает историю обменов, в которых участвовал пользователь
+func (r *TradeRepository) GetUserTrades(ctx context.Context, userID int64) ([]models.Trade, error) {
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades t` + tradeJoins + `
+		WHERE t.proposer_id = $1 OR t.recipient_id = $1
+		ORDER BY t.created_at DESC`
+
+	trades := make([]models.Trade, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &trades, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*models.Trade, len(trades))
+	for i := range trades {
+		refs[i] = &trades[i]
+	}
+	if err := r.attachItems(ctx, refs); err != nil {
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+// SetStatus переводит ожидающее предложение в указанный статус
+func (r *TradeRepository) SetStatus(ctx context.Context, id int64, status string) error {
+	query := `
+		UPDATE trades
+		SET status = $2, resolved_at = NOW()
+		WHERE id = $1 AND status = 'pending'`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, status)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("trade not found or already resolved")
+	}
+
+	return nil
+}
+
+// attachItems загружает предметы обменов и раскладывает их по сторонам:
+// предметы инициатора предлагаются, предметы получателя запрашиваются
+func (r *TradeRepository) attachItems(ctx context.Context, trades []*models.Trade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Trade, len(trades))
+	ids := make([]int64, 0, len(trades))
+	for _, trade := range trades {
+		trade.OfferedItems = make([]models.TradeItem, 0)
+		trade.RequestedItems = make([]models.TradeItem, 0)
+		byID[trade.ID] = trade
+		ids = append(ids, trade.ID)
+	}
+
+	query := `
+		SELECT ti.trade_id, ti.user_merch_id, ti.owner_id, m.name AS item, v.sku AS variant
+		FROM trade_items ti
+		JOIN user_merch um ON um.id = ti.user_merch_id
+		JOIN merch_items m ON m.id = um.merch_id
+		LEFT JOIN merch_variants v ON v.id = um.variant_id
+		WHERE ti.trade_id = ANY($1)
+		ORDER BY ti.trade_id, ti.user_merch_id`
+
+	var items []models.TradeItem
+	err := conn(ctx, r.db).SelectContext(ctx, &items, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		trade := byID[item.TradeID]
+		if item.OwnerID == trade.ProposerID {
+			trade.OfferedItems = append(trade.OfferedItems, item)
+		} else {
+			trade.RequestedItems = append(trade.RequestedItems, item)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,9 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/haqer0002/avito-shop/internal/models"
@@ -21,16 +24,22 @@ func NewTransactionRepository(db *sqlx.DB) *TransactionRepository {
 
 // Create создает новую транзакцию
 func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	if transaction.Kind == "" {
+		transaction.Kind = models.TransactionTransfer
+	}
+
 	query := `
-		INSERT INTO transactions (from_user_id, to_user_id, amount, description)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO transactions (from_user_id, to_user_id, amount, description, kind, reversal_of)
+		VALUES (NULLIF($1, 0), NULLIF($2, 0), $3, $4, $5, $6)
 		RETURNING id, created_at`
 
-	err := r.db.QueryRowContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		transaction.FromUserID,
 		transaction.ToUserID,
 		transaction.Amount,
 		transaction.Description,
+		transaction.Kind,
+		transaction.ReversalOf,
 	).Scan(&transaction.ID, &transaction.CreatedAt)
 
 	if err != nil {
@@ -43,16 +52,65 @@ func (r *TransactionRepository) Create(ctx context.Context, transaction *models.
 // GetUserTransactions получает все транзакции пользователя
 func (r *TransactionRepository) GetUserTransactions(ctx context.Context, userID int64) ([]models.Transaction, error) {
 	query := `
-		SELECT id, from_user_id, to_user_id, amount, created_at, description
+		SELECT id, COALESCE(from_user_id, 0) AS from_user_id, COALESCE(to_user_id, 0) AS to_user_id,
+			amount, created_at, COALESCE(description, '') AS description, kind, reversal_of
 		FROM transactions
 		WHERE from_user_id = $1 OR to_user_id = $1
 		ORDER BY created_at DESC`
 
 	var transactions []models.Transaction
-	err := r.db.SelectContext(ctx, &transactions, query, userID)
+	err := conn(ctx, r.db).SelectContext(ctx, &transactions, query, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	return transactions, nil
 }
+
+// GetByIDForUpdate получает транзакцию по ID и блокирует ее до конца транзакции БД
+func (r *TransactionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Transaction, error) {
+	transaction := &models.Transaction{}
+	query := `
+		SELECT t.id, COALESCE(t.from_user_id, 0) AS from_user_id, COALESCE(t.to_user_id, 0) AS to_user_id,
+			t.amount, t.created_at, COALESCE(t.description, '') AS description, t.kind, t.reversal_of,
+			EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id) AS reversed
+		FROM transactions t
+		WHERE t.id = $1
+		FOR UPDATE`
+
+	err := conn(ctx, r.db).GetContext(ctx, transaction, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("transaction not found")
+		}
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// peerTransferKinds — виды операций, которыми монеты переходят от одного пользователя к другому.
+// Лимиты переводов и поиск мошенничества учитывают их все, иначе лимиты можно обойти покупкой
+// объявления сообщника на маркетплейсе или доплатой в обмене
+const peerTransferKinds = `('transfer', 'market_sale', 'trade')`
+
+// GetOutgoingStats считает сумму и число получателей исходящих переводов между пользователями
+// (без отмен и служебных операций) начиная с since,
+// а также проверяет, были ли переводы получателю toUserID
+func (r *TransactionRepository) GetOutgoingStats(ctx context.Context, fromUserID, toUserID int64, since time.Time) (*models.TransferStats, error) {
+	stats := &models.TransferStats{}
+	query := `
+		SELECT
+			COALESCE(SUM(amount), 0) AS total,
+			COUNT(DISTINCT to_user_id) AS recipients,
+			COALESCE(BOOL_OR(to_user_id = $2), FALSE) AS has_recipient
+		FROM transactions
+		WHERE from_user_id = $1 AND kind IN ` + peerTransferKinds + ` AND created_at >= $3`
+
+	err := conn(ctx, r.db).GetContext(ctx, stats, query, fromUserID, toUserID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
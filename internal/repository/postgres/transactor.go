@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// txState хранит открытую транзакцию и глубину вложенности
+type txState struct {
+	tx    *sqlx.Tx
+	depth int
+}
+
+// dbtx объединяет методы, общие для *sqlx.DB и *sqlx.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// conn возвращает транзакцию из контекста, если она открыта, иначе само подключение
+func conn(ctx context.Context, db *sqlx.DB) dbtx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
+
+// Transactor реализует интерфейс repository.Transactor
+type Transactor struct {
+	db *sqlx.DB
+}
+
+// NewTransactor создает новый экземпляр Transactor
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{
+		db: db,
+	}
+}
+
+// WithinTransaction выполняет fn в транзакции. Вложенный вызов работает
+// через SAVEPOINT, поэтому ошибка внутри откатывает только его изменения
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return t.withinSavepoint(ctx, state, fn)
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Transactor) withinSavepoint(ctx context.Context, parent *txState, fn func(ctx context.Context) error) error {
+	state := &txState{tx: parent.tx, depth: parent.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", state.depth)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		_, _ = state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return nil
+}
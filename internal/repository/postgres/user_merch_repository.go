@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/lib/pq"
 )
 
 // UserMerchRepository реализует интерфейс repository.UserMerchRepository
@@ -19,17 +23,29 @@ func NewUserMerchRepository(db *sqlx.DB) *UserMerchRepository {
 	}
 }
 
-// Create создает запись о купленном мерче
+// Create создает запись о купленном мерче. Если CreatedAt задан, запись сохраняет это время покупки,
+// иначе временем покупки становится текущее
 func (r *UserMerchRepository) Create(ctx context.Context, userMerch *models.UserMerch) error {
 	query := `
-		INSERT INTO user_merch (user_id, merch_id)
-		VALUES ($1, $2)
-		RETURNING id, created_at`
+		INSERT INTO user_merch (user_id, merch_id, variant_id, price_paid, order_id, given_by, gift_note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, NOW()))
+		RETURNING id, status, created_at`
 
-	err := r.db.QueryRowContext(ctx, query,
+	var createdAt *time.Time
+	if !userMerch.CreatedAt.IsZero() {
+		createdAt = &userMerch.CreatedAt
+	}
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		userMerch.UserID,
 		userMerch.MerchID,
-	).Scan(&userMerch.ID, &userMerch.CreatedAt)
+		userMerch.VariantID,
+		userMerch.PricePaid,
+		userMerch.OrderID,
+		userMerch.GivenBy,
+		userMerch.GiftNote,
+		createdAt,
+	).Scan(&userMerch.ID, &userMerch.Status, &userMerch.CreatedAt)
 
 	if err != nil {
 		return err
@@ -38,19 +54,153 @@ func (r *UserMerchRepository) Create(ctx context.Context, userMerch *models.User
 	return nil
 }
 
-// GetUserMerch получает весь мерч пользователя
+// GetUserMerch получает весь мерч, которым владеет пользователь
 func (r *UserMerchRepository) GetUserMerch(ctx context.Context, userID int64) ([]models.UserMerch, error) {
 	query := `
-		SELECT um.id, um.user_id, um.merch_id, um.created_at
+		SELECT um.id, um.user_id, um.merch_id, um.variant_id, v.sku AS variant_sku, v.attributes,
+		       um.price_paid, um.order_id, um.given_by, um.gift_note, um.status, um.created_at, um.traded_at
 		FROM user_merch um
-		WHERE um.user_id = $1
+		LEFT JOIN merch_variants v ON v.id = um.variant_id
+		WHERE um.user_id = $1 AND um.status = 'owned'
 		ORDER BY um.created_at DESC`
 
 	var userMerch []models.UserMerch
-	err := r.db.SelectContext(ctx, &userMerch, query, userID)
+	err := conn(ctx, r.db).SelectContext(ctx, &userMerch, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return userMerch, nil
+}
+
+// GetByIDForUpdate получает предмет из инвентаря и блокирует его до конца транзакции
+func (r *UserMerchRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.UserMerch, error) {
+	userMerch := &models.UserMerch{}
+	query := `
+		SELECT um.id, um.user_id, um.merch_id, um.variant_id, v.sku AS variant_sku, v.attributes,
+		       um.price_paid, um.order_id, um.given_by, um.gift_note, um.status, um.created_at, um.traded_at
+		FROM user_merch um
+		LEFT JOIN merch_variants v ON v.id = um.variant_id
+		WHERE um.id = $1
+		FOR UPDATE OF um`
+
+	err := conn(ctx, r.db).GetContext(ctx, userMerch, query, id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("item not found")
+		}
 		return nil, err
 	}
 
 	return userMerch, nil
 }
+
+// GetByIDsForUpdate получает предметы из инвентаря и блокирует их в порядке возрастания идентификаторов
+func (r *UserMerchRepository) GetByIDsForUpdate(ctx context.Context, ids []int64) ([]models.UserMerch, error) {
+	query := `
+		SELECT um.id, um.user_id, um.merch_id, um.variant_id, v.sku AS variant_sku, v.attributes,
+		       um.price_paid, um.order_id, um.given_by, um.gift_note, um.status, um.created_at, um.traded_at
+		FROM user_merch um
+		LEFT JOIN merch_variants v ON v.id = um.variant_id
+		WHERE um.id = ANY($1)
+		ORDER BY um.id
+		FOR UPDATE OF um`
+
+	userMerch := make([]models.UserMerch, 0, len(ids))
+	err := conn(ctx, r.db).SelectContext(ctx, &userMerch, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	return userMerch, nil
+}
+
+// Transfer передает предметы из инвентаря другому пользователю
+func (r *UserMerchRepository) Transfer(ctx context.Context, ids []int64, toUserID int64) error {
+	query := `
+		UPDATE user_merch
+		SET user_id = $2, traded_at = NOW()
+		WHERE id = ANY($1) AND status = 'owned'`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, pq.Array(ids), toUserID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows != int64(len(ids)) {
+		return errors.New("some items are no longer available")
+	}
+
+	return nil
+}
+
+// SetStatus меняет статус предмета из инвентаря
+func (r *UserMerchRepository) SetStatus(ctx context.Context, id int64, status string) error {
+	query := `UPDATE user_merch SET status = $2 WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id, status)
+	return err
+}
+
+// CancelByOrder помечает мерч из заказа отмененным
+func (r *UserMerchRepository) CancelByOrder(ctx context.Context, orderID int64) error {
+	query := `
+		UPDATE user_merch
+		SET status = 'cancelled'
+		WHERE order_id = $1 AND status = 'owned'`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, orderID)
+	return err
+}
+
+// CountPurchased считает единицы товара, купленные пользователем начиная с since:
+// заказанные им для себя или подаренные ему. Учитываются заказы, а не текущий владелец,
+// поэтому переданный другому мерч остается в лимите покупателя.
+// Отмененные и возвращенные покупки не учитываются
+func (r *UserMerchRepository) CountPurchased(ctx context.Context, userID, merchID int64, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM user_merch um
+		JOIN orders o ON o.id = um.order_id
+		WHERE COALESCE(o.recipient_id, o.user_id) = $1 AND um.merch_id = $2
+			AND um.status NOT IN ('cancelled', 'returned') AND o.created_at >= $3`
+
+	var count int
+	err := conn(ctx, r.db).GetContext(ctx, &count, query, userID, merchID, since)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetGifts получает подарки, полученные и отправленные пользователем.
+// Единицы одного товара из одного заказа объединяются, отмененные подарки не учитываются
+func (r *UserMerchRepository) GetGifts(ctx context.Context, userID int64) ([]models.Gift, error) {
+	query := `
+		SELECT um.order_id, um.given_by AS from_user_id, giver.username AS from_user,
+		       um.user_id AS to_user_id, recipient.username AS to_user,
+		       m.name AS item, v.sku AS variant, COUNT(*) AS quantity, um.gift_note AS note,
+		       MIN(um.created_at) AS created_at
+		FROM user_merch um
+		JOIN merch_items m ON m.id = um.merch_id
+		JOIN users giver ON giver.id = um.given_by
+		JOIN users recipient ON recipient.id = um.user_id
+		LEFT JOIN merch_variants v ON v.id = um.variant_id
+		WHERE (um.user_id = $1 OR um.given_by = $1) AND um.given_by IS NOT NULL AND um.status <> 'cancelled'
+		GROUP BY um.order_id, um.given_by, giver.username, um.user_id, recipient.username, m.name, v.sku, um.gift_note
+		ORDER BY MIN(um.created_at) DESC`
+
+	gifts := make([]models.Gift, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &gifts, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gifts, nil
+}
@@ -6,11 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/jmoiron/sqlx"
 )
 
+const userColumns = `id, username, password, coins, held_coins, created_at, is_admin, transfers_on_hold, is_system`
+
 // UserRepository реализует интерфейс repository.UserRepository
 type UserRepository struct {
 	db *sqlx.DB
@@ -23,18 +26,25 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 	}
 }
 
-// Create создает нового пользователя
+// Create создает нового пользователя вместе с партией начальных монет
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (username, password, coins)
-		VALUES ($1, $2, $3)
-		RETURNING id`
-
-	err := r.db.QueryRowContext(ctx, query,
+		WITH u AS (
+			INSERT INTO users (username, password, coins, is_system)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, coins, created_at
+		), lot AS (
+			INSERT INTO coin_lots (user_id, amount, remaining)
+			SELECT id, coins, coins FROM u WHERE coins > 0
+		)
+		SELECT id, created_at FROM u`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		user.Username,
 		user.Password,
 		user.Coins,
-	).Scan(&user.ID)
+		user.IsSystem,
+	).Scan(&user.ID, &user.CreatedAt)
 
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
@@ -49,11 +59,11 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, username, password, coins
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE username = $1`
 
-	err := r.db.GetContext(ctx, user, query, username)
+	err := conn(ctx, r.db).GetContext(ctx, user, query, username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("user not found")
@@ -64,27 +74,77 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	return user, nil
 }
 
-// UpdateCoins обновляет количество монет пользователя
+// UpdateCoins обновляет количество монет пользователя.
+// Списать можно только монеты, не занятые удержаниями
 func (r *UserRepository) UpdateCoins(ctx context.Context, userID int64, amount int64) error {
 	query := `
 		UPDATE users
 		SET coins = coins + $1
-		WHERE id = $2 AND coins + $1 >= 0`
+		WHERE id = $2 AND coins + $1 >= held_coins
+		RETURNING coins`
 
-	result, err := r.db.ExecContext(ctx, query, amount, userID)
+	var balance int64
+	err := conn(ctx, r.db).GetContext(ctx, &balance, query, amount, userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("insufficient funds")
+		}
 		return err
 	}
 
-	rows, err := result.RowsAffected()
+	return r.updateLots(ctx, userID, amount, balance)
+}
+
+// ForceUpdateCoins изменяет количество монет без проверки баланса, допуская отрицательный остаток
+func (r *UserRepository) ForceUpdateCoins(ctx context.Context, userID int64, amount int64) error {
+	query := `
+		UPDATE users
+		SET coins = coins + $1
+		WHERE id = $2
+		RETURNING coins`
+
+	var balance int64
+	err := conn(ctx, r.db).GetContext(ctx, &balance, query, amount, userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("insufficient funds")
+	return r.updateLots(ctx, userID, amount, balance)
+}
+
+// RefundCoins возвращает пользователю монеты, списанные в транзакции, начатой в spentAt.
+// Монеты возвращаются в исходные партии и сгорают в тот же срок, что и до списания
+func (r *UserRepository) RefundCoins(ctx context.Context, userID, amount int64, spentAt time.Time) error {
+	query := `
+		UPDATE users
+		SET coins = coins + $1
+		WHERE id = $2
+		RETURNING coins`
+
+	var balance int64
+	err := conn(ctx, r.db).GetContext(ctx, &balance, query, amount, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return err
 	}
 
+	return restoreCoinLots(ctx, r.db, userID, amount, balance, spentAt)
+}
+
+// updateLots отражает изменение баланса в партиях монет: зачисление создает новую партию,
+// а списание расходует самые старые
+func (r *UserRepository) updateLots(ctx context.Context, userID, amount, balance int64) error {
+	switch {
+	case amount > 0:
+		return addCoinLot(ctx, r.db, userID, amount, balance)
+	case amount < 0:
+		return consumeCoinLots(ctx, r.db, userID, -amount)
+	}
 	return nil
 }
 
@@ -93,12 +153,12 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 	log.Printf("Getting user by ID: %d (type: %T)", id, id)
 	user := &models.User{}
 	query := `
-		SELECT id, username, password, coins
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1`
 
 	log.Printf("Executing query: %s with ID: %d", query, id)
-	err := r.db.GetContext(ctx, user, query, id)
+	err := conn(ctx, r.db).GetContext(ctx, user, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("User with ID %d not found", id)
@@ -112,6 +172,74 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 	return user, nil
 }
 
+// GetByIDForUpdate получает пользователя по ID и блокирует его строку до конца транзакции
+func (r *UserRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.User, error) {
+	user := &models.User{}
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = $1
+		FOR UPDATE`
+
+	err := conn(ctx, r.db).GetContext(ctx, user, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	return user, nil
+}
+
+// SetTransfersOnHold включает или снимает блокировку исходящих переводов пользователя
+func (r *UserRepository) SetTransfersOnHold(ctx context.Context, userID int64, hold bool) error {
+	query := `
+		UPDATE users
+		SET transfers_on_hold = $2
+		WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, hold)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// SetLeaderboardOptOut скрывает пользователя из рейтингов или возвращает его в них
+func (r *UserRepository) SetLeaderboardOptOut(ctx context.Context, userID int64, optOut bool) error {
+	query := `
+		UPDATE users
+		SET leaderboard_opt_out = $2
+		WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, optOut)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
 func (r *UserRepository) GetDB() *sqlx.DB {
 	return r.db
 }
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// WishlistRepository реализует интерфейс repository.WishlistRepository
+type WishlistRepository struct {
+	db *sqlx.DB
+}
+
+// NewWishlistRepository создает новый экземпляр WishlistRepository
+func NewWishlistRepository(db *sqlx.DB) *WishlistRepository {
+	return &WishlistRepository{
+		db: db,
+	}
+}
+
+// Add добавляет товар в список желаний, запоминая его текущее наличие.
+// Повторное добавление ничего не меняет
+func (r *WishlistRepository) Add(ctx context.Context, userID, merchID int64) error {
+	query := `
+		INSERT INTO wishlist_items (user_id, merch_id, in_stock)
+		SELECT $1, id, ` + merchInStock + `
+		FROM merch_items
+		WHERE id = $2
+		ON CONFLICT (user_id, merch_id) DO NOTHING`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, merchID)
+	return err
+}
+
+// Remove удаляет товар из списка желаний
+func (r *WishlistRepository) Remove(ctx context.Context, userID, merchID int64) error {
+	query := `DELETE FROM wishlist_items WHERE user_id = $1 AND merch_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, merchID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("item is not in the wishlist")
+	}
+
+	return nil
+}
+
+// wishlistQuery выбирает позиции списка желаний с текущими данными товаров
+const wishlistQuery = `
+		SELECT w.user_id, w.merch_id, merch_items.name, merch_items.display_name, merch_items.category,
+			merch_items.price, ` + merchInStock + ` AS in_stock, w.notified_discount_id, w.added_at
+		FROM wishlist_items w
+		JOIN merch_items ON merch_items.id = w.merch_id`
+
+// GetItems получает список желаний пользователя
+func (r *WishlistRepository) GetItems(ctx context.Context, userID int64) ([]models.WishlistItem, error) {
+	query := wishlistQuery + `
+		WHERE w.user_id = $1
+		ORDER BY w.added_at`
+
+	items := make([]models.WishlistItem, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &items, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetAll получает списки желаний всех пользователей
+func (r *WishlistRepository) GetAll(ctx context.Context) ([]models.WishlistItem, error) {
+	query := wishlistQuery + `
+		ORDER BY w.merch_id, w.user_id`
+
+	items := make([]models.WishlistItem, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SyncStock запоминает текущее наличие товаров в списках желаний
+// и возвращает позиции, у которых наличие изменилось
+func (r *WishlistRepository) SyncStock(ctx context.Context) ([]models.WishlistItem, error) {
+	query := `
+		UPDATE wishlist_items w
+		SET in_stock = s.in_stock
+		FROM (
+			SELECT id, name, display_name, category, price, ` + merchInStock + ` AS in_stock
+			FROM merch_items
+		) s
+		WHERE w.merch_id = s.id AND w.in_stock <> s.in_stock
+		RETURNING w.user_id, w.merch_id, s.name, s.display_name, s.category, s.price,
+			w.in_stock, w.notified_discount_id, w.added_at`
+
+	items := make([]models.WishlistItem, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SetNotifiedDiscount запоминает скидку, о которой пользователь уже уведомлен
+func (r *WishlistRepository) SetNotifiedDiscount(ctx context.Context, userID, merchID int64, discountID *int64) error {
+	query := `
+		UPDATE wishlist_items
+		SET notified_discount_id = $3
+		WHERE user_id = $1 AND merch_id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, merchID, discountID)
+	return err
+}
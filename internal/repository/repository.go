@@ -2,40 +2,287 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/haqer0002/avito-shop/internal/models"
 )
 
+// Transactor определяет выполнение операций в рамках одной транзакции БД
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // UserRepository определяет методы для работы с пользователями
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.User, error)
 	UpdateCoins(ctx context.Context, userID int64, amount int64) error
+	ForceUpdateCoins(ctx context.Context, userID int64, amount int64) error
+	RefundCoins(ctx context.Context, userID, amount int64, spentAt time.Time) error
+	SetTransfersOnHold(ctx context.Context, userID int64, hold bool) error
+	SetLeaderboardOptOut(ctx context.Context, userID int64, optOut bool) error
 }
 
 // TransactionRepository определяет методы для работы с транзакциями
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *models.Transaction) error
 	GetUserTransactions(ctx context.Context, userID int64) ([]models.Transaction, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Transaction, error)
+	GetOutgoingStats(ctx context.Context, fromUserID, toUserID int64, since time.Time) (*models.TransferStats, error)
 }
 
 // MerchRepository определяет методы для работы с мерчем
 type MerchRepository interface {
 	GetByName(ctx context.Context, name string) (*models.MerchItem, error)
-	GetAll(ctx context.Context) ([]models.MerchItem, error)
+	GetByID(ctx context.Context, id int64) (*models.MerchItem, error)
+	Find(ctx context.Context, filter models.MerchFilter) ([]models.MerchItem, int, error)
+	GetCategories(ctx context.Context) ([]models.MerchCategory, error)
+	UpdateDetails(ctx context.Context, merch *models.MerchItem) error
+	DecrementStock(ctx context.Context, merchID int64, quantity int) error
+	Restock(ctx context.Context, merchID int64, quantity int) error
+	GetVariants(ctx context.Context, merchIDs []int64) ([]models.MerchVariant, error)
+	GetVariantBySKU(ctx context.Context, sku string) (*models.MerchVariant, error)
+	DecrementVariantStock(ctx context.Context, variantID int64, quantity int) error
+	RestockVariant(ctx context.Context, variantID int64, quantity int) error
+	UpdatePrice(ctx context.Context, merchID, price int64) (int64, error)
+	AddPriceChange(ctx context.Context, change *models.PriceChange) error
+	GetPriceHistory(ctx context.Context, merchID int64) ([]models.PriceChange, error)
+	AddImage(ctx context.Context, image *models.MerchImage) error
+	GetImages(ctx context.Context, merchIDs []int64) ([]models.MerchImage, error)
+	GetImage(ctx context.Context, imageID int64) (*models.MerchImage, error)
+	DeleteImage(ctx context.Context, imageID int64) error
+}
+
+// CartRepository определяет методы для работы с корзиной
+type CartRepository interface {
+	GetItems(ctx context.Context, userID int64) ([]models.CartItem, error)
+	AddItem(ctx context.Context, userID, merchID int64, variantID *int64, quantity int) error
+	SetQuantity(ctx context.Context, userID, merchID int64, variantID *int64, quantity int) error
+	RemoveItem(ctx context.Context, userID, merchID int64, variantID *int64) error
+	Clear(ctx context.Context, userID int64) error
+}
+
+// WishlistRepository определяет методы для работы со списком желаний
+type WishlistRepository interface {
+	Add(ctx context.Context, userID, merchID int64) error
+	Remove(ctx context.Context, userID, merchID int64) error
+	GetItems(ctx context.Context, userID int64) ([]models.WishlistItem, error)
+	GetAll(ctx context.Context) ([]models.WishlistItem, error)
+	SyncStock(ctx context.Context) ([]models.WishlistItem, error)
+	SetNotifiedDiscount(ctx context.Context, userID, merchID int64, discountID *int64) error
+}
+
+// NotificationRepository определяет методы для работы с уведомлениями
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	GetUserNotifications(ctx context.Context, userID int64, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(ctx context.Context, userID, notificationID int64) error
 }
 
 // UserMerchRepository определяет методы для работы с купленным мерчем
 type UserMerchRepository interface {
 	Create(ctx context.Context, userMerch *models.UserMerch) error
 	GetUserMerch(ctx context.Context, userID int64) ([]models.UserMerch, error)
+	CancelByOrder(ctx context.Context, orderID int64) error
+	CountPurchased(ctx context.Context, userID, merchID int64, since time.Time) (int, error)
+	GetGifts(ctx context.Context, userID int64) ([]models.Gift, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.UserMerch, error)
+	SetStatus(ctx context.Context, id int64, status string) error
+	GetByIDsForUpdate(ctx context.Context, ids []int64) ([]models.UserMerch, error)
+	Transfer(ctx context.Context, ids []int64, toUserID int64) error
+}
+
+// TradeRepository определяет методы для работы с предложениями обмена между пользователями
+type TradeRepository interface {
+	Create(ctx context.Context, trade *models.Trade) error
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Trade, error)
+	GetUserTrades(ctx context.Context, userID int64) ([]models.Trade, error)
+	SetStatus(ctx context.Context, id int64, status string) error
+}
+
+// ReturnRepository определяет методы для работы с заявками на возврат и обмен мерча
+type ReturnRepository interface {
+	Create(ctx context.Context, ret *models.MerchReturn) error
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.MerchReturn, error)
+	GetUserReturns(ctx context.Context, userID int64) ([]models.MerchReturn, error)
+	GetByStatus(ctx context.Context, status string) ([]models.MerchReturn, error)
+	Resolve(ctx context.Context, ret *models.MerchReturn) error
+}
+
+// MarketRepository определяет методы для работы с объявлениями маркетплейса
+type MarketRepository interface {
+	Create(ctx context.Context, listing *models.MarketListing) error
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.MarketListing, error)
+	Find(ctx context.Context, filter models.MarketFilter) ([]models.MarketListing, int, error)
+	GetUserListings(ctx context.Context, sellerID int64) ([]models.MarketListing, error)
+	Close(ctx context.Context, listing *models.MarketListing) error
+}
+
+// AuctionRepository определяет методы для работы с аукционами и ставками
+type AuctionRepository interface {
+	Create(ctx context.Context, auction *models.Auction) error
+	GetByID(ctx context.Context, id int64) (*models.Auction, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Auction, error)
+	GetOpen(ctx context.Context) ([]models.Auction, error)
+	LockDue(ctx context.Context, limit int) ([]models.Auction, error)
+	Close(ctx context.Context, id int64) error
+	GetBids(ctx context.Context, auctionID int64) ([]models.AuctionBid, error)
+	SaveBid(ctx context.Context, bid *models.AuctionBid) error
+	SetBidStatus(ctx context.Context, bidID int64, status string) error
+}
+
+// HoldRepository определяет методы для работы с удержаниями монет.
+// Изменение статуса удержания и баланса пользователя выполняется атомарно
+type HoldRepository interface {
+	Create(ctx context.Context, hold *models.CoinHold) error
+	GetByID(ctx context.Context, id int64) (*models.CoinHold, error)
+	GetActive(ctx context.Context, userID int64) ([]models.CoinHold, error)
+	Capture(ctx context.Context, id int64) (*models.CoinHold, error)
+	Resolve(ctx context.Context, id int64, status string) (*models.CoinHold, error)
+	LockExpired(ctx context.Context, limit int) ([]models.CoinHold, error)
+}
+
+// CoinLotRepository определяет методы для работы с партиями монет.
+// Партии создаются и расходуются вместе с изменением баланса в UserRepository
+type CoinLotRepository interface {
+	GetActive(ctx context.Context, userID int64) ([]models.CoinLot, error)
+	GetUsersWithExpired(ctx context.Context, grantedBefore time.Time, limit int) ([]int64, error)
+	SumRemaining(ctx context.Context, userID int64, grantedBefore time.Time) (int64, error)
+	MarkWarned(ctx context.Context, grantedBefore time.Time, limit int) ([]models.CoinLot, error)
+}
+
+// AchievementRepository определяет методы для работы с каталогом и выданными достижениями
+type AchievementRepository interface {
+	GetByCode(ctx context.Context, code string) (*models.Achievement, error)
+	Award(ctx context.Context, userID int64, code string, level int) (bool, error)
+	GetUserAchievements(ctx context.Context, userID int64) ([]models.UserAchievement, error)
+	GetDueAnniversaries(ctx context.Context, code string, limit int) ([]models.WorkAnniversary, error)
+}
+
+// LeaderboardRepository определяет методы для работы с рейтингами переводов
+type LeaderboardRepository interface {
+	GetTop(ctx context.Context, board, period string, limit int) ([]models.LeaderboardEntry, error)
+	Refresh(ctx context.Context) error
+}
+
+// TeamRepository определяет методы для работы с командами и их участниками.
+// Баланс кошелька команды хранится на ее служебном аккаунте в UserRepository
+type TeamRepository interface {
+	Create(ctx context.Context, team *models.Team) error
+	GetByID(ctx context.Context, id int64) (*models.Team, error)
+	GetUserTeams(ctx context.Context, userID int64) ([]models.Team, error)
+	GetMembers(ctx context.Context, teamID int64) ([]models.TeamMember, error)
+	GetMember(ctx context.Context, teamID, userID int64) (*models.TeamMember, error)
+	SetMember(ctx context.Context, member *models.TeamMember) error
+	RemoveMember(ctx context.Context, teamID, userID int64) error
+}
+
+// RaffleRepository определяет методы для работы с розыгрышами, билетами и победителями
+type RaffleRepository interface {
+	Create(ctx context.Context, raffle *models.Raffle) error
+	GetByID(ctx context.Context, id int64) (*models.Raffle, error)
+	GetOpen(ctx context.Context) ([]models.Raffle, error)
+	AddEntry(ctx context.Context, entry *models.RaffleEntry) error
+	GetEntries(ctx context.Context, raffleID int64) ([]models.RaffleEntry, error)
+	LockDue(ctx context.Context, limit int) ([]models.Raffle, error)
+	AddWinner(ctx context.Context, winner *models.RaffleWinner) error
+	GetWinners(ctx context.Context, raffleID int64) ([]models.RaffleWinner, error)
+	MarkDrawn(ctx context.Context, id int64) error
+}
+
+// OrderRepository определяет методы для работы с заказами
+type OrderRepository interface {
+	Create(ctx context.Context, order *models.Order) error
+	GetUserOrders(ctx context.Context, userID int64) ([]models.Order, error)
+	GetByID(ctx context.Context, id int64) (*models.Order, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Order, error)
+	UpdateStatus(ctx context.Context, id int64, status string) error
+}
+
+// DiscountRepository определяет методы для работы со скидками
+type DiscountRepository interface {
+	Create(ctx context.Context, discount *models.Discount) error
+	GetActive(ctx context.Context, at time.Time) ([]models.Discount, error)
+	GetAll(ctx context.Context) ([]models.Discount, error)
+}
+
+// PromoCodeRepository определяет методы для работы с промокодами
+type PromoCodeRepository interface {
+	Create(ctx context.Context, promo *models.PromoCode) error
+	GetAll(ctx context.Context) ([]models.PromoCode, error)
+	GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error)
+	CountUserRedemptions(ctx context.Context, promoCodeID, userID int64) (int, error)
+	Redeem(ctx context.Context, promoCodeID, userID, orderID int64) error
+}
+
+// PaymentRequestRepository определяет методы для работы с запросами на перевод монет
+type PaymentRequestRepository interface {
+	Create(ctx context.Context, request *models.PaymentRequest) error
+	Resolve(ctx context.Context, id, payerID int64, status string) (*models.PaymentRequest, error)
+	GetIncoming(ctx context.Context, payerID int64) ([]models.PaymentRequest, error)
+	GetOutgoing(ctx context.Context, requesterID int64) ([]models.PaymentRequest, error)
+}
+
+// ScheduledTransferRepository определяет методы для работы с запланированными переводами
+type ScheduledTransferRepository interface {
+	Create(ctx context.Context, schedule *models.ScheduledTransfer) error
+	GetUserSchedules(ctx context.Context, userID int64) ([]models.ScheduledTransfer, error)
+	SetStatus(ctx context.Context, id, userID int64, fromStatuses []string, status string) error
+	LockDue(ctx context.Context, limit int) ([]models.ScheduledTransfer, error)
+	Update(ctx context.Context, schedule *models.ScheduledTransfer) error
+}
+
+// FraudRepository определяет запросы поиска подозрительных паттернов в переводах
+type FraudRepository interface {
+	FindCircularTransfers(ctx context.Context, since time.Time) ([]models.FraudSignal, error)
+	FindNewAccountFunnels(ctx context.Context, since, accountsCreatedAfter time.Time, minSenders int) ([]models.FraudSignal, error)
+	FindBursts(ctx context.Context, since, baselineSince time.Time, minTransfers int) ([]models.FraudSignal, error)
+}
+
+// AlertRepository определяет методы для работы с оповещениями о подозрительных операциях
+type AlertRepository interface {
+	Create(ctx context.Context, alert *models.Alert) (bool, error)
+	GetByStatus(ctx context.Context, status string) ([]models.Alert, error)
+	Review(ctx context.Context, id, reviewerID int64, status string) (*models.Alert, error)
+}
+
+// DisputeRepository определяет методы для работы со спорами по переводам
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *models.Dispute) error
+	GetUserDisputes(ctx context.Context, userID int64) ([]models.Dispute, error)
+	GetByStatus(ctx context.Context, status string) ([]models.Dispute, error)
+	Resolve(ctx context.Context, id, adminID int64, status, resolution string) error
+	ResolveByTransaction(ctx context.Context, transactionID, adminID int64, status, resolution string) error
 }
 
 // Repository объединяет все репозитории
 type Repository struct {
-	Users        UserRepository
-	Transactions TransactionRepository
-	Merch        MerchRepository
-	UserMerch    UserMerchRepository
+	Transactor      Transactor
+	Users           UserRepository
+	Transactions    TransactionRepository
+	Merch           MerchRepository
+	UserMerch       UserMerchRepository
+	Orders          OrderRepository
+	Returns         ReturnRepository
+	Trades          TradeRepository
+	Market          MarketRepository
+	Auctions        AuctionRepository
+	Raffles         RaffleRepository
+	Holds           HoldRepository
+	CoinLots        CoinLotRepository
+	Achievements    AchievementRepository
+	Leaderboards    LeaderboardRepository
+	Teams           TeamRepository
+	Carts           CartRepository
+	Wishlists       WishlistRepository
+	Notifications   NotificationRepository
+	Discounts       DiscountRepository
+	PromoCodes      PromoCodeRepository
+	PaymentRequests PaymentRequestRepository
+	Schedules       ScheduledTransferRepository
+	Fraud           FraudRepository
+	Alerts          AlertRepository
+	Disputes        DisputeRepository
 }
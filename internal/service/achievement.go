@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// Коды достижений из каталога
+const (
+	AchievementWelcome           = "welcome"
+	AchievementFirstPurchase     = "first_purchase"
+	AchievementGenerousColleague = "generous_colleague"
+	AchievementWorkAnniversary   = "work_anniversary"
+)
+
+const (
+	// generousRecipients — сколько разных коллег нужно отблагодарить для достижения generous_colleague
+	generousRecipients = 10
+	// achievementBatchSize — сколько годовщин обрабатывается за один запуск
+	achievementBatchSize = 100
+)
+
+// achievementRule связывает достижение с событием, на которое оно проверяется
+type achievementRule struct {
+	code  string
+	event string
+	// evaluate возвращает получателя и уровень заслуженного достижения; нулевой уровень
+	// означает, что условие не выполнено
+	evaluate func(ctx context.Context, s *achievementServiceImpl, event events.Event) (int64, int, error)
+}
+
+// achievementRules — правила, проверяемые при доменных событиях. Годовщина работы
+// не связана с событием и проверяется периодически в AwardAnniversaries
+var achievementRules = []achievementRule{
+	{
+		code:  AchievementWelcome,
+		event: events.UserCreatedEvent,
+		evaluate: func(ctx context.Context, s *achievementServiceImpl, event events.Event) (int64, int, error) {
+			return event.(events.UserCreated).UserID, 1, nil
+		},
+	},
+	{
+		// Бонус за первую покупку выдается при доставке заказа, а не при оплате:
+		// иначе его можно получить и сохранить, отменив заказ. Кошельки команд и другие
+		// системные аккаунты достижений не получают
+		code:  AchievementFirstPurchase,
+		event: events.OrderDeliveredEvent,
+		evaluate: func(ctx context.Context, s *achievementServiceImpl, event events.Event) (int64, int, error) {
+			delivered := event.(events.OrderDelivered)
+			buyer, err := s.userRepo.GetByID(ctx, delivered.UserID)
+			if err != nil {
+				return 0, 0, err
+			}
+			if buyer.IsSystem {
+				return buyer.ID, 0, nil
+			}
+			return buyer.ID, 1, nil
+		},
+	},
+	{
+		code:  AchievementGenerousColleague,
+		event: events.CoinsSentEvent,
+		evaluate: func(ctx context.Context, s *achievementServiceImpl, event events.Event) (int64, int, error) {
+			sent := event.(events.CoinsSent)
+			stats, err := s.transactionRepo.GetOutgoingStats(ctx, sent.FromUserID, sent.ToUserID, time.Time{})
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to get transfer stats: %w", err)
+			}
+			if stats.Recipients < generousRecipients {
+				return sent.FromUserID, 0, nil
+			}
+			return sent.FromUserID, 1, nil
+		},
+	},
+}
+
+type achievementServiceImpl struct {
+	transactor       repository.Transactor
+	userRepo         repository.UserRepository
+	transactionRepo  repository.TransactionRepository
+	achievementRepo  repository.AchievementRepository
+	notificationRepo repository.NotificationRepository
+}
+
+func NewAchievementService(transactor repository.Transactor, userRepo repository.UserRepository, transactionRepo repository.TransactionRepository, achievementRepo repository.AchievementRepository, notificationRepo repository.NotificationRepository) AchievementService {
+	return &achievementServiceImpl{
+		transactor:       transactor,
+		userRepo:         userRepo,
+		transactionRepo:  transactionRepo,
+		achievementRepo:  achievementRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+// GetUserAchievements возвращает достижения пользователя
+func (s *achievementServiceImpl) GetUserAchievements(ctx context.Context, userID int64) ([]models.UserAchievement, error) {
+	return s.achievementRepo.GetUserAchievements(ctx, userID)
+}
+
+// HandleEvent проверяет правила, подписанные на событие, и выдает заслуженные достижения.
+// Ошибка одного правила не мешает проверить остальные. Проверка и выдача идут в отдельной точке
+// сохранения: событие может прийти внутри транзакции издателя, и ошибка запроса правила
+// иначе прервала бы ее целиком
+func (s *achievementServiceImpl) HandleEvent(ctx context.Context, event events.Event) error {
+	var errs []error
+	for _, rule := range achievementRules {
+		if rule.event != event.Name() {
+			continue
+		}
+
+		err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			userID, level, err := rule.evaluate(ctx, s, event)
+			if err != nil || level <= 0 {
+				return err
+			}
+			return s.award(ctx, userID, rule.code, level)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("achievement %s: %w", rule.code, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// AwardAnniversaries выдает достижения за годовщины работы, которые наступили с прошлой проверки
+func (s *achievementServiceImpl) AwardAnniversaries(ctx context.Context) error {
+	anniversaries, err := s.achievementRepo.GetDueAnniversaries(ctx, AchievementWorkAnniversary, achievementBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to find work anniversaries: %w", err)
+	}
+
+	for _, anniversary := range anniversaries {
+		if err := s.award(ctx, anniversary.UserID, AchievementWorkAnniversary, anniversary.Years); err != nil {
+			log.Printf("Awarding work anniversary to user %d failed: %v", anniversary.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// award выдает достижение вместе с бонусом и уведомлением. Повторная выдача того же уровня
+// ничего не делает, поэтому правило можно проверять сколько угодно раз
+func (s *achievementServiceImpl) award(ctx context.Context, userID int64, code string, level int) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		achievement, err := s.achievementRepo.GetByCode(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		awarded, err := s.achievementRepo.Award(ctx, userID, code, level)
+		if err != nil {
+			return fmt.Errorf("failed to award achievement: %w", err)
+		}
+		if !awarded {
+			return nil
+		}
+
+		message := fmt.Sprintf("You earned the %q badge", achievement.Title)
+		if level > 1 {
+			message = fmt.Sprintf("You earned the %q badge (level %d)", achievement.Title, level)
+		}
+
+		if achievement.Bonus > 0 {
+			if err := s.userRepo.UpdateCoins(ctx, userID, achievement.Bonus); err != nil {
+				return fmt.Errorf("failed to credit achievement bonus: %w", err)
+			}
+
+			transaction := &models.Transaction{
+				ToUserID:    userID,
+				Amount:      achievement.Bonus,
+				Description: fmt.Sprintf("Bonus for the %q achievement", achievement.Title),
+				Kind:        models.TransactionBonus,
+			}
+			if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+				return fmt.Errorf("failed to create transaction record: %w", err)
+			}
+
+			message += fmt.Sprintf(" and %d coins", achievement.Bonus)
+		}
+
+		notification := &models.Notification{
+			UserID:  userID,
+			Kind:    models.NotificationAchievement,
+			Message: message,
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			return fmt.Errorf("failed to create notification: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAchievementService_HandleEvent_Bonus(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockAchievementRepo := new(MockAchievementRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	service := NewAchievementService(new(MockTransactor), mockUserRepo, mockTransactionRepo, mockAchievementRepo, mockNotificationRepo)
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByID", ctx, int64(1)).Return(&models.User{ID: 1, Username: "buyer"}, nil)
+	mockAchievementRepo.On("GetByCode", ctx, AchievementFirstPurchase).Return(&models.Achievement{Code: AchievementFirstPurchase, Title: "First purchase", Bonus: 50}, nil)
+	mockAchievementRepo.On("Award", ctx, int64(1), AchievementFirstPurchase, 1).Return(true, nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), int64(50)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 0 && transaction.ToUserID == 1 && transaction.Amount == 50 && transaction.Kind == models.TransactionBonus
+	})).Return(nil)
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 1 && notification.Kind == models.NotificationAchievement &&
+			notification.Message == `You earned the "First purchase" badge and 50 coins`
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.HandleEvent(ctx, events.OrderDelivered{UserID: 1, OrderID: 7})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockAchievementRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+func TestAchievementService_HandleEvent_AlreadyAwarded(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockAchievementRepo := new(MockAchievementRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	service := NewAchievementService(new(MockTransactor), mockUserRepo, new(MockTransactionRepository), mockAchievementRepo, mockNotificationRepo)
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByID", ctx, int64(1)).Return(&models.User{ID: 1, Username: "buyer"}, nil)
+	mockAchievementRepo.On("GetByCode", ctx, AchievementFirstPurchase).Return(&models.Achievement{Code: AchievementFirstPurchase, Bonus: 50}, nil)
+	// Повторная доставка не приносит ни бонуса, ни уведомления
+	mockAchievementRepo.On("Award", ctx, int64(1), AchievementFirstPurchase, 1).Return(false, nil)
+
+	// Вызываем тестируемый метод
+	err := service.HandleEvent(ctx, events.OrderDelivered{UserID: 1, OrderID: 8})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockAchievementRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+	mockNotificationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAchievementService_HandleEvent_SystemBuyer(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockAchievementRepo := new(MockAchievementRepository)
+
+	service := NewAchievementService(new(MockTransactor), mockUserRepo, new(MockTransactionRepository), mockAchievementRepo, new(MockNotificationRepository))
+
+	ctx := context.Background()
+
+	// Настраиваем моки: заказ оплачен кошельком команды
+	mockUserRepo.On("GetByID", ctx, int64(100)).Return(&models.User{ID: 100, Username: "team:platform", IsSystem: true}, nil)
+
+	// Вызываем тестируемый метод
+	err := service.HandleEvent(ctx, events.OrderDelivered{UserID: 100, OrderID: 9})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockAchievementRepo.AssertNotCalled(t, "Award", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// savepointTransactor считает вложенность транзакций, чтобы проверить, где выполняются запросы
+type savepointTransactor struct {
+	depth int
+}
+
+func (t *savepointTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.depth++
+	defer func() { t.depth-- }()
+	return fn(ctx)
+}
+
+func TestAchievementService_HandleEvent_EvaluatesInSavepoint(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	transactor := &savepointTransactor{}
+
+	service := NewAchievementService(transactor, mockUserRepo, new(MockTransactionRepository), new(MockAchievementRepository), new(MockNotificationRepository))
+
+	ctx := context.Background()
+
+	// Настраиваем моки: запрос правила падает, но только внутри собственной точки сохранения
+	mockUserRepo.On("GetByID", ctx, int64(1)).Run(func(args mock.Arguments) {
+		assert.Equal(t, 1, transactor.depth)
+	}).Return(nil, errors.New("connection reset"))
+
+	// Вызываем тестируемый метод
+	err := service.HandleEvent(ctx, events.OrderDelivered{UserID: 1, OrderID: 7})
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "connection reset")
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAchievementService_HandleEvent_GenerousColleague(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockAchievementRepo := new(MockAchievementRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	service := NewAchievementService(new(MockTransactor), new(MockUserRepository), mockTransactionRepo, mockAchievementRepo, mockNotificationRepo)
+
+	ctx := context.Background()
+
+	// Настраиваем моки: девять разных получателей — условие еще не выполнено
+	mockTransactionRepo.On("GetOutgoingStats", ctx, int64(1), int64(2), time.Time{}).Return(&models.TransferStats{Recipients: 9}, nil).Once()
+
+	// Вызываем тестируемый метод
+	err := service.HandleEvent(ctx, events.CoinsSent{FromUserID: 1, ToUserID: 2, Amount: 10})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockAchievementRepo.AssertNotCalled(t, "Award", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Десятый получатель приносит достижение без бонуса
+	mockTransactionRepo.On("GetOutgoingStats", ctx, int64(1), int64(3), time.Time{}).Return(&models.TransferStats{Recipients: 10}, nil).Once()
+	mockAchievementRepo.On("GetByCode", ctx, AchievementGenerousColleague).Return(&models.Achievement{Code: AchievementGenerousColleague, Title: "Generous colleague"}, nil)
+	mockAchievementRepo.On("Award", ctx, int64(1), AchievementGenerousColleague, 1).Return(true, nil)
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 1 && notification.Message == `You earned the "Generous colleague" badge`
+	})).Return(nil)
+
+	err = service.HandleEvent(ctx, events.CoinsSent{FromUserID: 1, ToUserID: 3, Amount: 10})
+
+	assert.NoError(t, err)
+	mockTransactionRepo.AssertExpectations(t)
+	mockAchievementRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+func TestAchievementService_AwardAnniversaries(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockAchievementRepo := new(MockAchievementRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	service := NewAchievementService(new(MockTransactor), mockUserRepo, mockTransactionRepo, mockAchievementRepo, mockNotificationRepo)
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockAchievementRepo.On("GetDueAnniversaries", ctx, AchievementWorkAnniversary, achievementBatchSize).Return([]models.WorkAnniversary{
+		{UserID: 1, Years: 3},
+		{UserID: 2, Years: 1},
+	}, nil)
+	mockAchievementRepo.On("GetByCode", ctx, AchievementWorkAnniversary).Return(&models.Achievement{Code: AchievementWorkAnniversary, Title: "Work anniversary", Bonus: 200}, nil)
+	// Ошибка выдачи первому пользователю не мешает наградить второго
+	mockAchievementRepo.On("Award", ctx, int64(1), AchievementWorkAnniversary, 3).Return(false, errors.New("connection reset"))
+	mockAchievementRepo.On("Award", ctx, int64(2), AchievementWorkAnniversary, 1).Return(true, nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(2), int64(200)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.ToUserID == 2 && transaction.Amount == 200
+	})).Return(nil)
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 2 && notification.Message == `You earned the "Work anniversary" badge and 200 coins`
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.AwardAnniversaries(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockAchievementRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+func TestUserService_SendCoins_PublishesEvent(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+
+	var published []events.Event
+	bus := events.NewBus()
+	bus.Subscribe(events.CoinsSentEvent, func(ctx context.Context, event events.Event) error {
+		published = append(published, event)
+		return nil
+	})
+
+	service := NewUserService(new(MockTransactor), mockUserRepo, mockTransactionRepo, new(MockUserMerchRepository), NewTransferPolicy(mockTransactionRepo, config.TransferLimits{}), noCoinExpiry(), bus)
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByUsername", ctx, "bob").Return(&models.User{ID: 2, Username: "bob"}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1, Coins: 1000}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), int64(-100)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(2), int64(100)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.SendCoins(ctx, 1, "bob", 100)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, []events.Event{events.CoinsSent{FromUserID: 1, ToUserID: 2, Amount: 100}}, published)
+}
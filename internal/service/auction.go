@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// auctionBatchSize — сколько завершившихся аукционов закрывается за один запуск
+const auctionBatchSize = 20
+
+type auctionServiceImpl struct {
+	transactor       repository.Transactor
+	merchRepo        repository.MerchRepository
+	userMerchRepo    repository.UserMerchRepository
+	transactionRepo  repository.TransactionRepository
+	auctionRepo      repository.AuctionRepository
+	notificationRepo repository.NotificationRepository
+	escrow           EscrowService
+	holdGrace        time.Duration
+}
+
+func NewAuctionService(transactor repository.Transactor, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, transactionRepo repository.TransactionRepository, auctionRepo repository.AuctionRepository, notificationRepo repository.NotificationRepository, escrow EscrowService, holdGrace time.Duration) AuctionService {
+	return &auctionServiceImpl{
+		transactor:       transactor,
+		merchRepo:        merchRepo,
+		userMerchRepo:    userMerchRepo,
+		transactionRepo:  transactionRepo,
+		auctionRepo:      auctionRepo,
+		notificationRepo: notificationRepo,
+		escrow:           escrow,
+		holdGrace:        holdGrace,
+	}
+}
+
+// CreateAuction создает аукцион и резервирует разыгрываемые единицы товара на складе
+func (s *auctionServiceImpl) CreateAuction(ctx context.Context, adminID int64, input models.CreateAuctionRequest) (*models.Auction, error) {
+	startsAt := time.Now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	if !input.EndsAt.After(startsAt) || !input.EndsAt.After(time.Now()) {
+		return nil, errors.New("auction must end in the future and after it starts")
+	}
+	if input.Quantity <= 0 || input.MinBid <= 0 {
+		return nil, errors.New("quantity and minimum bid must be positive")
+	}
+
+	merch, err := s.merchRepo.GetByName(ctx, input.Item)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	auction := &models.Auction{
+		MerchID:   merch.ID,
+		Item:      merch.Name,
+		Quantity:  input.Quantity,
+		MinBid:    input.MinBid,
+		StartsAt:  startsAt,
+		EndsAt:    input.EndsAt,
+		CreatedBy: &adminID,
+	}
+
+	switch {
+	case input.Variant != "":
+		variant, err := s.merchRepo.GetVariantBySKU(ctx, input.Variant)
+		if err != nil {
+			return nil, err
+		}
+		if variant.MerchID != merch.ID {
+			return nil, fmt.Errorf("variant %s does not belong to %s", input.Variant, merch.Name)
+		}
+		auction.VariantID = &variant.ID
+		auction.Variant = &variant.SKU
+	case merch.HasVariants:
+		return nil, fmt.Errorf("%s comes in several variants, choose one by SKU", merch.Name)
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if auction.VariantID != nil {
+			err = s.merchRepo.DecrementVariantStock(ctx, *auction.VariantID, auction.Quantity)
+		} else {
+			err = s.merchRepo.DecrementStock(ctx, merch.ID, auction.Quantity)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot reserve %d of %s: %w", auction.Quantity, merch.Name, err)
+		}
+
+		return s.auctionRepo.Create(ctx, auction)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auction: %w", err)
+	}
+
+	auction.MinimumBid = auction.MinBid
+	return auction, nil
+}
+
+func (s *auctionServiceImpl) GetAuctions(ctx context.Context) ([]models.Auction, error) {
+	auctions, err := s.auctionRepo.GetOpen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range auctions {
+		auctions[i].MinimumBid = minimumBid(&auctions[i])
+	}
+
+	return auctions, nil
+}
+
+// GetAuction возвращает аукцион вместе со всеми ставками
+func (s *auctionServiceImpl) GetAuction(ctx context.Context, auctionID int64) (*models.Auction, error) {
+	auction, err := s.auctionRepo.GetByID(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	auction.Bids, err = s.auctionRepo.GetBids(ctx, auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bids: %w", err)
+	}
+	auction.MinimumBid = minimumBid(auction)
+
+	return auction, nil
+}
+
+// PlaceBid делает ставку или повышает ставку пользователя. Монеты ставки удерживаются
+// до закрытия аукциона. Если ставка вытесняет самую низкую из выигрывающих,
+// ее удержание сразу снимается.
+// Аукцион блокируется на время ставки, поэтому параллельные ставки выполняются по очереди
+func (s *auctionServiceImpl) PlaceBid(ctx context.Context, userID, auctionID, amount int64) (*models.AuctionBid, error) {
+	if amount <= 0 {
+		return nil, errors.New("bid must be positive")
+	}
+
+	var bid *models.AuctionBid
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		auction, err := s.auctionRepo.GetByIDForUpdate(ctx, auctionID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if auction.Status == models.AuctionClosed || !now.Before(auction.EndsAt) {
+			return errors.New("auction has ended")
+		}
+		if now.Before(auction.StartsAt) {
+			return errors.New("auction has not started yet")
+		}
+
+		bids, err := s.auctionRepo.GetBids(ctx, auction.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get bids: %w", err)
+		}
+
+		var active []models.AuctionBid
+		var own *models.AuctionBid
+		for i := range bids {
+			if bids[i].Status == models.BidActive {
+				active = append(active, bids[i])
+			}
+			if bids[i].UserID == userID {
+				own = &bids[i]
+			}
+		}
+
+		// Повышение своей выигрывающей ставки никого не вытесняет
+		raising := own != nil && own.Status == models.BidActive
+		if raising {
+			if amount <= own.Amount {
+				return fmt.Errorf("new bid must be higher than your current bid of %d", own.Amount)
+			}
+		} else if minimum := minimumBid(auction); amount < minimum {
+			return fmt.Errorf("bid must be at least %d", minimum)
+		}
+
+		// При повышении прежнее удержание заменяется удержанием на всю новую сумму
+		if raising {
+			if err := s.releaseHold(ctx, *own); err != nil {
+				return err
+			}
+		}
+
+		reason := fmt.Sprintf("Bid on auction #%d", auction.ID)
+		hold, err := s.escrow.Hold(ctx, userID, amount, reason, auction.EndsAt.Add(s.holdGrace))
+		if err != nil {
+			return err
+		}
+
+		bid = &models.AuctionBid{AuctionID: auction.ID, UserID: userID, Amount: amount, HoldID: &hold.ID}
+		if err := s.auctionRepo.SaveBid(ctx, bid); err != nil {
+			return fmt.Errorf("failed to save bid: %w", err)
+		}
+
+		// Новая ставка строго выше самой низкой выигрывающей, поэтому вытесняется последняя из них
+		if !raising && len(active) >= auction.Quantity {
+			return s.release(ctx, auction, active[len(active)-1])
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to place bid: %w", err)
+	}
+
+	return bid, nil
+}
+
+// CloseDue закрывает завершившиеся аукционы: выигравшие ставки превращаются в покупки,
+// а непроданные единицы возвращаются на склад. Выбранные аукционы остаются
+// заблокированными до конца транзакции, поэтому другие экземпляры приложения их не увидят
+func (s *auctionServiceImpl) CloseDue(ctx context.Context) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		auctions, err := s.auctionRepo.LockDue(ctx, auctionBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to lock due auctions: %w", err)
+		}
+
+		for i := range auctions {
+			auction := &auctions[i]
+
+			// Каждый аукцион закрывается во вложенной транзакции, чтобы ошибка
+			// в одном не мешала закрыть остальные
+			err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				return s.close(ctx, auction)
+			})
+			if err != nil {
+				log.Printf("Closing auction %d failed: %v", auction.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// close списывает удержания выигравших ставок и выдает товар победителям.
+// Ставка, удержание которой уже истекло, не выигрывает, и ее единица возвращается на склад
+func (s *auctionServiceImpl) close(ctx context.Context, auction *models.Auction) error {
+	bids, err := s.auctionRepo.GetBids(ctx, auction.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get bids: %w", err)
+	}
+
+	won := 0
+	for _, bid := range bids {
+		if bid.Status != models.BidActive {
+			continue
+		}
+
+		if bid.HoldID == nil {
+			return fmt.Errorf("bid %d has no coin hold", bid.ID)
+		}
+		if _, err := s.escrow.Capture(ctx, *bid.HoldID); err != nil {
+			log.Printf("Bid %d on auction %d is void: %v", bid.ID, auction.ID, err)
+			if err := s.auctionRepo.SetBidStatus(ctx, bid.ID, models.BidExpired); err != nil {
+				return err
+			}
+			continue
+		}
+
+		transaction := &models.Transaction{
+			FromUserID:  bid.UserID,
+			Amount:      bid.Amount,
+			Description: fmt.Sprintf("Won auction #%d", auction.ID),
+			Kind:        models.TransactionBid,
+		}
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
+
+		userMerch := &models.UserMerch{
+			UserID:    bid.UserID,
+			MerchID:   auction.MerchID,
+			VariantID: auction.VariantID,
+			PricePaid: bid.Amount,
+		}
+		if err := s.userMerchRepo.Create(ctx, userMerch); err != nil {
+			return fmt.Errorf("failed to record won item: %w", err)
+		}
+		if err := s.auctionRepo.SetBidStatus(ctx, bid.ID, models.BidWon); err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("You won %s at auction #%d for %d coins", auction.Item, auction.ID, bid.Amount)
+		if err := s.notify(ctx, bid.UserID, auction, models.NotificationAuctionWon, message); err != nil {
+			return err
+		}
+		won++
+	}
+
+	if unsold := auction.Quantity - won; unsold > 0 {
+		if auction.VariantID != nil {
+			err = s.merchRepo.RestockVariant(ctx, *auction.VariantID, unsold)
+		} else {
+			err = s.merchRepo.Restock(ctx, auction.MerchID, unsold)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restock unsold items: %w", err)
+		}
+	}
+
+	return s.auctionRepo.Close(ctx, auction.ID)
+}
+
+// release снимает удержание перебитой ставки
+func (s *auctionServiceImpl) release(ctx context.Context, auction *models.Auction, bid models.AuctionBid) error {
+	if err := s.auctionRepo.SetBidStatus(ctx, bid.ID, models.BidOutbid); err != nil {
+		return err
+	}
+
+	if err := s.releaseHold(ctx, bid); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your bid of %d on %s was outbid", bid.Amount, auction.Item)
+	return s.notify(ctx, bid.UserID, auction, models.NotificationOutbid, message)
+}
+
+func (s *auctionServiceImpl) releaseHold(ctx context.Context, bid models.AuctionBid) error {
+	if bid.HoldID == nil {
+		return fmt.Errorf("bid %d has no coin hold", bid.ID)
+	}
+
+	_, err := s.escrow.Release(ctx, *bid.HoldID)
+	return err
+}
+
+func (s *auctionServiceImpl) notify(ctx context.Context, userID int64, auction *models.Auction, kind, message string) error {
+	notification := &models.Notification{
+		UserID:  userID,
+		Kind:    kind,
+		MerchID: &auction.MerchID,
+		Message: message,
+	}
+
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+// minimumBid возвращает сумму, с которой новая ставка попадает в число выигрывающих
+func minimumBid(auction *models.Auction) int64 {
+	if auction.ActiveBids < auction.Quantity || auction.LowestBid == nil {
+		return auction.MinBid
+	}
+	return *auction.LowestBid + 1
+}
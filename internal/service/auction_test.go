@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func holdID(id int64) *int64 {
+	return &id
+}
+
+// runningAuction возвращает идущий аукцион на две единицы, в котором уже есть две ставки
+func runningAuction() (*models.Auction, []models.AuctionBid) {
+	lowest := int64(120)
+	auction := &models.Auction{
+		ID: 1, MerchID: 6, Item: "hoody", Quantity: 2, MinBid: 100, ActiveBids: 2, LowestBid: &lowest,
+		StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour), Status: models.AuctionOpen,
+	}
+	bids := []models.AuctionBid{
+		{ID: 11, AuctionID: 1, UserID: 2, Amount: 150, Status: models.BidActive, HoldID: holdID(21)},
+		{ID: 12, AuctionID: 1, UserID: 3, Amount: 120, Status: models.BidActive, HoldID: holdID(22)},
+		{ID: 13, AuctionID: 1, UserID: 4, Amount: 100, Status: models.BidOutbid, HoldID: holdID(23)},
+	}
+	return auction, bids
+}
+
+func TestAuctionService_PlaceBid_Outbids(t *testing.T) {
+	mockHoldRepo := new(MockHoldRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockAuctionRepo := new(MockAuctionRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	transactor := new(MockTransactor)
+	service := NewAuctionService(transactor, new(MockMerchRepository), new(MockUserMerchRepository), mockTransactionRepo, mockAuctionRepo, mockNotificationRepo, NewEscrowService(transactor, mockHoldRepo), time.Hour)
+
+	ctx := context.Background()
+	auction, bids := runningAuction()
+
+	// Настраиваем моки
+	mockAuctionRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(auction, nil)
+	mockAuctionRepo.On("GetBids", ctx, int64(1)).Return(bids, nil)
+	// Монеты ставки удерживаются до закрытия аукциона, а не списываются
+	mockHoldRepo.On("Create", ctx, mock.MatchedBy(func(hold *models.CoinHold) bool {
+		return hold.UserID == 5 && hold.Amount == 121 && hold.ExpiresAt.Equal(auction.EndsAt.Add(time.Hour))
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.CoinHold).ID = 25
+	}).Return(nil)
+	mockAuctionRepo.On("SaveBid", ctx, mock.MatchedBy(func(bid *models.AuctionBid) bool {
+		return bid.UserID == 5 && bid.Amount == 121 && *bid.HoldID == 25
+	})).Return(nil)
+	// Самая низкая выигрывающая ставка перебита, и ее удержание сразу снимается
+	mockAuctionRepo.On("SetBidStatus", ctx, int64(12), models.BidOutbid).Return(nil)
+	mockHoldRepo.On("Resolve", ctx, int64(22), models.HoldReleased).Return(&models.CoinHold{ID: 22, UserID: 3, Amount: 120}, nil)
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 3 && notification.Kind == models.NotificationOutbid
+	})).Return(nil)
+
+	// Вызываем тестируемый метод: ставка не выше самой низкой выигрывающей не принимается
+	_, err := service.PlaceBid(ctx, 5, 1, 120)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "at least 121")
+
+	bid, err := service.PlaceBid(ctx, 5, 1, 121)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(121), bid.Amount)
+	mockHoldRepo.AssertExpectations(t)
+	mockAuctionRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuctionService_PlaceBid_Raise(t *testing.T) {
+	mockHoldRepo := new(MockHoldRepository)
+	mockAuctionRepo := new(MockAuctionRepository)
+
+	transactor := new(MockTransactor)
+	service := NewAuctionService(transactor, new(MockMerchRepository), new(MockUserMerchRepository), new(MockTransactionRepository), mockAuctionRepo, new(MockNotificationRepository), NewEscrowService(transactor, mockHoldRepo), time.Hour)
+
+	ctx := context.Background()
+	auction, bids := runningAuction()
+
+	// Настраиваем моки
+	mockAuctionRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(auction, nil)
+	mockAuctionRepo.On("GetBids", ctx, int64(1)).Return(bids, nil)
+	// Прежнее удержание заменяется удержанием на всю новую сумму
+	mockHoldRepo.On("Resolve", ctx, int64(22), models.HoldReleased).Return(&models.CoinHold{ID: 22, UserID: 3, Amount: 120}, nil)
+	mockHoldRepo.On("Create", ctx, mock.MatchedBy(func(hold *models.CoinHold) bool {
+		return hold.UserID == 3 && hold.Amount == 150
+	})).Return(nil)
+	mockAuctionRepo.On("SaveBid", ctx, mock.AnythingOfType("*models.AuctionBid")).Return(nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.PlaceBid(ctx, 3, 1, 110)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "higher than your current bid")
+
+	_, err = service.PlaceBid(ctx, 3, 1, 150)
+
+	assert.NoError(t, err)
+	mockHoldRepo.AssertExpectations(t)
+	mockAuctionRepo.AssertNotCalled(t, "SetBidStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuctionService_PlaceBid_Ended(t *testing.T) {
+	mockAuctionRepo := new(MockAuctionRepository)
+
+	transactor := new(MockTransactor)
+	service := NewAuctionService(transactor, new(MockMerchRepository), new(MockUserMerchRepository), new(MockTransactionRepository), mockAuctionRepo, new(MockNotificationRepository), NewEscrowService(transactor, new(MockHoldRepository)), time.Hour)
+
+	ctx := context.Background()
+	auction, _ := runningAuction()
+	auction.EndsAt = time.Now().Add(-time.Minute)
+
+	// Настраиваем моки
+	mockAuctionRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(auction, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.PlaceBid(ctx, 5, 1, 500)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "auction has ended")
+}
+
+func TestAuctionService_CloseDue(t *testing.T) {
+	mockHoldRepo := new(MockHoldRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockAuctionRepo := new(MockAuctionRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	transactor := new(MockTransactor)
+	service := NewAuctionService(transactor, mockMerchRepo, mockUserMerchRepo, mockTransactionRepo, mockAuctionRepo, mockNotificationRepo, NewEscrowService(transactor, mockHoldRepo), time.Hour)
+
+	ctx := context.Background()
+
+	// Настраиваем моки: из трех единиц продана одна, у второй выигравшей ставки удержание уже истекло,
+	// поэтому две единицы возвращаются на склад
+	mockAuctionRepo.On("LockDue", ctx, auctionBatchSize).Return([]models.Auction{
+		{ID: 1, MerchID: 6, Item: "hoody", Quantity: 3, MinBid: 100, Status: models.AuctionOpen},
+	}, nil)
+	mockAuctionRepo.On("GetBids", ctx, int64(1)).Return([]models.AuctionBid{
+		{ID: 11, AuctionID: 1, UserID: 2, Amount: 150, Status: models.BidActive, HoldID: holdID(21)},
+		{ID: 12, AuctionID: 1, UserID: 3, Amount: 120, Status: models.BidActive, HoldID: holdID(22)},
+		{ID: 13, AuctionID: 1, UserID: 4, Amount: 100, Status: models.BidOutbid, HoldID: holdID(23)},
+	}, nil)
+	mockHoldRepo.On("Capture", ctx, int64(21)).Return(&models.CoinHold{ID: 21, UserID: 2, Amount: 150}, nil)
+	mockHoldRepo.On("Capture", ctx, int64(22)).Return(nil, errors.New("hold is not active"))
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 2 && transaction.Amount == 150 && transaction.Kind == models.TransactionBid
+	})).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.MatchedBy(func(userMerch *models.UserMerch) bool {
+		return userMerch.UserID == 2 && userMerch.MerchID == 6 && userMerch.PricePaid == 150
+	})).Return(nil)
+	mockAuctionRepo.On("SetBidStatus", ctx, int64(11), models.BidWon).Return(nil)
+	mockAuctionRepo.On("SetBidStatus", ctx, int64(12), models.BidExpired).Return(nil)
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 2 && notification.Kind == models.NotificationAuctionWon
+	})).Return(nil)
+	mockMerchRepo.On("Restock", ctx, int64(6), 2).Return(nil)
+	mockAuctionRepo.On("Close", ctx, int64(1)).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.CloseDue(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockHoldRepo.AssertExpectations(t)
+	mockMerchRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockAuctionRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/haqer0002/avito-shop/internal/events"
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/haqer0002/avito-shop/internal/repository"
 )
@@ -17,6 +19,9 @@ const (
 	salt       = "hjqrhjqw124617ajfhajs"
 	signingKey = "qrkjk#4#%35FSFJlja#4353KSFjH"
 	tokenTTL   = 12 * time.Hour
+
+	// systemUsername — имя системного аккаунта площадки, зарезервированное при регистрации
+	systemUsername = "system"
 )
 
 type tokenClaims struct {
@@ -25,11 +30,12 @@ type tokenClaims struct {
 }
 
 type authServiceImpl struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	publisher events.Publisher
 }
 
-func NewAuthService(repo repository.UserRepository) AuthService {
-	return &authServiceImpl{repo: repo}
+func NewAuthService(repo repository.UserRepository, publisher events.Publisher) AuthService {
+	return &authServiceImpl{repo: repo, publisher: publisher}
 }
 
 func (s *authServiceImpl) CreateUser(ctx context.Context, username, password string) error {
@@ -49,6 +55,12 @@ func (s *authServiceImpl) CreateUser(ctx context.Context, username, password str
 		return errors.New("password must be at least 6 characters long")
 	}
 
+	// Имена системного аккаунта и кошельков команд зарезервированы
+	if username == systemUsername || strings.HasPrefix(username, teamWalletPrefix) {
+		log.Printf("Validation error: username %s is reserved", username)
+		return errors.New("username is reserved")
+	}
+
 	hashedPassword := s.generatePasswordHash(password)
 	log.Printf("Generated password hash for user %s: %s", username, hashedPassword)
 
@@ -65,6 +77,7 @@ func (s *authServiceImpl) CreateUser(ctx context.Context, username, password str
 	}
 
 	log.Printf("Successfully created user %s with ID: %d", username, user.ID)
+	s.publisher.Publish(ctx, events.UserCreated{UserID: user.ID, Username: user.Username})
 	return nil
 }
 
@@ -144,6 +157,14 @@ func (s *authServiceImpl) GetUserByUsername(ctx context.Context, username string
 	return user, nil
 }
 
+func (s *authServiceImpl) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin, nil
+}
+
 func (s *authServiceImpl) GetRepo() repository.UserRepository {
 	return s.repo
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/haqer0002/avito-shop/internal/events"
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -11,7 +12,7 @@ import (
 
 func TestAuthService_CreateUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewAuthService(mockRepo)
+	service := NewAuthService(mockRepo, events.NewBus())
 
 	ctx := context.Background()
 	username := "testuser"
@@ -28,9 +29,25 @@ func TestAuthService_CreateUser(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestAuthService_CreateUser_ReservedName(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewAuthService(mockRepo, events.NewBus())
+
+	ctx := context.Background()
+
+	// Вызываем тестируемый метод
+	err := service.CreateUser(ctx, "system", "testpass")
+	teamErr := service.CreateUser(ctx, "team:platform", "testpass")
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "reserved")
+	assert.ErrorContains(t, teamErr, "reserved")
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestAuthService_GenerateToken(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo)
+	authService := NewAuthService(mockRepo, events.NewBus())
 	service := authService.(*authServiceImpl)
 
 	ctx := context.Background()
@@ -58,7 +75,7 @@ func TestAuthService_GenerateToken(t *testing.T) {
 
 func TestAuthService_ParseToken(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo)
+	authService := NewAuthService(mockRepo, events.NewBus())
 	service := authService.(*authServiceImpl)
 
 	ctx := context.Background()
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type cartServiceImpl struct {
+	transactor repository.Transactor
+	userRepo   repository.UserRepository
+	merchRepo  repository.MerchRepository
+	cartRepo   repository.CartRepository
+	purchaser  *purchaser
+	publisher  events.Publisher
+}
+
+func NewCartService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, cartRepo repository.CartRepository, pricing PricingEngine, publisher events.Publisher) CartService {
+	return &cartServiceImpl{
+		transactor: transactor,
+		userRepo:   userRepo,
+		merchRepo:  merchRepo,
+		cartRepo:   cartRepo,
+		publisher:  publisher,
+		purchaser: &purchaser{
+			userRepo:      userRepo,
+			merchRepo:     merchRepo,
+			userMerchRepo: userMerchRepo,
+			orderRepo:     orderRepo,
+			pricing:       pricing,
+		},
+	}
+}
+
+func (s *cartServiceImpl) GetCart(ctx context.Context, userID int64) (*models.Cart, error) {
+	items, err := s.cartRepo.GetItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	cart := &models.Cart{Items: items}
+	for i := range cart.Items {
+		cart.Items[i].Subtotal = cart.Items[i].Price * int64(cart.Items[i].Quantity)
+		cart.Total += cart.Items[i].Subtotal
+	}
+
+	return cart, nil
+}
+
+func (s *cartServiceImpl) AddItem(ctx context.Context, userID int64, merchName, variantSKU string, quantity int) error {
+	line, err := s.cartLine(ctx, merchName, variantSKU)
+	if err != nil {
+		return err
+	}
+
+	return s.cartRepo.AddItem(ctx, userID, line.merch.ID, variantID(line.variant), quantity)
+}
+
+func (s *cartServiceImpl) SetQuantity(ctx context.Context, userID int64, merchName, variantSKU string, quantity int) error {
+	line, err := s.cartLine(ctx, merchName, variantSKU)
+	if err != nil {
+		return err
+	}
+
+	return s.cartRepo.SetQuantity(ctx, userID, line.merch.ID, variantID(line.variant), quantity)
+}
+
+func (s *cartServiceImpl) RemoveItem(ctx context.Context, userID int64, merchName, variantSKU string) error {
+	line, err := s.cartLine(ctx, merchName, variantSKU)
+	if err != nil {
+		return err
+	}
+
+	return s.cartRepo.RemoveItem(ctx, userID, line.merch.ID, variantID(line.variant))
+}
+
+// cartLine находит товар и вариант позиции корзины. Товар с вариантами кладется
+// в корзину только с артикулом варианта
+func (s *cartServiceImpl) cartLine(ctx context.Context, merchName, sku string) (purchaseLine, error) {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return purchaseLine{}, fmt.Errorf("merch not found: %w", err)
+	}
+
+	line := purchaseLine{merch: merch}
+	switch {
+	case sku != "":
+		line.variant, err = s.merchRepo.GetVariantBySKU(ctx, sku)
+		if err != nil {
+			return purchaseLine{}, err
+		}
+		if line.variant.MerchID != merch.ID {
+			return purchaseLine{}, fmt.Errorf("variant %s does not belong to %s", sku, merch.Name)
+		}
+	case merch.HasVariants:
+		return purchaseLine{}, fmt.Errorf("%s comes in several variants, choose one by SKU", merch.Name)
+	}
+
+	return line, nil
+}
+
+// Checkout оформляет всю корзину одним заказом по текущим ценам и очищает ее
+func (s *cartServiceImpl) Checkout(ctx context.Context, userID int64, promoCode string) (*models.Order, error) {
+	var order *models.Order
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Блокируем пользователя, чтобы параллельные оформления одной корзины выполнялись по очереди
+		if _, err := s.userRepo.GetByIDForUpdate(ctx, userID); err != nil {
+			return err
+		}
+
+		cartItems, err := s.cartRepo.GetItems(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get cart: %w", err)
+		}
+		if len(cartItems) == 0 {
+			return errors.New("cart is empty")
+		}
+
+		lines := make([]purchaseLine, 0, len(cartItems))
+		for _, item := range cartItems {
+			merch, err := s.merchRepo.GetByID(ctx, item.MerchID)
+			if err != nil {
+				return err
+			}
+
+			line := purchaseLine{merch: merch, quantity: item.Quantity}
+			if item.VariantSKU != nil {
+				line.variant, err = s.merchRepo.GetVariantBySKU(ctx, *item.VariantSKU)
+				if err != nil {
+					return err
+				}
+			}
+			lines = append(lines, line)
+		}
+
+		order, err = s.purchaser.placeOrder(ctx, userID, lines, promoCode, nil)
+		if err != nil {
+			return err
+		}
+
+		return s.cartRepo.Clear(ctx, userID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checkout failed: %w", err)
+	}
+
+	s.publisher.Publish(ctx, events.MerchBought{UserID: userID, OrderID: order.ID, Total: order.Total})
+	return order, nil
+}
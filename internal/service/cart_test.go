@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCartService_GetCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), new(MockUserMerchRepository), new(MockOrderRepository), mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockCartRepo.On("GetItems", ctx, int64(1)).Return([]models.CartItem{
+		{MerchID: 1, Name: "socks", Price: 10, Quantity: 5},
+		{MerchID: 2, Name: "cup", Price: 20, Quantity: 1},
+	}, nil)
+
+	// Вызываем тестируемый метод
+	cart, err := service.GetCart(ctx, 1)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), cart.Items[0].Subtotal)
+	assert.Equal(t, int64(70), cart.Total)
+}
+
+func TestCartService_Checkout(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByIDForUpdate", ctx, userID).Return(&models.User{ID: userID, Coins: 1000}, nil)
+	mockCartRepo.On("GetItems", ctx, userID).Return([]models.CartItem{
+		{MerchID: 1, Name: "socks", Price: 10, Quantity: 5},
+		{MerchID: 2, Name: "cup", Price: 20, Quantity: 1},
+	}, nil)
+	mockMerchRepo.On("GetByID", ctx, int64(1)).Return(&models.MerchItem{ID: 1, Name: "socks", Price: 10}, nil)
+	mockMerchRepo.On("GetByID", ctx, int64(2)).Return(&models.MerchItem{ID: 2, Name: "cup", Price: 20}, nil)
+	mockMerchRepo.On("DecrementStock", ctx, int64(1), 5).Return(nil)
+	mockMerchRepo.On("DecrementStock", ctx, int64(2), 1).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, userID, int64(-70)).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.MatchedBy(func(order *models.Order) bool {
+		return order.Total == 70 && len(order.Items) == 2
+	})).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.AnythingOfType("*models.UserMerch")).Return(nil).Times(6)
+	mockCartRepo.On("Clear", ctx, userID).Return(nil)
+
+	// Вызываем тестируемый метод
+	order, err := service.Checkout(ctx, userID, "")
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(70), order.Total)
+	mockUserRepo.AssertExpectations(t)
+	mockMerchRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockCartRepo.AssertExpectations(t)
+}
+
+func TestCartService_Checkout_InsufficientFunds(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), mockUserRepo, mockMerchRepo, new(MockUserMerchRepository), mockOrderRepo, mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+	userID := int64(1)
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByIDForUpdate", ctx, userID).Return(&models.User{ID: userID, Coins: 30}, nil)
+	mockCartRepo.On("GetItems", ctx, userID).Return([]models.CartItem{
+		{MerchID: 1, Name: "socks", Price: 10, Quantity: 5},
+	}, nil)
+	mockMerchRepo.On("GetByID", ctx, int64(1)).Return(&models.MerchItem{ID: 1, Name: "socks", Price: 10}, nil)
+	mockMerchRepo.On("DecrementStock", ctx, int64(1), 5).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, userID, int64(-50)).Return(errors.New("insufficient funds"))
+
+	// Вызываем тестируемый метод
+	_, err := service.Checkout(ctx, userID, "")
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to deduct coins")
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "Clear", mock.Anything, mock.Anything)
+}
+
+func TestCartService_Checkout_EmptyCart(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), mockUserRepo, new(MockMerchRepository), new(MockUserMerchRepository), new(MockOrderRepository), mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1}, nil)
+	mockCartRepo.On("GetItems", ctx, int64(1)).Return([]models.CartItem{}, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.Checkout(ctx, 1, "")
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cart is empty")
+}
+
+func TestCartService_Checkout_LimitAppliesToWholeOrder(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, new(MockOrderRepository), mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+	userID := int64(1)
+	limit := 1
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByIDForUpdate", ctx, userID).Return(&models.User{ID: userID}, nil)
+	mockCartRepo.On("GetItems", ctx, userID).Return([]models.CartItem{
+		{MerchID: 1, Name: "pink-hoody", Price: 500, Quantity: 2},
+	}, nil)
+	mockMerchRepo.On("GetByID", ctx, int64(1)).Return(&models.MerchItem{ID: 1, Name: "pink-hoody", Price: 500, PurchaseLimit: &limit}, nil)
+	mockUserMerchRepo.On("CountPurchased", ctx, userID, int64(1), mock.AnythingOfType("time.Time")).Return(0, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.Checkout(ctx, userID, "")
+
+	// Проверяем результаты
+	var limitErr *LimitExceededError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 1, limitErr.Remaining)
+	mockCartRepo.AssertNotCalled(t, "Clear", mock.Anything, mock.Anything)
+}
+
+func TestCartService_Checkout_Variant(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+	userID := int64(1)
+	sku := "hoody-m"
+	variantPrice := int64(350)
+	variant := &models.MerchVariant{ID: 7, MerchID: 1, SKU: sku, Price: &variantPrice}
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByIDForUpdate", ctx, userID).Return(&models.User{ID: userID, Coins: 1000}, nil)
+	mockCartRepo.On("GetItems", ctx, userID).Return([]models.CartItem{
+		{MerchID: 1, Name: "hoody", VariantID: &variant.ID, VariantSKU: &sku, Price: variantPrice, Quantity: 1},
+	}, nil)
+	mockMerchRepo.On("GetByID", ctx, int64(1)).Return(&models.MerchItem{ID: 1, Name: "hoody", Price: 300, HasVariants: true}, nil)
+	mockMerchRepo.On("GetVariantBySKU", ctx, sku).Return(variant, nil)
+	mockMerchRepo.On("DecrementVariantStock", ctx, variant.ID, 1).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, userID, int64(-350)).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.MatchedBy(func(order *models.Order) bool {
+		return order.Total == 350 && order.Items[0].VariantID != nil && *order.Items[0].VariantID == variant.ID
+	})).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.AnythingOfType("*models.UserMerch")).Return(nil)
+	mockCartRepo.On("Clear", ctx, userID).Return(nil)
+
+	// Вызываем тестируемый метод
+	order, err := service.Checkout(ctx, userID, "")
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(350), order.Total)
+	mockMerchRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+	mockCartRepo.AssertExpectations(t)
+}
+
+func TestCartService_AddItem_RequiresVariant(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+	mockCartRepo := new(MockCartRepository)
+
+	service := NewCartService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), mockCartRepo, newTestPricing(), events.NewBus())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockMerchRepo.On("GetByName", ctx, "hoody").Return(&models.MerchItem{ID: 1, Name: "hoody", HasVariants: true}, nil)
+
+	// Вызываем тестируемый метод
+	err := service.AddItem(ctx, 1, "hoody", "", 1)
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "choose one by SKU")
+	mockCartRepo.AssertNotCalled(t, "AddItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
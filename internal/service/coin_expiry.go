@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// expiryBatchSize — сколько пользователей или партий обрабатывается за один запуск
+const expiryBatchSize = 100
+
+type coinExpiryServiceImpl struct {
+	transactor       repository.Transactor
+	userRepo         repository.UserRepository
+	lotRepo          repository.CoinLotRepository
+	transactionRepo  repository.TransactionRepository
+	notificationRepo repository.NotificationRepository
+	settings         config.CoinExpirySettings
+}
+
+func NewCoinExpiryService(transactor repository.Transactor, userRepo repository.UserRepository, lotRepo repository.CoinLotRepository, transactionRepo repository.TransactionRepository, notificationRepo repository.NotificationRepository, settings config.CoinExpirySettings) CoinExpiryService {
+	return &coinExpiryServiceImpl{
+		transactor:       transactor,
+		userRepo:         userRepo,
+		lotRepo:          lotRepo,
+		transactionRepo:  transactionRepo,
+		notificationRepo: notificationRepo,
+		settings:         settings,
+	}
+}
+
+// GetExpiringSoon возвращает монеты баланса, которые сгорят в пределах периода предупреждения,
+// сгруппированные по дате сгорания
+func (s *coinExpiryServiceImpl) GetExpiringSoon(ctx context.Context, userID int64) ([]models.ExpiringCoins, error) {
+	expiring := make([]models.ExpiringCoins, 0)
+	if s.settings.Months == 0 {
+		return expiring, nil
+	}
+
+	lots, err := s.lotRepo.GetActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin lots: %w", err)
+	}
+
+	horizon := time.Now().Add(s.settings.WarnBefore)
+	for _, lot := range lots {
+		expiresAt := s.lotExpiry(lot.GrantedAt)
+		if expiresAt.After(horizon) {
+			// Партии отсортированы по дате начисления, остальные сгорят еще позже
+			break
+		}
+
+		if n := len(expiring); n > 0 && expiring[n-1].ExpiresAt.Equal(expiresAt) {
+			expiring[n-1].Amount += lot.Remaining
+			continue
+		}
+		expiring = append(expiring, models.ExpiringCoins{Amount: lot.Remaining, ExpiresAt: expiresAt})
+	}
+
+	return expiring, nil
+}
+
+// ExpireDue списывает монеты из сгоревших партий. Монеты, удержанные под незавершенные сделки,
+// не сгорают, пока удержание не снято
+func (s *coinExpiryServiceImpl) ExpireDue(ctx context.Context) error {
+	if s.settings.Months == 0 {
+		return nil
+	}
+
+	cutoff := s.expiredBefore(time.Now())
+	userIDs, err := s.lotRepo.GetUsersWithExpired(ctx, cutoff, expiryBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to find expired coins: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		// Монеты каждого пользователя списываются в отдельной транзакции, чтобы ошибка
+		// у одного не мешала обработать остальных
+		err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			return s.expire(ctx, userID, cutoff)
+		})
+		if err != nil {
+			log.Printf("Expiring coins of user %d failed: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// expire списывает остаток партий пользователя, начисленных раньше cutoff. Партии расходуются
+// с самых старых, поэтому списание приходится именно на сгоревшие партии
+func (s *coinExpiryServiceImpl) expire(ctx context.Context, userID int64, cutoff time.Time) error {
+	user, err := s.userRepo.GetByIDForUpdate(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Остаток перечитывается под блокировкой пользователя: монеты могли потратить
+	// или их уже списал другой экземпляр приложения
+	expired, err := s.lotRepo.SumRemaining(ctx, userID, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to sum expired coins: %w", err)
+	}
+
+	amount := min(expired, user.AvailableCoins())
+	if amount <= 0 {
+		return nil
+	}
+
+	if err := s.userRepo.UpdateCoins(ctx, userID, -amount); err != nil {
+		return fmt.Errorf("failed to expire coins: %w", err)
+	}
+
+	transaction := &models.Transaction{
+		FromUserID:  userID,
+		Amount:      amount,
+		Description: fmt.Sprintf("Coins granted before %s expired", cutoff.Format("2006-01-02")),
+		Kind:        models.TransactionExpiry,
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return fmt.Errorf("failed to create transaction record: %w", err)
+	}
+
+	return nil
+}
+
+// WarnDue предупреждает пользователей о монетах, которые сгорят в пределах периода предупреждения.
+// О каждой партии предупреждение отправляется один раз
+func (s *coinExpiryServiceImpl) WarnDue(ctx context.Context) error {
+	if s.settings.Months == 0 {
+		return nil
+	}
+
+	cutoff := s.expiredBefore(time.Now().Add(s.settings.WarnBefore))
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		lots, err := s.lotRepo.MarkWarned(ctx, cutoff, expiryBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to mark expiring coin lots: %w", err)
+		}
+
+		type warning struct {
+			userID    int64
+			expiresAt time.Time
+		}
+
+		var order []warning
+		amounts := make(map[warning]int64)
+		for _, lot := range lots {
+			key := warning{userID: lot.UserID, expiresAt: s.lotExpiry(lot.GrantedAt)}
+			if _, ok := amounts[key]; !ok {
+				order = append(order, key)
+			}
+			amounts[key] += lot.Remaining
+		}
+
+		for _, key := range order {
+			notification := &models.Notification{
+				UserID:  key.userID,
+				Kind:    models.NotificationExpiring,
+				Message: fmt.Sprintf("%d of your coins expire on %s, spend them before then", amounts[key], key.expiresAt.Format("2006-01-02")),
+			}
+			if err := s.notificationRepo.Create(ctx, notification); err != nil {
+				return fmt.Errorf("failed to create notification: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// lotExpiry возвращает момент сгорания партии: через Months месяцев после окончания
+// финансового года, в котором она начислена
+func (s *coinExpiryServiceImpl) lotExpiry(grantedAt time.Time) time.Time {
+	return fiscalYearStart(grantedAt, s.settings.FiscalYearStart).AddDate(1, s.settings.Months, 0)
+}
+
+// expiredBefore возвращает границу, партии начисленные раньше которой к моменту t уже сгорели
+func (s *coinExpiryServiceImpl) expiredBefore(t time.Time) time.Time {
+	t = t.UTC()
+	shifted := time.Date(t.Year(), t.Month()-time.Month(s.settings.Months), 1, 0, 0, 0, 0, time.UTC)
+	return fiscalYearStart(shifted, s.settings.FiscalYearStart)
+}
+
+// fiscalYearStart возвращает начало финансового года, в который попадает t
+func fiscalYearStart(t time.Time, startMonth time.Month) time.Time {
+	t = t.UTC()
+	year := t.Year()
+	if t.Month() < startMonth {
+		year--
+	}
+	return time.Date(year, startMonth, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// noCoinExpiry возвращает сервис сгорания монет с отключенной политикой
+func noCoinExpiry() CoinExpiryService {
+	return NewCoinExpiryService(new(MockTransactor), new(MockUserRepository), new(MockCoinLotRepository), new(MockTransactionRepository), new(MockNotificationRepository), config.CoinExpirySettings{})
+}
+
+// testExpirySettings — монеты сгорают через три месяца после окончания финансового года, который начинается 1 апреля
+var testExpirySettings = config.CoinExpirySettings{Months: 3, FiscalYearStart: time.April, WarnBefore: 30 * 24 * time.Hour}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCoinExpiry_Dates(t *testing.T) {
+	service := NewCoinExpiryService(new(MockTransactor), nil, nil, nil, nil, testExpirySettings).(*coinExpiryServiceImpl)
+
+	// Финансовый год начинается 1 апреля, монеты сгорают через три месяца после его окончания
+	assert.Equal(t, date(2025, time.July, 1), service.lotExpiry(date(2025, time.March, 31)))
+	assert.Equal(t, date(2026, time.July, 1), service.lotExpiry(date(2025, time.April, 1)))
+	assert.Equal(t, date(2026, time.July, 1), service.lotExpiry(date(2026, time.February, 10)))
+
+	// 30 июня 2026 года сгоревшими считаются монеты, начисленные до 1 апреля 2025 года,
+	// а с 1 июля к ним добавляются начисленные до 1 апреля 2026 года
+	assert.Equal(t, date(2025, time.April, 1), service.expiredBefore(date(2026, time.June, 30)))
+	assert.Equal(t, date(2026, time.April, 1), service.expiredBefore(date(2026, time.July, 1)))
+}
+
+func TestCoinExpiryService_GetExpiringSoon(t *testing.T) {
+	mockLotRepo := new(MockCoinLotRepository)
+	service := NewCoinExpiryService(new(MockTransactor), nil, mockLotRepo, nil, nil, testExpirySettings).(*coinExpiryServiceImpl)
+
+	ctx := context.Background()
+	yearAgo := time.Now().AddDate(-1, 0, 0)
+	lastYearExpiry := service.lotExpiry(yearAgo)
+	// В период предупреждения попадает сгорание партий прошлого финансового года, но не текущего
+	service.settings.WarnBefore = time.Until(lastYearExpiry) + 30*24*time.Hour
+
+	// Настраиваем моки
+	mockLotRepo.On("GetActive", ctx, int64(1)).Return([]models.CoinLot{
+		{ID: 1, UserID: 1, Remaining: 200, GrantedAt: fiscalYearStart(yearAgo, time.April)},
+		{ID: 2, UserID: 1, Remaining: 50, GrantedAt: yearAgo},
+		{ID: 3, UserID: 1, Remaining: 400, GrantedAt: time.Now()},
+	}, nil)
+
+	// Вызываем тестируемый метод
+	expiring, err := service.GetExpiringSoon(ctx, 1)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ExpiringCoins{{Amount: 250, ExpiresAt: lastYearExpiry}}, expiring)
+}
+
+func TestCoinExpiryService_ExpireDue(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockLotRepo := new(MockCoinLotRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	service := NewCoinExpiryService(new(MockTransactor), mockUserRepo, mockLotRepo, mockTransactionRepo, nil, testExpirySettings).(*coinExpiryServiceImpl)
+
+	ctx := context.Background()
+	cutoff := service.expiredBefore(time.Now())
+
+	// Настраиваем моки
+	mockLotRepo.On("GetUsersWithExpired", ctx, cutoff, expiryBatchSize).Return([]int64{1, 2}, nil)
+
+	// У первого пользователя сгорают 300 монет
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1, Coins: 1000}, nil)
+	mockLotRepo.On("SumRemaining", ctx, int64(1), cutoff).Return(int64(300), nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), int64(-300)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 1 && transaction.Amount == 300 && transaction.Kind == models.TransactionExpiry
+	})).Return(nil)
+
+	// У второго часть сгоревших монет удержана под ставку и пока не сгорает
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(&models.User{ID: 2, Coins: 500, HeldCoins: 400}, nil)
+	mockLotRepo.On("SumRemaining", ctx, int64(2), cutoff).Return(int64(300), nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(2), int64(-100)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 2 && transaction.Amount == 100
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.ExpireDue(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockLotRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestCoinExpiryService_WarnDue(t *testing.T) {
+	mockLotRepo := new(MockCoinLotRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+	service := NewCoinExpiryService(new(MockTransactor), nil, mockLotRepo, nil, mockNotificationRepo, testExpirySettings).(*coinExpiryServiceImpl)
+
+	ctx := context.Background()
+	cutoff := service.expiredBefore(time.Now().Add(service.settings.WarnBefore))
+
+	// Настраиваем моки: партии одного пользователя с одной датой сгорания объединяются в одно предупреждение
+	mockLotRepo.On("MarkWarned", ctx, cutoff, expiryBatchSize).Return([]models.CoinLot{
+		{ID: 1, UserID: 1, Remaining: 200, GrantedAt: date(2025, time.May, 1)},
+		{ID: 2, UserID: 1, Remaining: 50, GrantedAt: date(2025, time.June, 1)},
+		{ID: 3, UserID: 2, Remaining: 70, GrantedAt: date(2025, time.June, 1)},
+	}, nil)
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 1 && notification.Kind == models.NotificationExpiring &&
+			notification.Message == "250 of your coins expire on 2026-07-01, spend them before then"
+	})).Return(nil).Once()
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+		return notification.UserID == 2 && notification.Message == "70 of your coins expire on 2026-07-01, spend them before then"
+	})).Return(nil).Once()
+
+	// Вызываем тестируемый метод
+	err := service.WarnDue(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockLotRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+func TestCoinExpiryService_Disabled(t *testing.T) {
+	service := noCoinExpiry()
+
+	// Вызываем тестируемый метод
+	expiring, err := service.GetExpiringSoon(context.Background(), 1)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Empty(t, expiring)
+	assert.NoError(t, service.ExpireDue(context.Background()))
+	assert.NoError(t, service.WarnDue(context.Background()))
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type disputeServiceImpl struct {
+	transactor      repository.Transactor
+	userRepo        repository.UserRepository
+	transactionRepo repository.TransactionRepository
+	disputeRepo     repository.DisputeRepository
+}
+
+func NewDisputeService(transactor repository.Transactor, userRepo repository.UserRepository, transactionRepo repository.TransactionRepository, disputeRepo repository.DisputeRepository) DisputeService {
+	return &disputeServiceImpl{
+		transactor:      transactor,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		disputeRepo:     disputeRepo,
+	}
+}
+
+func (s *disputeServiceImpl) OpenDispute(ctx context.Context, userID, transactionID int64, reason string) (*models.Dispute, error) {
+	var dispute *models.Dispute
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		transaction, err := s.transactionRepo.GetByIDForUpdate(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		// Оспорить можно только собственный исходящий перевод
+		if transaction.FromUserID != userID || transaction.Kind != models.TransactionTransfer {
+			return errors.New("only transfers you sent can be disputed")
+		}
+		if transaction.Reversed {
+			return errors.New("transaction is already reversed")
+		}
+
+		dispute = &models.Dispute{
+			TransactionID: transaction.ID,
+			OpenedBy:      userID,
+			Amount:        transaction.Amount,
+			Reason:        reason,
+		}
+
+		return s.disputeRepo.Create(ctx, dispute)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dispute: %w", err)
+	}
+
+	return dispute, nil
+}
+
+func (s *disputeServiceImpl) GetUserDisputes(ctx context.Context, userID int64) ([]models.Dispute, error) {
+	return s.disputeRepo.GetUserDisputes(ctx, userID)
+}
+
+func (s *disputeServiceImpl) GetDisputes(ctx context.Context, status string) ([]models.Dispute, error) {
+	return s.disputeRepo.GetByStatus(ctx, status)
+}
+
+func (s *disputeServiceImpl) RejectDispute(ctx context.Context, adminID, disputeID int64, resolution string) error {
+	return s.disputeRepo.Resolve(ctx, disputeID, adminID, models.DisputeRejected, resolution)
+}
+
+// ReverseTransaction проводит компенсирующую транзакцию от получателя обратно к отправителю.
+// Если получатель уже потратил монеты, отмена невозможна без разрешения на отрицательный баланс
+func (s *disputeServiceImpl) ReverseTransaction(ctx context.Context, adminID, transactionID int64, input models.ReverseTransactionRequest) (*models.Transaction, error) {
+	var reversal *models.Transaction
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		original, err := s.transactionRepo.GetByIDForUpdate(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if original.Kind != models.TransactionTransfer {
+			return errors.New("only transfers between users can be reversed")
+		}
+		if original.Reversed {
+			return errors.New("transaction is already reversed")
+		}
+
+		// Списываем монеты у получателя
+		if input.AllowNegative {
+			err = s.userRepo.ForceUpdateCoins(ctx, original.ToUserID, -original.Amount)
+		} else {
+			err = s.userRepo.UpdateCoins(ctx, original.ToUserID, -original.Amount)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to deduct coins from recipient: %w", err)
+		}
+
+		// Возвращаем монеты отправителю
+		err = s.userRepo.RefundCoins(ctx, original.FromUserID, original.Amount, original.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to return coins to sender: %w", err)
+		}
+
+		description := fmt.Sprintf("Reversal of transaction %d", original.ID)
+		if input.Reason != "" {
+			description += ": " + input.Reason
+		}
+
+		reversal = &models.Transaction{
+			FromUserID:  original.ToUserID,
+			ToUserID:    original.FromUserID,
+			Amount:      original.Amount,
+			Description: description,
+			Kind:        models.TransactionReversal,
+			ReversalOf:  &original.ID,
+		}
+
+		err = s.transactionRepo.Create(ctx, reversal)
+		if err != nil {
+			return fmt.Errorf("failed to create reversal record: %w", err)
+		}
+
+		// Открытый спор по этой транзакции считается решенным
+		return s.disputeRepo.ResolveByTransaction(ctx, original.ID, adminID, models.DisputeResolved, description)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reverse transaction: %w", err)
+	}
+
+	return reversal, nil
+}
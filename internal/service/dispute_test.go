@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDisputeService_ReverseTransaction(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockDisputeRepo := new(MockDisputeRepository)
+
+	service := NewDisputeService(new(MockTransactor), mockUserRepo, mockTransactionRepo, mockDisputeRepo)
+
+	ctx := context.Background()
+	adminID := int64(100)
+	original := &models.Transaction{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 50, Kind: models.TransactionTransfer, CreatedAt: time.Now()}
+
+	// Настраиваем моки
+	mockTransactionRepo.On("GetByIDForUpdate", ctx, original.ID).Return(original, nil)
+	mockUserRepo.On("UpdateCoins", ctx, original.ToUserID, -original.Amount).Return(nil)
+	mockUserRepo.On("RefundCoins", ctx, original.FromUserID, original.Amount, original.CreatedAt).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(t *models.Transaction) bool {
+		return t.Kind == models.TransactionReversal && t.FromUserID == 2 && t.ToUserID == 1 &&
+			t.ReversalOf != nil && *t.ReversalOf == original.ID
+	})).Return(nil)
+	mockDisputeRepo.On("ResolveByTransaction", ctx, original.ID, adminID, models.DisputeResolved, mock.Anything).Return(nil)
+
+	// Вызываем тестируемый метод
+	reversal, err := service.ReverseTransaction(ctx, adminID, original.ID, models.ReverseTransactionRequest{Reason: "wrong user"})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, original.Amount, reversal.Amount)
+	mockTransactionRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+	mockDisputeRepo.AssertExpectations(t)
+}
+
+func TestDisputeService_ReverseTransaction_AlreadySpent(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+
+	service := NewDisputeService(new(MockTransactor), mockUserRepo, mockTransactionRepo, new(MockDisputeRepository))
+
+	ctx := context.Background()
+	original := &models.Transaction{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 50, Kind: models.TransactionTransfer}
+
+	// Настраиваем моки: у получателя не осталось монет
+	mockTransactionRepo.On("GetByIDForUpdate", ctx, original.ID).Return(original, nil)
+	mockUserRepo.On("UpdateCoins", ctx, original.ToUserID, -original.Amount).Return(errors.New("insufficient funds"))
+
+	// Вызываем тестируемый метод
+	_, err := service.ReverseTransaction(ctx, 100, original.ID, models.ReverseTransactionRequest{})
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to deduct coins from recipient")
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", ctx, original.FromUserID, original.Amount)
+}
+
+func TestDisputeService_ReverseTransaction_AllowNegative(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockDisputeRepo := new(MockDisputeRepository)
+
+	service := NewDisputeService(new(MockTransactor), mockUserRepo, mockTransactionRepo, mockDisputeRepo)
+
+	ctx := context.Background()
+	original := &models.Transaction{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 50, Kind: models.TransactionTransfer}
+
+	// Настраиваем моки
+	mockTransactionRepo.On("GetByIDForUpdate", ctx, original.ID).Return(original, nil)
+	mockUserRepo.On("ForceUpdateCoins", ctx, original.ToUserID, -original.Amount).Return(nil)
+	mockUserRepo.On("RefundCoins", ctx, original.FromUserID, original.Amount, original.CreatedAt).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.AnythingOfType("*models.Transaction")).Return(nil)
+	mockDisputeRepo.On("ResolveByTransaction", ctx, original.ID, int64(100), models.DisputeResolved, mock.Anything).Return(nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.ReverseTransaction(ctx, 100, original.ID, models.ReverseTransactionRequest{AllowNegative: true})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestDisputeService_OpenDispute_NotSender(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockDisputeRepo := new(MockDisputeRepository)
+
+	service := NewDisputeService(new(MockTransactor), new(MockUserRepository), mockTransactionRepo, mockDisputeRepo)
+
+	ctx := context.Background()
+	transaction := &models.Transaction{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 50, Kind: models.TransactionTransfer}
+
+	// Настраиваем моки
+	mockTransactionRepo.On("GetByIDForUpdate", ctx, transaction.ID).Return(transaction, nil)
+
+	// Вызываем тестируемый метод от имени получателя
+	_, err := service.OpenDispute(ctx, 2, transaction.ID, "not mine")
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	mockDisputeRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
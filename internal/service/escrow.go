@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// holdBatchSize — сколько истекших удержаний снимается за один запуск
+const holdBatchSize = 100
+
+type escrowServiceImpl struct {
+	transactor repository.Transactor
+	holdRepo   repository.HoldRepository
+}
+
+func NewEscrowService(transactor repository.Transactor, holdRepo repository.HoldRepository) EscrowService {
+	return &escrowServiceImpl{
+		transactor: transactor,
+		holdRepo:   holdRepo,
+	}
+}
+
+// Hold удерживает монеты пользователя до expiresAt. Удержание уменьшает доступный баланс,
+// но не общий
+func (s *escrowServiceImpl) Hold(ctx context.Context, userID, amount int64, reason string, expiresAt time.Time) (*models.CoinHold, error) {
+	if amount <= 0 {
+		return nil, errors.New("hold amount must be positive")
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, errors.New("hold must expire in the future")
+	}
+
+	hold := &models.CoinHold{
+		UserID:    userID,
+		Amount:    amount,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.holdRepo.Create(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to hold %d coins: %w", amount, err)
+	}
+
+	return hold, nil
+}
+
+// Capture превращает удержание в трату: монеты списываются с общего баланса.
+// Запись в истории транзакций создает вызывающий сервис
+func (s *escrowServiceImpl) Capture(ctx context.Context, holdID int64) (*models.CoinHold, error) {
+	hold, err := s.holdRepo.Capture(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture hold %d: %w", holdID, err)
+	}
+
+	return hold, nil
+}
+
+// Release снимает удержание, и монеты снова становятся доступными
+func (s *escrowServiceImpl) Release(ctx context.Context, holdID int64) (*models.CoinHold, error) {
+	hold, err := s.holdRepo.Resolve(ctx, holdID, models.HoldReleased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release hold %d: %w", holdID, err)
+	}
+
+	return hold, nil
+}
+
+func (s *escrowServiceImpl) GetUserHolds(ctx context.Context, userID int64) ([]models.CoinHold, error) {
+	return s.holdRepo.GetActive(ctx, userID)
+}
+
+// ExpireDue снимает удержания с истекшим сроком, чтобы монеты не оставались заблокированными навсегда
+func (s *escrowServiceImpl) ExpireDue(ctx context.Context) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		holds, err := s.holdRepo.LockExpired(ctx, holdBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to lock expired holds: %w", err)
+		}
+
+		for _, hold := range holds {
+			// Каждое удержание снимается во вложенной транзакции, чтобы ошибка
+			// в одном не мешала снять остальные
+			err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, err := s.holdRepo.Resolve(ctx, hold.ID, models.HoldExpired)
+				return err
+			})
+			if err != nil {
+				log.Printf("Expiring hold %d failed: %v", hold.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
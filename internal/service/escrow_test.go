@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEscrowService_Hold(t *testing.T) {
+	mockHoldRepo := new(MockHoldRepository)
+	service := NewEscrowService(new(MockTransactor), mockHoldRepo)
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	mockHoldRepo.On("Create", ctx, mock.MatchedBy(func(hold *models.CoinHold) bool {
+		return hold.UserID == 1 && hold.Amount == 500
+	})).Return(errors.New("insufficient funds"))
+
+	_, err := service.Hold(ctx, 1, 0, "test", expiresAt)
+	assert.ErrorContains(t, err, "must be positive")
+
+	_, err = service.Hold(ctx, 1, 100, "test", time.Now().Add(-time.Minute))
+	assert.ErrorContains(t, err, "expire in the future")
+
+	// Удержание не может превышать доступный баланс
+	_, err = service.Hold(ctx, 1, 500, "test", expiresAt)
+	assert.ErrorContains(t, err, "insufficient funds")
+	mockHoldRepo.AssertExpectations(t)
+}
+
+func TestEscrowService_ExpireDue(t *testing.T) {
+	mockHoldRepo := new(MockHoldRepository)
+	service := NewEscrowService(new(MockTransactor), mockHoldRepo)
+
+	ctx := context.Background()
+
+	mockHoldRepo.On("LockExpired", ctx, holdBatchSize).Return([]models.CoinHold{
+		{ID: 1, UserID: 2, Amount: 100, Status: models.HoldActive},
+		{ID: 2, UserID: 3, Amount: 50, Status: models.HoldActive},
+	}, nil)
+	// Ошибка на одном удержании не мешает снять остальные
+	mockHoldRepo.On("Resolve", ctx, int64(1), models.HoldExpired).Return(nil, errors.New("hold is not active"))
+	mockHoldRepo.On("Resolve", ctx, int64(2), models.HoldExpired).Return(&models.CoinHold{ID: 2}, nil)
+
+	err := service.ExpireDue(ctx)
+
+	assert.NoError(t, err)
+	mockHoldRepo.AssertExpectations(t)
+}
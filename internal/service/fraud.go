@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type fraudServiceImpl struct {
+	transactor repository.Transactor
+	userRepo   repository.UserRepository
+	fraudRepo  repository.FraudRepository
+	alertRepo  repository.AlertRepository
+	settings   config.FraudSettings
+}
+
+func NewFraudService(transactor repository.Transactor, userRepo repository.UserRepository, fraudRepo repository.FraudRepository, alertRepo repository.AlertRepository, settings config.FraudSettings) FraudService {
+	return &fraudServiceImpl{
+		transactor: transactor,
+		userRepo:   userRepo,
+		fraudRepo:  fraudRepo,
+		alertRepo:  alertRepo,
+		settings:   settings,
+	}
+}
+
+// Analyze ищет подозрительные паттерны в переводах, оценивает их и сохраняет оповещения.
+// Если оценка достигает порога, исходящие переводы пользователя блокируются до проверки
+func (s *fraudServiceImpl) Analyze(ctx context.Context) error {
+	now := time.Now()
+	var alerts []models.Alert
+
+	circular, err := s.fraudRepo.FindCircularTransfers(ctx, now.Add(-s.settings.Window))
+	if err != nil {
+		return fmt.Errorf("failed to find circular transfers: %w", err)
+	}
+	for _, signal := range circular {
+		alerts = append(alerts, scoreCircular(signal))
+	}
+
+	funnels, err := s.fraudRepo.FindNewAccountFunnels(ctx, now.Add(-s.settings.Window), now.Add(-s.settings.NewAccountAge), s.settings.FunnelMinSenders)
+	if err != nil {
+		return fmt.Errorf("failed to find new account funnels: %w", err)
+	}
+	for _, signal := range funnels {
+		alerts = append(alerts, scoreFunnel(signal))
+	}
+
+	bursts, err := s.fraudRepo.FindBursts(ctx, now.Add(-s.settings.BurstWindow), now.Add(-s.settings.BaselineWindow), s.settings.BurstMinTransfers)
+	if err != nil {
+		return fmt.Errorf("failed to find activity bursts: %w", err)
+	}
+	for _, signal := range bursts {
+		if alert, ok := s.scoreBurst(signal); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for i := range alerts {
+		if err := s.raise(ctx, &alerts[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// raise сохраняет оповещение и при необходимости блокирует переводы пользователя
+func (s *fraudServiceImpl) raise(ctx context.Context, alert *models.Alert) error {
+	alert.HoldApplied = s.settings.HoldScore > 0 && alert.Score >= s.settings.HoldScore
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		created, err := s.alertRepo.Create(ctx, alert)
+		if err != nil {
+			return fmt.Errorf("failed to create alert: %w", err)
+		}
+
+		// Открытое оповещение по этому правилу уже есть
+		if !created {
+			return nil
+		}
+		log.Printf("Fraud alert %d for user %d: %s (score %d)", alert.ID, alert.UserID, alert.Details, alert.Score)
+
+		if alert.HoldApplied {
+			if err := s.userRepo.SetTransfersOnHold(ctx, alert.UserID, true); err != nil {
+				return fmt.Errorf("failed to put transfers on hold: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *fraudServiceImpl) GetAlerts(ctx context.Context, status string) ([]models.Alert, error) {
+	return s.alertRepo.GetByStatus(ctx, status)
+}
+
+func (s *fraudServiceImpl) ReviewAlert(ctx context.Context, adminID, alertID int64, input models.ReviewAlertRequest) (*models.Alert, error) {
+	var alert *models.Alert
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		alert, err = s.alertRepo.Review(ctx, alertID, adminID, input.Status)
+		if err != nil {
+			return fmt.Errorf("failed to review alert: %w", err)
+		}
+
+		if input.ReleaseHold {
+			if err := s.userRepo.SetTransfersOnHold(ctx, alert.UserID, false); err != nil {
+				return fmt.Errorf("failed to release hold: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (s *fraudServiceImpl) SetHold(ctx context.Context, userID int64, hold bool) error {
+	return s.userRepo.SetTransfersOnHold(ctx, userID, hold)
+}
+
+// scoreCircular оценивает циклический перевод: чем короче цикл, тем он подозрительнее
+func scoreCircular(signal models.FraudSignal) models.Alert {
+	length := int(signal.Value)
+	score := 60
+	if length == 2 {
+		score = 70
+	}
+
+	return newAlert(signal, models.FraudRuleCircular, score,
+		fmt.Sprintf("coins returned to the sender through a cycle of %d users", length))
+}
+
+// scoreFunnel оценивает перевод монет одному получателю со многих новых аккаунтов
+func scoreFunnel(signal models.FraudSignal) models.Alert {
+	senders := int(signal.Value)
+
+	return newAlert(signal, models.FraudRuleFunnel, capScore(senders*10),
+		fmt.Sprintf("received coins from %d new accounts", senders))
+}
+
+// scoreBurst сравнивает число недавних переводов с обычной активностью пользователя
+// за тот же промежуток времени
+func (s *fraudServiceImpl) scoreBurst(signal models.FraudSignal) (models.Alert, bool) {
+	baselineSpan := s.settings.BaselineWindow - s.settings.BurstWindow
+	expected := 0.0
+	if baselineSpan > 0 {
+		expected = signal.Baseline * float64(s.settings.BurstWindow) / float64(baselineSpan)
+	}
+	if expected < 1 {
+		expected = 1
+	}
+
+	ratio := signal.Value / expected
+	if ratio < float64(s.settings.BurstFactor) {
+		return models.Alert{}, false
+	}
+
+	score := capScore(int(ratio / float64(s.settings.BurstFactor) * 50))
+	return newAlert(signal, models.FraudRuleBurst, score,
+		fmt.Sprintf("%d transfers in %s, %.1fx above usual activity", int(signal.Value), s.settings.BurstWindow, ratio)), true
+}
+
+func newAlert(signal models.FraudSignal, rule string, score int, details string) models.Alert {
+	transactionID := signal.TransactionID
+	return models.Alert{
+		UserID:        signal.UserID,
+		TransactionID: &transactionID,
+		Rule:          rule,
+		Score:         score,
+		Details:       details,
+	}
+}
+
+func capScore(score int) int {
+	if score > 100 {
+		return 100
+	}
+	return score
+}
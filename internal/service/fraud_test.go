@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testFraudSettings = config.FraudSettings{
+	Window:            24 * time.Hour,
+	NewAccountAge:     7 * 24 * time.Hour,
+	FunnelMinSenders:  5,
+	BurstWindow:       time.Hour,
+	BaselineWindow:    30 * 24 * time.Hour,
+	BurstFactor:       5,
+	BurstMinTransfers: 10,
+	HoldScore:         70,
+}
+
+func TestFraudService_Analyze(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockFraudRepo := new(MockFraudRepository)
+	mockAlertRepo := new(MockAlertRepository)
+
+	service := NewFraudService(new(MockTransactor), mockUserRepo, mockFraudRepo, mockAlertRepo, testFraudSettings)
+
+	ctx := context.Background()
+	anyTime := mock.AnythingOfType("time.Time")
+
+	// Пользователь 1 вернул себе монеты через цикл из двух человек,
+	// пользователь 2 сделал 12 переводов за час при почти нулевой обычной активности
+	mockFraudRepo.On("FindCircularTransfers", ctx, anyTime).
+		Return([]models.FraudSignal{{UserID: 1, TransactionID: 10, Value: 2}}, nil)
+	mockFraudRepo.On("FindNewAccountFunnels", ctx, anyTime, anyTime, 5).
+		Return([]models.FraudSignal{}, nil)
+	mockFraudRepo.On("FindBursts", ctx, anyTime, anyTime, 10).
+		Return([]models.FraudSignal{{UserID: 2, TransactionID: 20, Value: 12, Baseline: 0}}, nil)
+
+	mockAlertRepo.On("Create", ctx, mock.MatchedBy(func(a *models.Alert) bool {
+		return a.Rule == models.FraudRuleCircular && a.UserID == 1 && a.Score == 70 && a.HoldApplied
+	})).Return(true, nil)
+	mockAlertRepo.On("Create", ctx, mock.MatchedBy(func(a *models.Alert) bool {
+		return a.Rule == models.FraudRuleBurst && a.UserID == 2 && a.Score == 100 && a.HoldApplied
+	})).Return(false, nil)
+	mockUserRepo.On("SetTransfersOnHold", ctx, int64(1), true).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.Analyze(ctx)
+
+	// Проверяем результаты: повторное оповещение не блокирует пользователя 2 еще раз
+	assert.NoError(t, err)
+	mockFraudRepo.AssertExpectations(t)
+	mockAlertRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "SetTransfersOnHold", ctx, int64(2), true)
+}
+
+func TestFraudService_ScoreBurst_UsualActivity(t *testing.T) {
+	service := &fraudServiceImpl{settings: testFraudSettings}
+
+	// 719 переводов за предыдущие 719 часов — около одного в час, 3 в час не всплеск
+	_, ok := service.scoreBurst(models.FraudSignal{UserID: 1, Value: 3, Baseline: 719})
+
+	assert.False(t, ok)
+}
+
+func TestFraudService_ReviewAlert_ReleaseHold(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockAlertRepo := new(MockAlertRepository)
+
+	service := NewFraudService(new(MockTransactor), mockUserRepo, new(MockFraudRepository), mockAlertRepo, testFraudSettings)
+
+	ctx := context.Background()
+	reviewed := &models.Alert{ID: 5, UserID: 3, Status: models.AlertDismissed}
+
+	// Настраиваем моки
+	mockAlertRepo.On("Review", ctx, int64(5), int64(100), models.AlertDismissed).Return(reviewed, nil)
+	mockUserRepo.On("SetTransfersOnHold", ctx, int64(3), false).Return(nil)
+
+	// Вызываем тестируемый метод
+	alert, err := service.ReviewAlert(ctx, 100, 5, models.ReviewAlertRequest{Status: models.AlertDismissed, ReleaseHold: true})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, reviewed, alert)
+	mockAlertRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// Размер рейтинга
+const (
+	defaultLeaderboardSize = 10
+	maxLeaderboardSize     = 100
+)
+
+type leaderboardServiceImpl struct {
+	userRepo        repository.UserRepository
+	leaderboardRepo repository.LeaderboardRepository
+}
+
+func NewLeaderboardService(userRepo repository.UserRepository, leaderboardRepo repository.LeaderboardRepository) LeaderboardService {
+	return &leaderboardServiceImpl{
+		userRepo:        userRepo,
+		leaderboardRepo: leaderboardRepo,
+	}
+}
+
+// GetLeaderboard возвращает рейтинг за период. Рейтинг строится по агрегатам, которые
+// пересчитываются периодически, поэтому последние переводы могут появиться в нем с задержкой
+func (s *leaderboardServiceImpl) GetLeaderboard(ctx context.Context, board, period string, limit int) (*models.Leaderboard, error) {
+	switch board {
+	case models.LeaderboardSenders, models.LeaderboardReceivers, models.LeaderboardRecipients:
+	default:
+		return nil, fmt.Errorf("unknown leaderboard %q", board)
+	}
+
+	switch period {
+	case "":
+		period = models.LeaderboardAll
+	case models.LeaderboardWeek, models.LeaderboardMonth, models.LeaderboardAll:
+	default:
+		return nil, fmt.Errorf("unknown leaderboard period %q", period)
+	}
+
+	if limit <= 0 {
+		limit = defaultLeaderboardSize
+	}
+	if limit > maxLeaderboardSize {
+		limit = maxLeaderboardSize
+	}
+
+	entries, err := s.leaderboardRepo.GetTop(ctx, board, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	return &models.Leaderboard{Board: board, Period: period, Entries: entries}, nil
+}
+
+// SetOptOut скрывает пользователя из рейтингов или возвращает его в них.
+// Настройка действует сразу, не дожидаясь пересчета рейтингов
+func (s *leaderboardServiceImpl) SetOptOut(ctx context.Context, userID int64, optOut bool) error {
+	return s.userRepo.SetLeaderboardOptOut(ctx, userID, optOut)
+}
+
+// Refresh пересчитывает агрегаты рейтингов
+func (s *leaderboardServiceImpl) Refresh(ctx context.Context) error {
+	if err := s.leaderboardRepo.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to refresh leaderboards: %w", err)
+	}
+	return nil
+}
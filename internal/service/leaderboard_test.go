@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLeaderboardService_GetLeaderboard(t *testing.T) {
+	mockLeaderboardRepo := new(MockLeaderboardRepository)
+	service := NewLeaderboardService(new(MockUserRepository), mockLeaderboardRepo)
+
+	ctx := context.Background()
+	entries := []models.LeaderboardEntry{
+		{Rank: 1, Username: "alice", Value: 900},
+		{Rank: 2, Username: "bob", Value: 400},
+	}
+	mockLeaderboardRepo.On("GetTop", ctx, models.LeaderboardSenders, models.LeaderboardWeek, 5).Return(entries, nil)
+
+	leaderboard, err := service.GetLeaderboard(ctx, models.LeaderboardSenders, models.LeaderboardWeek, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Leaderboard{Board: models.LeaderboardSenders, Period: models.LeaderboardWeek, Entries: entries}, leaderboard)
+	mockLeaderboardRepo.AssertExpectations(t)
+}
+
+func TestLeaderboardService_GetLeaderboard_Defaults(t *testing.T) {
+	mockLeaderboardRepo := new(MockLeaderboardRepository)
+	service := NewLeaderboardService(new(MockUserRepository), mockLeaderboardRepo)
+
+	ctx := context.Background()
+	mockLeaderboardRepo.On("GetTop", ctx, models.LeaderboardRecipients, models.LeaderboardAll, defaultLeaderboardSize).Return([]models.LeaderboardEntry{}, nil).Once()
+	mockLeaderboardRepo.On("GetTop", ctx, models.LeaderboardReceivers, models.LeaderboardMonth, maxLeaderboardSize).Return([]models.LeaderboardEntry{}, nil).Once()
+
+	// Без периода и размера рейтинг строится за все время с размером по умолчанию
+	leaderboard, err := service.GetLeaderboard(ctx, models.LeaderboardRecipients, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaderboardAll, leaderboard.Period)
+
+	// Слишком большой размер ограничивается
+	_, err = service.GetLeaderboard(ctx, models.LeaderboardReceivers, models.LeaderboardMonth, 1000)
+	assert.NoError(t, err)
+
+	mockLeaderboardRepo.AssertExpectations(t)
+}
+
+func TestLeaderboardService_GetLeaderboard_Invalid(t *testing.T) {
+	mockLeaderboardRepo := new(MockLeaderboardRepository)
+	service := NewLeaderboardService(new(MockUserRepository), mockLeaderboardRepo)
+
+	ctx := context.Background()
+
+	_, err := service.GetLeaderboard(ctx, "spenders", models.LeaderboardWeek, 10)
+	assert.ErrorContains(t, err, "unknown leaderboard")
+
+	_, err = service.GetLeaderboard(ctx, models.LeaderboardSenders, "year", 10)
+	assert.ErrorContains(t, err, "unknown leaderboard period")
+
+	mockLeaderboardRepo.AssertNotCalled(t, "GetTop", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLeaderboardService_SetOptOut(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service := NewLeaderboardService(mockUserRepo, new(MockLeaderboardRepository))
+
+	ctx := context.Background()
+	mockUserRepo.On("SetLeaderboardOptOut", ctx, int64(1), true).Return(nil)
+
+	err := service.SetOptOut(ctx, 1, true)
+
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+}
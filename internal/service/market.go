@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type marketServiceImpl struct {
+	transactor      repository.Transactor
+	userRepo        repository.UserRepository
+	userMerchRepo   repository.UserMerchRepository
+	orderRepo       repository.OrderRepository
+	transactionRepo repository.TransactionRepository
+	marketRepo      repository.MarketRepository
+	policy          TransferPolicy
+	settings        config.MarketSettings
+}
+
+func NewMarketService(transactor repository.Transactor, userRepo repository.UserRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, transactionRepo repository.TransactionRepository, marketRepo repository.MarketRepository, policy TransferPolicy, settings config.MarketSettings) MarketService {
+	return &marketServiceImpl{
+		transactor:      transactor,
+		userRepo:        userRepo,
+		userMerchRepo:   userMerchRepo,
+		orderRepo:       orderRepo,
+		transactionRepo: transactionRepo,
+		marketRepo:      marketRepo,
+		policy:          policy,
+		settings:        settings,
+	}
+}
+
+// CreateListing выставляет предмет из инвентаря на продажу. Пока объявление активно,
+// предмет заблокирован: его нельзя обменять, вернуть или выставить повторно
+func (s *marketServiceImpl) CreateListing(ctx context.Context, userID int64, input models.CreateListingRequest) (*models.MarketListing, error) {
+	if input.Price <= 0 {
+		return nil, errors.New("price must be positive")
+	}
+
+	var listing *models.MarketListing
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		item, err := s.userMerchRepo.GetByIDForUpdate(ctx, input.ItemID)
+		if err != nil {
+			return err
+		}
+
+		if item.UserID != userID {
+			return errors.New("item not found")
+		}
+		if item.Status != models.UserMerchOwned {
+			return fmt.Errorf("item is %s and cannot be listed", item.Status)
+		}
+		if err := checkDelivered(ctx, s.orderRepo, item); err != nil {
+			return err
+		}
+
+		if err := s.userMerchRepo.SetStatus(ctx, item.ID, models.UserMerchListed); err != nil {
+			return fmt.Errorf("failed to lock item: %w", err)
+		}
+
+		created := &models.MarketListing{
+			UserMerchID: item.ID,
+			SellerID:    userID,
+			Price:       input.Price,
+		}
+		if err := s.marketRepo.Create(ctx, created); err != nil {
+			return err
+		}
+
+		// Перечитываем объявление, чтобы вернуть имя продавца и название предмета
+		listing, err = s.marketRepo.GetByIDForUpdate(ctx, created.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listing: %w", err)
+	}
+
+	return listing, nil
+}
+
+// CancelListing снимает объявление с продажи и возвращает предмет в инвентарь продавца
+func (s *marketServiceImpl) CancelListing(ctx context.Context, userID, listingID int64) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		listing, err := s.activeListing(ctx, listingID)
+		if err != nil {
+			return err
+		}
+		if listing.SellerID != userID {
+			return errors.New("listing not found")
+		}
+
+		if err := s.userMerchRepo.SetStatus(ctx, listing.UserMerchID, models.UserMerchOwned); err != nil {
+			return fmt.Errorf("failed to unlock item: %w", err)
+		}
+
+		listing.Status = models.ListingCancelled
+		return s.marketRepo.Close(ctx, listing)
+	})
+}
+
+// BuyListing покупает предмет по объявлению. Монеты переходят продавцу, комиссия площадки
+// удерживается с продавца в пользу системного аккаунта, а предмет переходит покупателю
+func (s *marketServiceImpl) BuyListing(ctx context.Context, userID, listingID int64) (*models.MarketListing, error) {
+	var listing *models.MarketListing
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		listing, err = s.activeListing(ctx, listingID)
+		if err != nil {
+			return err
+		}
+		if listing.SellerID == userID {
+			return errors.New("cannot buy your own listing")
+		}
+
+		fee := listing.Price * int64(s.settings.FeePercent) / 100
+
+		lockIDs := []int64{userID, listing.SellerID}
+		var feeAccount *models.User
+		if fee > 0 {
+			feeAccount, err = s.userRepo.GetByUsername(ctx, s.settings.FeeAccount)
+			if err != nil {
+				return fmt.Errorf("fee account not found: %w", err)
+			}
+			if !feeAccount.IsSystem {
+				return fmt.Errorf("fee account %s is not a system account", feeAccount.Username)
+			}
+			lockIDs = append(lockIDs, feeAccount.ID)
+		}
+
+		users, err := lockUsers(ctx, s.userRepo, lockIDs...)
+		if err != nil {
+			return err
+		}
+		buyer, seller := users[userID], users[listing.SellerID]
+
+		// Покупка на маркетплейсе — перевод между пользователями и проходит политику переводов
+		if err := s.policy.Check(ctx, buyer, seller, listing.Price); err != nil {
+			return err
+		}
+
+		item, err := s.userMerchRepo.GetByIDForUpdate(ctx, listing.UserMerchID)
+		if err != nil {
+			return err
+		}
+		if item.UserID != listing.SellerID || item.Status != models.UserMerchListed {
+			return errors.New("item is no longer available")
+		}
+
+		if err := s.userRepo.UpdateCoins(ctx, buyer.ID, -listing.Price); err != nil {
+			return fmt.Errorf("failed to deduct coins from buyer: %w", err)
+		}
+		if err := s.userRepo.UpdateCoins(ctx, seller.ID, listing.Price); err != nil {
+			return fmt.Errorf("failed to add coins to seller: %w", err)
+		}
+
+		sale := &models.Transaction{
+			FromUserID:  buyer.ID,
+			ToUserID:    seller.ID,
+			Amount:      listing.Price,
+			Description: fmt.Sprintf("Marketplace purchase of %s (listing #%d)", listing.Item, listing.ID),
+			Kind:        models.TransactionSale,
+		}
+		if err := s.transactionRepo.Create(ctx, sale); err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
+
+		if feeAccount != nil {
+			if err := s.userRepo.UpdateCoins(ctx, seller.ID, -fee); err != nil {
+				return fmt.Errorf("failed to charge marketplace fee: %w", err)
+			}
+			if err := s.userRepo.UpdateCoins(ctx, feeAccount.ID, fee); err != nil {
+				return fmt.Errorf("failed to credit marketplace fee: %w", err)
+			}
+
+			charge := &models.Transaction{
+				FromUserID:  seller.ID,
+				ToUserID:    feeAccount.ID,
+				Amount:      fee,
+				Description: fmt.Sprintf("Marketplace fee for listing #%d", listing.ID),
+				Kind:        models.TransactionFee,
+			}
+			if err := s.transactionRepo.Create(ctx, charge); err != nil {
+				return fmt.Errorf("failed to create transaction record: %w", err)
+			}
+		}
+
+		if err := s.userMerchRepo.SetStatus(ctx, item.ID, models.UserMerchOwned); err != nil {
+			return fmt.Errorf("failed to unlock item: %w", err)
+		}
+		if err := s.userMerchRepo.Transfer(ctx, []int64{item.ID}, buyer.ID); err != nil {
+			return fmt.Errorf("failed to transfer item: %w", err)
+		}
+
+		listing.Status = models.ListingSold
+		listing.BuyerID = &buyer.ID
+		listing.Fee = fee
+
+		return s.marketRepo.Close(ctx, listing)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to buy listing: %w", err)
+	}
+
+	return listing, nil
+}
+
+// GetListings возвращает страницу активных объявлений и их общее число
+func (s *marketServiceImpl) GetListings(ctx context.Context, filter models.MarketFilter) ([]models.MarketListing, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultMerchPageSize
+	}
+	if filter.Limit > maxMerchPageSize {
+		filter.Limit = maxMerchPageSize
+	}
+	if filter.Offset < 0 {
+		return nil, 0, errors.New("offset must not be negative")
+	}
+
+	return s.marketRepo.Find(ctx, filter)
+}
+
+func (s *marketServiceImpl) GetUserListings(ctx context.Context, userID int64) ([]models.MarketListing, error) {
+	return s.marketRepo.GetUserListings(ctx, userID)
+}
+
+// activeListing блокирует объявление и проверяет, что оно еще не закрыто
+func (s *marketServiceImpl) activeListing(ctx context.Context, listingID int64) (*models.MarketListing, error) {
+	listing, err := s.marketRepo.GetByIDForUpdate(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingActive {
+		return nil, fmt.Errorf("listing is already %s", listing.Status)
+	}
+
+	return listing, nil
+}
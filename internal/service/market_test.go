@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMarketService_CreateListing(t *testing.T) {
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockMarketRepo := new(MockMarketRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	settings := config.MarketSettings{FeeAccount: "system"}
+	service := NewMarketService(new(MockTransactor), new(MockUserRepository), mockUserMerchRepo, mockOrderRepo, new(MockTransactionRepository), mockMarketRepo, policy, settings)
+
+	ctx := context.Background()
+	userID := int64(1)
+	orderID := int64(7)
+
+	// Настраиваем моки
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(10)).Return(&models.UserMerch{
+		ID: 10, UserID: userID, MerchID: 1, OrderID: &orderID, Status: models.UserMerchOwned,
+	}, nil)
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(11)).Return(&models.UserMerch{
+		ID: 11, UserID: userID, MerchID: 1, Status: models.UserMerchListed,
+	}, nil)
+	mockOrderRepo.On("GetByID", ctx, orderID).Return(&models.Order{ID: orderID, Status: models.OrderDelivered}, nil)
+	mockUserMerchRepo.On("SetStatus", ctx, int64(10), models.UserMerchListed).Return(nil)
+	mockMarketRepo.On("Create", ctx, mock.MatchedBy(func(listing *models.MarketListing) bool {
+		return listing.UserMerchID == 10 && listing.SellerID == userID && listing.Price == 150
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.MarketListing).ID = 3
+	}).Return(nil)
+	mockMarketRepo.On("GetByIDForUpdate", ctx, int64(3)).Return(&models.MarketListing{
+		ID: 3, UserMerchID: 10, SellerID: userID, Item: "t-shirt", Price: 150, Status: models.ListingActive,
+	}, nil)
+
+	// Вызываем тестируемый метод
+	listing, err := service.CreateListing(ctx, userID, models.CreateListingRequest{ItemID: 10, Price: 150})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, "t-shirt", listing.Item)
+
+	// Уже выставленный предмет нельзя выставить повторно
+	_, err = service.CreateListing(ctx, userID, models.CreateListingRequest{ItemID: 11, Price: 150})
+	assert.ErrorContains(t, err, "item is listed")
+
+	// Чужой предмет выставить нельзя
+	_, err = service.CreateListing(ctx, 2, models.CreateListingRequest{ItemID: 10, Price: 150})
+	assert.ErrorContains(t, err, "item not found")
+
+	mockMarketRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestMarketService_BuyListing(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockMarketRepo := new(MockMarketRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	settings := config.MarketSettings{FeePercent: 10, FeeAccount: "system"}
+	service := NewMarketService(new(MockTransactor), mockUserRepo, mockUserMerchRepo, new(MockOrderRepository), mockTransactionRepo, mockMarketRepo, policy, settings)
+
+	ctx := context.Background()
+	sellerID, buyerID, systemID := int64(1), int64(2), int64(3)
+
+	// Настраиваем моки
+	mockMarketRepo.On("GetByIDForUpdate", ctx, int64(3)).Return(&models.MarketListing{
+		ID: 3, UserMerchID: 10, SellerID: sellerID, Item: "t-shirt", Price: 150, Status: models.ListingActive,
+	}, nil)
+	mockUserRepo.On("GetByUsername", ctx, "system").Return(&models.User{ID: systemID, Username: "system", IsSystem: true}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, sellerID).Return(&models.User{ID: sellerID, Username: "seller"}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, buyerID).Return(&models.User{ID: buyerID, Username: "buyer", Coins: 500}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, systemID).Return(&models.User{ID: systemID, Username: "system", IsSystem: true}, nil)
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(10)).Return(&models.UserMerch{
+		ID: 10, UserID: sellerID, MerchID: 1, Status: models.UserMerchListed,
+	}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, buyerID, int64(-150)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, sellerID, int64(150)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, sellerID, int64(-15)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, systemID, int64(15)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == buyerID && transaction.ToUserID == sellerID &&
+			transaction.Amount == 150 && transaction.Kind == models.TransactionSale
+	})).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == sellerID && transaction.ToUserID == systemID &&
+			transaction.Amount == 15 && transaction.Kind == models.TransactionFee
+	})).Return(nil)
+	mockUserMerchRepo.On("SetStatus", ctx, int64(10), models.UserMerchOwned).Return(nil)
+	mockUserMerchRepo.On("Transfer", ctx, []int64{10}, buyerID).Return(nil)
+	mockMarketRepo.On("Close", ctx, mock.MatchedBy(func(listing *models.MarketListing) bool {
+		return listing.Status == models.ListingSold && *listing.BuyerID == buyerID && listing.Fee == 15
+	})).Return(nil)
+
+	// Вызываем тестируемый метод: свое объявление купить нельзя
+	_, err := service.BuyListing(ctx, sellerID, 3)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "your own listing")
+
+	listing, err := service.BuyListing(ctx, buyerID, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), listing.Fee)
+	mockUserRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockMarketRepo.AssertExpectations(t)
+}
+
+func TestMarketService_BuyListing_FeeAccountNotSystem(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockMarketRepo := new(MockMarketRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	settings := config.MarketSettings{FeePercent: 10, FeeAccount: "system"}
+	service := NewMarketService(new(MockTransactor), mockUserRepo, new(MockUserMerchRepository), new(MockOrderRepository), mockTransactionRepo, mockMarketRepo, policy, settings)
+
+	ctx := context.Background()
+
+	// Настраиваем моки: имя системного аккаунта занято обычным пользователем
+	mockMarketRepo.On("GetByIDForUpdate", ctx, int64(3)).Return(&models.MarketListing{
+		ID: 3, UserMerchID: 10, SellerID: 1, Item: "t-shirt", Price: 150, Status: models.ListingActive,
+	}, nil)
+	mockUserRepo.On("GetByUsername", ctx, "system").Return(&models.User{ID: 3, Username: "system"}, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.BuyListing(ctx, 2, 3)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "not a system account")
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMarketService_CancelListing(t *testing.T) {
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockMarketRepo := new(MockMarketRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	settings := config.MarketSettings{FeeAccount: "system"}
+	service := NewMarketService(new(MockTransactor), new(MockUserRepository), mockUserMerchRepo, new(MockOrderRepository), new(MockTransactionRepository), mockMarketRepo, policy, settings)
+
+	ctx := context.Background()
+
+	mockMarketRepo.On("GetByIDForUpdate", ctx, int64(3)).Return(&models.MarketListing{
+		ID: 3, UserMerchID: 10, SellerID: 1, Price: 150, Status: models.ListingActive,
+	}, nil)
+	mockMarketRepo.On("GetByIDForUpdate", ctx, int64(4)).Return(&models.MarketListing{
+		ID: 4, UserMerchID: 12, SellerID: 1, Price: 150, Status: models.ListingSold,
+	}, nil)
+	mockUserMerchRepo.On("SetStatus", ctx, int64(10), models.UserMerchOwned).Return(nil)
+	mockMarketRepo.On("Close", ctx, mock.MatchedBy(func(listing *models.MarketListing) bool {
+		return listing.ID == 3 && listing.Status == models.ListingCancelled
+	})).Return(nil)
+
+	// Снять объявление может только продавец
+	assert.ErrorContains(t, service.CancelListing(ctx, 2, 3), "listing not found")
+	// Проданное объявление снять нельзя
+	assert.ErrorContains(t, service.CancelListing(ctx, 1, 4), "already sold")
+
+	assert.NoError(t, service.CancelListing(ctx, 1, 3))
+	mockUserMerchRepo.AssertExpectations(t)
+	mockMarketRepo.AssertNumberOfCalls(t, "Close", 1)
+}
@@ -1,56 +1,413 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"strings"
 
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/imaging"
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/haqer0002/avito-shop/internal/repository"
+	"github.com/haqer0002/avito-shop/internal/storage"
+)
+
+// Размер страницы каталога
+const (
+	defaultMerchPageSize = 50
+	maxMerchPageSize     = 100
 )
 
 type merchServiceImpl struct {
-	userRepo      repository.UserRepository
-	merchRepo     repository.MerchRepository
-	userMerchRepo repository.UserMerchRepository
+	transactor repository.Transactor
+	userRepo   repository.UserRepository
+	merchRepo  repository.MerchRepository
+	purchaser  *purchaser
+	store      storage.BlobStore
+	media      config.MediaSettings
+	publisher  events.Publisher
 }
 
-func NewMerchService(userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository) MerchService {
+func NewMerchService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, pricing PricingEngine, store storage.BlobStore, media config.MediaSettings, publisher events.Publisher) MerchService {
 	return &merchServiceImpl{
-		userRepo:      userRepo,
-		merchRepo:     merchRepo,
-		userMerchRepo: userMerchRepo,
+		transactor: transactor,
+		userRepo:   userRepo,
+		merchRepo:  merchRepo,
+		store:      store,
+		media:      media,
+		publisher:  publisher,
+		purchaser: &purchaser{
+			userRepo:      userRepo,
+			merchRepo:     merchRepo,
+			userMerchRepo: userMerchRepo,
+			orderRepo:     orderRepo,
+			pricing:       pricing,
+		},
 	}
 }
 
-func (s *merchServiceImpl) BuyMerch(ctx context.Context, userID int64, merchName string) error {
+func (s *merchServiceImpl) BuyMerch(ctx context.Context, userID int64, merchName string, opts models.BuyOptions) (*models.Order, error) {
 	// Получаем информацию о мерче
 	merch, err := s.merchRepo.GetByName(ctx, merchName)
 	if err != nil {
-		return fmt.Errorf("merch not found: %w", err)
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	line := purchaseLine{merch: merch, quantity: opts.Quantity}
+	if opts.VariantSKU != "" {
+		line.variant, err = s.merchRepo.GetVariantBySKU(ctx, opts.VariantSKU)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Покупка в подарок: мерч получает другой пользователь
+	var present *gift
+	lockIDs := []int64{userID}
+	if opts.Recipient != "" {
+		recipient, err := s.userRepo.GetByUsername(ctx, opts.Recipient)
+		if err != nil {
+			return nil, fmt.Errorf("recipient not found: %w", err)
+		}
+
+		present = &gift{recipientID: recipient.ID}
+		if note := strings.TrimSpace(opts.GiftNote); note != "" {
+			present.note = &note
+		}
+		lockIDs = append(lockIDs, recipient.ID)
+	}
+
+	var order *models.Order
+
+	// Списание, заказ и запись о покупке выполняются атомарно
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Блокируем покупателя и получателя, чтобы параллельные покупки не обошли ограничения на товар
+		if _, err := lockUsers(ctx, s.userRepo, lockIDs...); err != nil {
+			return err
+		}
+
+		order, err = s.purchaser.placeOrder(ctx, userID, []purchaseLine{line}, opts.PromoCode, present)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, events.MerchBought{UserID: userID, OrderID: order.ID, Total: order.Total})
+	return order, nil
+}
+
+// GetAllMerch возвращает страницу каталога с вложенными вариантами и изображениями товаров
+// и общее число товаров по фильтру
+func (s *merchServiceImpl) GetAllMerch(ctx context.Context, filter models.MerchFilter) ([]models.MerchItem, int, error) {
+	switch filter.Sort {
+	case "":
+		filter.Sort = models.MerchSortPriceAsc
+	case models.MerchSortPriceAsc, models.MerchSortPriceDesc, models.MerchSortNameAsc, models.MerchSortNameDesc:
+	default:
+		return nil, 0, fmt.Errorf("unknown sort order %q", filter.Sort)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultMerchPageSize
+	}
+	if filter.Limit > maxMerchPageSize {
+		filter.Limit = maxMerchPageSize
+	}
+	if filter.Offset < 0 {
+		return nil, 0, errors.New("offset must not be negative")
+	}
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return nil, 0, errors.New("min price is greater than max price")
+	}
+	// Теги хранятся нормализованными, см. normalizeTags
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+
+	items, total, err := s.merchRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(items) == 0 {
+		return items, total, nil
+	}
+
+	if err := s.attachVariants(ctx, items); err != nil {
+		return nil, 0, err
+	}
+	if err := s.attachImages(ctx, items); err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// attachVariants загружает варианты товаров, у которых они есть
+func (s *merchServiceImpl) attachVariants(ctx context.Context, items []models.MerchItem) error {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.HasVariants {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
 	}
 
-	// Списываем монеты у пользователя
-	err = s.userRepo.UpdateCoins(ctx, userID, -merch.Price)
+	variants, err := s.merchRepo.GetVariants(ctx, ids)
 	if err != nil {
-		return fmt.Errorf("failed to deduct coins: %w", err)
+		return fmt.Errorf("failed to get merch variants: %w", err)
 	}
 
-	// Создаем запись о покупке
-	userMerch := &models.UserMerch{
-		UserID:  userID,
-		MerchID: merch.ID,
+	byMerch := make(map[int64][]models.MerchVariant, len(ids))
+	for _, variant := range variants {
+		byMerch[variant.MerchID] = append(byMerch[variant.MerchID], variant)
+	}
+	for i := range items {
+		items[i].Variants = byMerch[items[i].ID]
 	}
 
-	err = s.userMerchRepo.Create(ctx, userMerch)
+	return nil
+}
+
+// attachImages загружает изображения товаров и проставляет их адреса
+func (s *merchServiceImpl) attachImages(ctx context.Context, items []models.MerchItem) error {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	images, err := s.merchRepo.GetImages(ctx, ids)
 	if err != nil {
-		// В случае ошибки возвращаем монеты пользователю
-		_ = s.userRepo.UpdateCoins(ctx, userID, merch.Price)
-		return fmt.Errorf("failed to record purchase: %w", err)
+		return fmt.Errorf("failed to get merch images: %w", err)
+	}
+
+	byMerch := make(map[int64][]models.MerchImage, len(ids))
+	for _, image := range images {
+		s.setImageURLs(&image)
+		byMerch[image.MerchID] = append(byMerch[image.MerchID], image)
+	}
+	for i := range items {
+		items[i].Images = byMerch[items[i].ID]
+		if items[i].Images == nil {
+			items[i].Images = []models.MerchImage{}
+		}
 	}
 
 	return nil
 }
 
-func (s *merchServiceImpl) GetAllMerch(ctx context.Context) ([]models.MerchItem, error) {
-	return s.merchRepo.GetAll(ctx)
+func (s *merchServiceImpl) setImageURLs(image *models.MerchImage) {
+	image.URL = s.store.URL(image.Key)
+	image.ThumbnailURL = s.store.URL(image.ThumbnailKey)
+}
+
+func (s *merchServiceImpl) GetCategories(ctx context.Context) ([]models.MerchCategory, error) {
+	return s.merchRepo.GetCategories(ctx)
+}
+
+// UpdateMerch меняет отображаемое название, описание, категорию и теги товара
+func (s *merchServiceImpl) UpdateMerch(ctx context.Context, merchName string, input models.UpdateMerchRequest) (*models.MerchItem, error) {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	if input.DisplayName != nil {
+		displayName := strings.TrimSpace(*input.DisplayName)
+		if displayName == "" {
+			return nil, errors.New("display name must not be empty")
+		}
+		merch.DisplayName = displayName
+	}
+	if input.Description != nil {
+		merch.Description = input.Description
+		if strings.TrimSpace(*input.Description) == "" {
+			merch.Description = nil
+		}
+	}
+
+	if input.Category != nil {
+		merch.Category = input.Category
+		if *input.Category == "" {
+			merch.Category = nil
+		}
+	}
+	if input.Tags != nil {
+		merch.Tags = normalizeTags(input.Tags)
+	}
+
+	err = s.merchRepo.UpdateDetails(ctx, merch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update merch: %w", err)
+	}
+
+	return merch, nil
+}
+
+// normalizeTags приводит теги к нижнему регистру и убирает пустые и повторяющиеся
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// ChangePrice меняет цену товара и записывает изменение в историю цен
+func (s *merchServiceImpl) ChangePrice(ctx context.Context, adminID int64, merchName string, price int64) (*models.PriceChange, error) {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	change := &models.PriceChange{
+		MerchID:   merch.ID,
+		NewPrice:  price,
+		ChangedBy: &adminID,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		oldPrice, err := s.merchRepo.UpdatePrice(ctx, merch.ID, price)
+		if err != nil {
+			return fmt.Errorf("failed to update price: %w", err)
+		}
+		change.OldPrice = &oldPrice
+
+		return s.merchRepo.AddPriceChange(ctx, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (s *merchServiceImpl) GetPriceHistory(ctx context.Context, merchName string) ([]models.PriceChange, error) {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	return s.merchRepo.GetPriceHistory(ctx, merch.ID)
+}
+
+// UploadImage сохраняет изображение товара и его миниатюру в хранилище
+func (s *merchServiceImpl) UploadImage(ctx context.Context, merchName string, r io.Reader) (*models.MerchImage, error) {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	// Читаем на один байт больше лимита, чтобы отличить файл предельного размера от слишком большого
+	data, err := io.ReadAll(io.LimitReader(r, s.media.MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > s.media.MaxImageSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", s.media.MaxImageSize)
+	}
+
+	// Размеры проверяем по заголовку до декодирования: маленький файл может описывать
+	// огромное изображение, под которое декодер выделит гигабайты памяти
+	format, width, height, err := imaging.Probe(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(width)*int64(height) > s.media.MaxImagePixels {
+		return nil, fmt.Errorf("image is larger than %d pixels", s.media.MaxImagePixels)
+	}
+
+	thumbnail, err := imaging.Thumbnail(bytes.NewReader(data), s.media.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+
+	extension := format
+	if format == "jpeg" {
+		extension = "jpg"
+	}
+
+	image := &models.MerchImage{
+		MerchID:      merch.ID,
+		Key:          fmt.Sprintf("merch/%d/%s.%s", merch.ID, name, extension),
+		ThumbnailKey: fmt.Sprintf("merch/%d/%s_thumb.jpg", merch.ID, name),
+		ContentType:  "image/" + format,
+	}
+
+	if err := s.store.Put(ctx, image.Key, bytes.NewReader(data), image.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := s.store.Put(ctx, image.ThumbnailKey, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+		s.deleteBlobs(ctx, image)
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	if err := s.merchRepo.AddImage(ctx, image); err != nil {
+		s.deleteBlobs(ctx, image)
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+
+	s.setImageURLs(image)
+	return image, nil
+}
+
+// DeleteImage удаляет изображение товара вместе с файлами в хранилище
+func (s *merchServiceImpl) DeleteImage(ctx context.Context, merchName string, imageID int64) error {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return fmt.Errorf("merch not found: %w", err)
+	}
+
+	image, err := s.merchRepo.GetImage(ctx, imageID)
+	if err != nil {
+		return err
+	}
+	if image.MerchID != merch.ID {
+		return errors.New("merch image not found")
+	}
+
+	if err := s.merchRepo.DeleteImage(ctx, image.ID); err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+
+	// Запись уже удалена, поэтому оставшиеся в хранилище файлы никому не видны
+	s.deleteBlobs(ctx, image)
+	return nil
+}
+
+// deleteBlobs удаляет файлы изображения, ошибки удаления не мешают основной операции
+func (s *merchServiceImpl) deleteBlobs(ctx context.Context, image *models.MerchImage) {
+	for _, key := range []string{image.Key, image.ThumbnailKey} {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// randomName возвращает случайное имя файла, чтобы адрес изображения не менялся и не угадывался
+func randomName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
@@ -1,11 +1,20 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"image"
+	"image/png"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
 	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/storage"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,17 +32,115 @@ func (m *MockMerchRepository) GetByName(ctx context.Context, name string) (*mode
 	return args.Get(0).(*models.MerchItem), args.Error(1)
 }
 
-func (m *MockMerchRepository) GetAll(ctx context.Context) ([]models.MerchItem, error) {
+func (m *MockMerchRepository) GetByID(ctx context.Context, id int64) (*models.MerchItem, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MerchItem), args.Error(1)
+}
+
+func (m *MockMerchRepository) UpdatePrice(ctx context.Context, merchID, price int64) (int64, error) {
+	args := m.Called(ctx, merchID, price)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMerchRepository) AddPriceChange(ctx context.Context, change *models.PriceChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GetPriceHistory(ctx context.Context, merchID int64) ([]models.PriceChange, error) {
+	args := m.Called(ctx, merchID)
+	return args.Get(0).([]models.PriceChange), args.Error(1)
+}
+
+func (m *MockMerchRepository) Find(ctx context.Context, filter models.MerchFilter) ([]models.MerchItem, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.MerchItem), args.Int(1), args.Error(2)
+}
+
+func (m *MockMerchRepository) GetCategories(ctx context.Context) ([]models.MerchCategory, error) {
 	args := m.Called(ctx)
-	return args.Get(0).([]models.MerchItem), args.Error(1)
+	return args.Get(0).([]models.MerchCategory), args.Error(1)
+}
+
+func (m *MockMerchRepository) UpdateDetails(ctx context.Context, merch *models.MerchItem) error {
+	args := m.Called(ctx, merch)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) DecrementStock(ctx context.Context, merchID int64, quantity int) error {
+	args := m.Called(ctx, merchID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) Restock(ctx context.Context, merchID int64, quantity int) error {
+	args := m.Called(ctx, merchID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GetVariants(ctx context.Context, merchIDs []int64) ([]models.MerchVariant, error) {
+	args := m.Called(ctx, merchIDs)
+	return args.Get(0).([]models.MerchVariant), args.Error(1)
+}
+
+func (m *MockMerchRepository) GetVariantBySKU(ctx context.Context, sku string) (*models.MerchVariant, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MerchVariant), args.Error(1)
+}
+
+func (m *MockMerchRepository) DecrementVariantStock(ctx context.Context, variantID int64, quantity int) error {
+	args := m.Called(ctx, variantID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) RestockVariant(ctx context.Context, variantID int64, quantity int) error {
+	args := m.Called(ctx, variantID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) AddImage(ctx context.Context, image *models.MerchImage) error {
+	args := m.Called(ctx, image)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GetImages(ctx context.Context, merchIDs []int64) ([]models.MerchImage, error) {
+	args := m.Called(ctx, merchIDs)
+	return args.Get(0).([]models.MerchImage), args.Error(1)
+}
+
+func (m *MockMerchRepository) GetImage(ctx context.Context, imageID int64) (*models.MerchImage, error) {
+	args := m.Called(ctx, imageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MerchImage), args.Error(1)
+}
+
+func (m *MockMerchRepository) DeleteImage(ctx context.Context, imageID int64) error {
+	args := m.Called(ctx, imageID)
+	return args.Error(0)
+}
+
+// testMedia — параметры хранения изображений для тестов
+var testMedia = config.MediaSettings{URLPrefix: "/media", ThumbnailSize: 16, MaxImageSize: 1 << 20, MaxImagePixels: 4096}
+
+// newTestStore создает локальное хранилище во временном каталоге теста
+func newTestStore(t *testing.T) *storage.LocalStore {
+	return storage.NewLocalStore(t.TempDir(), testMedia.URLPrefix)
 }
 
 func TestMerchService_BuyMerch(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockMerchRepo := new(MockMerchRepository)
 	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
 
-	service := NewMerchService(mockUserRepo, mockMerchRepo, mockUserMerchRepo)
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, newTestPricing(), newTestStore(t), testMedia, events.NewBus())
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -48,16 +155,23 @@ func TestMerchService_BuyMerch(t *testing.T) {
 
 	// Настраиваем моки
 	mockMerchRepo.On("GetByName", ctx, merchName).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, userID).Return(&models.User{ID: userID}, nil)
+	mockMerchRepo.On("DecrementStock", ctx, testMerch.ID, 1).Return(nil)
 	mockUserRepo.On("UpdateCoins", ctx, userID, -testMerch.Price).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.MatchedBy(func(order *models.Order) bool {
+		return order.Status == models.OrderPlaced && order.Total == testMerch.Price && len(order.Items) == 1
+	})).Return(nil)
 	mockUserMerchRepo.On("Create", ctx, mock.AnythingOfType("*models.UserMerch")).Return(nil)
 
 	// Вызываем тестируемый метод
-	err := service.BuyMerch(ctx, userID, merchName)
+	order, err := service.BuyMerch(ctx, userID, merchName, models.BuyOptions{Quantity: 1})
 
 	// Проверяем результаты
 	assert.NoError(t, err)
+	assert.Equal(t, testMerch.Price, order.Total)
 	mockMerchRepo.AssertExpectations(t)
 	mockUserRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
 	mockUserMerchRepo.AssertExpectations(t)
 }
 
@@ -65,8 +179,9 @@ func TestMerchService_BuyMerch_InsufficientFunds(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockMerchRepo := new(MockMerchRepository)
 	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
 
-	service := NewMerchService(mockUserRepo, mockMerchRepo, mockUserMerchRepo)
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, newTestPricing(), newTestStore(t), testMedia, events.NewBus())
 
 	ctx := context.Background()
 	userID := int64(1)
@@ -81,14 +196,346 @@ func TestMerchService_BuyMerch_InsufficientFunds(t *testing.T) {
 
 	// Настраиваем моки
 	mockMerchRepo.On("GetByName", ctx, merchName).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, userID).Return(&models.User{ID: userID}, nil)
+	mockMerchRepo.On("DecrementStock", ctx, testMerch.ID, 1).Return(nil)
 	mockUserRepo.On("UpdateCoins", ctx, userID, -testMerch.Price).Return(errors.New("insufficient funds"))
 
 	// Вызываем тестируемый метод
-	err := service.BuyMerch(ctx, userID, merchName)
+	_, err := service.BuyMerch(ctx, userID, merchName, models.BuyOptions{Quantity: 1})
 
 	// Проверяем результаты
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to deduct coins")
 	mockMerchRepo.AssertExpectations(t)
 	mockUserRepo.AssertExpectations(t)
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMerchService_BuyMerch_OutOfStock(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	testMerch := &models.MerchItem{ID: 1, Name: "hoody", Price: 300}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1}, nil)
+	mockMerchRepo.On("DecrementStock", ctx, testMerch.ID, 1).Return(errors.New("not enough stock"))
+
+	_, err := service.BuyMerch(ctx, 1, testMerch.Name, models.BuyOptions{Quantity: 1})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not enough stock")
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMerchService_BuyMerch_Quantity(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	testMerch := &models.MerchItem{ID: 1, Name: "socks", Price: 10}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1}, nil)
+	mockMerchRepo.On("DecrementStock", ctx, testMerch.ID, 5).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), int64(-50)).Return(nil).Once()
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.AnythingOfType("*models.UserMerch")).Return(nil).Times(5)
+
+	order, err := service.BuyMerch(ctx, 1, testMerch.Name, models.BuyOptions{Quantity: 5})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), order.Total)
+	assert.Equal(t, 5, order.Items[0].Quantity)
+	mockUserRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+}
+
+func TestMerchService_BuyMerch_LimitExceeded(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	limit := 3
+	period := models.PurchaseLimitMonth
+	testMerch := &models.MerchItem{ID: 1, Name: "pink-hoody", Price: 500, PurchaseLimit: &limit, PurchaseLimitPeriod: &period}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1}, nil)
+	mockUserMerchRepo.On("CountPurchased", ctx, int64(1), testMerch.ID, mock.AnythingOfType("time.Time")).Return(2, nil)
+
+	_, err := service.BuyMerch(ctx, 1, testMerch.Name, models.BuyOptions{Quantity: 2})
+
+	var limitErr *LimitExceededError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 1, limitErr.Remaining)
+	assert.Equal(t, models.PurchaseLimitMonth, limitErr.Period)
+	mockMerchRepo.AssertNotCalled(t, "DecrementStock", mock.Anything, mock.Anything, mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMerchService_BuyMerch_Variant(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	price := int64(90)
+	testMerch := &models.MerchItem{ID: 1, Name: "t-shirt", Price: 80, HasVariants: true}
+	variant := &models.MerchVariant{ID: 7, MerchID: 1, SKU: "TSHIRT-XL", Attributes: models.Attributes{"size": "XL"}, Price: &price}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockMerchRepo.On("GetVariantBySKU", ctx, variant.SKU).Return(variant, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1}, nil)
+	mockMerchRepo.On("DecrementVariantStock", ctx, variant.ID, 1).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), -price).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.MatchedBy(func(userMerch *models.UserMerch) bool {
+		return userMerch.VariantID != nil && *userMerch.VariantID == variant.ID && userMerch.PricePaid == price
+	})).Return(nil)
+
+	order, err := service.BuyMerch(ctx, 1, testMerch.Name, models.BuyOptions{VariantSKU: variant.SKU, Quantity: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, price, order.Total)
+	mockMerchRepo.AssertNotCalled(t, "DecrementStock", mock.Anything, mock.Anything, mock.Anything)
+	mockUserMerchRepo.AssertExpectations(t)
+}
+
+func TestMerchService_BuyMerch_VariantRequired(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	testMerch := &models.MerchItem{ID: 1, Name: "t-shirt", Price: 80, HasVariants: true}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1}, nil)
+
+	_, err := service.BuyMerch(ctx, 1, testMerch.Name, models.BuyOptions{Quantity: 1})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "choose one by SKU")
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMerchService_GetAllMerch_NestsVariants(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	mockMerchRepo.On("Find", ctx, models.MerchFilter{Sort: models.MerchSortPriceAsc, Limit: defaultMerchPageSize}).Return([]models.MerchItem{
+		{ID: 1, Name: "cup", Price: 20},
+		{ID: 2, Name: "hoody", Price: 300, HasVariants: true},
+	}, 2, nil)
+	mockMerchRepo.On("GetVariants", ctx, []int64{2}).Return([]models.MerchVariant{
+		{ID: 1, MerchID: 2, SKU: "HOODY-S", Attributes: models.Attributes{"size": "S"}},
+		{ID: 2, MerchID: 2, SKU: "HOODY-M", Attributes: models.Attributes{"size": "M"}},
+	}, nil)
+	mockMerchRepo.On("GetImages", ctx, []int64{1, 2}).Return([]models.MerchImage{
+		{ID: 5, MerchID: 1, Key: "merch/1/abc.png", ThumbnailKey: "merch/1/abc_thumb.jpg"},
+	}, nil)
+
+	items, total, err := service.GetAllMerch(ctx, models.MerchFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Empty(t, items[0].Variants)
+	assert.Len(t, items[1].Variants, 2)
+	assert.Equal(t, "/media/merch/1/abc.png", items[0].Images[0].URL)
+	assert.Equal(t, "/media/merch/1/abc_thumb.jpg", items[0].Images[0].ThumbnailURL)
+	assert.Empty(t, items[1].Images)
+}
+
+func TestMerchService_ChangePrice(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	adminID := int64(42)
+	testMerch := &models.MerchItem{ID: 1, Name: "cup", Price: 20}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockMerchRepo.On("UpdatePrice", ctx, testMerch.ID, int64(25)).Return(int64(20), nil)
+	mockMerchRepo.On("AddPriceChange", ctx, mock.MatchedBy(func(change *models.PriceChange) bool {
+		return *change.OldPrice == 20 && change.NewPrice == 25 && *change.ChangedBy == adminID
+	})).Return(nil)
+
+	change, err := service.ChangePrice(ctx, adminID, testMerch.Name, 25)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25), change.NewPrice)
+	mockMerchRepo.AssertExpectations(t)
+}
+
+func TestMerchService_GetAllMerch_Filter(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	filter := models.MerchFilter{Category: "apparel", Tag: " Summer ", InStock: true, Sort: models.MerchSortNameDesc, Limit: 1000, Offset: 10}
+	expected := filter
+	expected.Tag = "summer"
+	expected.Limit = maxMerchPageSize
+
+	mockMerchRepo.On("Find", ctx, expected).Return([]models.MerchItem{}, 12, nil)
+
+	items, total, err := service.GetAllMerch(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, 12, total)
+
+	_, _, err = service.GetAllMerch(ctx, models.MerchFilter{Sort: "random"})
+	assert.Error(t, err)
+
+	_, _, err = service.GetAllMerch(ctx, models.MerchFilter{MinPrice: 100, MaxPrice: 50})
+	assert.Error(t, err)
+}
+
+func TestMerchService_UpdateMerch(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+
+	service := NewMerchService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	category := "office"
+	testMerch := &models.MerchItem{ID: 3, Name: "cup", Price: 20}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockMerchRepo.On("UpdateDetails", ctx, mock.MatchedBy(func(merch *models.MerchItem) bool {
+		return *merch.Category == category && merch.DisplayName == "Кружка" &&
+			assert.ObjectsAreEqual(pq.StringArray{"kitchen", "gift"}, merch.Tags)
+	})).Return(nil)
+
+	displayName := " Кружка "
+	description := "Керамическая кружка с логотипом"
+	merch, err := service.UpdateMerch(ctx, testMerch.Name, models.UpdateMerchRequest{
+		DisplayName: &displayName,
+		Description: &description,
+		Category:    &category,
+		Tags:        []string{" Kitchen", "gift", "kitchen", ""},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "office", *merch.Category)
+	assert.Equal(t, description, *merch.Description)
+	mockMerchRepo.AssertExpectations(t)
+
+	empty := " "
+	_, err = service.UpdateMerch(ctx, testMerch.Name, models.UpdateMerchRequest{DisplayName: &empty})
+	assert.Error(t, err)
+}
+
+func TestMerchService_UploadImage(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+	store := newTestStore(t)
+
+	service := NewMerchService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, new(MockUserMerchRepository), new(MockOrderRepository), newTestPricing(), store, testMedia, events.NewBus())
+
+	ctx := context.Background()
+	testMerch := &models.MerchItem{ID: 3, Name: "cup", Price: 20}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockMerchRepo.On("AddImage", ctx, mock.AnythingOfType("*models.MerchImage")).Return(nil)
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 32)))
+	assert.NoError(t, err)
+
+	uploaded, err := service.UploadImage(ctx, testMerch.Name, &buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", uploaded.ContentType)
+	assert.Equal(t, testMedia.URLPrefix+"/"+uploaded.ThumbnailKey, uploaded.ThumbnailURL)
+
+	// Миниатюра вписывается в заданный размер с сохранением пропорций
+	thumbnail, err := store.Open(ctx, uploaded.ThumbnailKey)
+	assert.NoError(t, err)
+	defer thumbnail.Close()
+
+	thumbnailConfig, format, err := image.DecodeConfig(thumbnail)
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 16, thumbnailConfig.Width)
+	assert.Equal(t, 8, thumbnailConfig.Height)
+
+	_, err = service.UploadImage(ctx, testMerch.Name, strings.NewReader("not an image"))
+	assert.Error(t, err)
+
+	_, err = service.UploadImage(ctx, testMerch.Name, bytes.NewReader(make([]byte, testMedia.MaxImageSize+1)))
+	assert.ErrorContains(t, err, "larger than")
+
+	// Небольшой файл с большими размерами отклоняется до декодирования
+	buf.Reset()
+	err = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 128, 64)))
+	assert.NoError(t, err)
+	_, err = service.UploadImage(ctx, testMerch.Name, &buf)
+	assert.ErrorContains(t, err, "pixels")
+}
+
+func TestMerchService_BuyMerch_Gift(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewMerchService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, newTestPricing(), newTestStore(t), testMedia, events.NewBus())
+
+	ctx := context.Background()
+	buyerID := int64(5)
+	recipient := &models.User{ID: 2, Username: "colleague"}
+	limit := 1
+	testMerch := &models.MerchItem{ID: 10, Name: "pink-hoody", Price: 500, PurchaseLimit: &limit}
+
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockUserRepo.On("GetByUsername", ctx, recipient.Username).Return(recipient, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, recipient.ID).Return(recipient, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, buyerID).Return(&models.User{ID: buyerID}, nil)
+	// Ограничение на товар проверяется для получателя
+	mockUserMerchRepo.On("CountPurchased", ctx, recipient.ID, testMerch.ID, time.Time{}).Return(0, nil)
+	mockMerchRepo.On("DecrementStock", ctx, testMerch.ID, 1).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, buyerID, int64(-500)).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.MatchedBy(func(order *models.Order) bool {
+		return order.UserID == buyerID && *order.RecipientID == recipient.ID && *order.GiftNote == "С днем рождения!"
+	})).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.MatchedBy(func(userMerch *models.UserMerch) bool {
+		return userMerch.UserID == recipient.ID && *userMerch.GivenBy == buyerID && *userMerch.GiftNote == "С днем рождения!"
+	})).Return(nil)
+
+	order, err := service.BuyMerch(ctx, buyerID, testMerch.Name, models.BuyOptions{
+		Quantity:  1,
+		Recipient: recipient.Username,
+		GiftNote:  " С днем рождения! ",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), order.Total)
+	mockUserRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+
+	// Подарить самому себе нельзя
+	mockUserRepo.On("GetByUsername", ctx, "self").Return(&models.User{ID: buyerID}, nil)
+
+	_, err = service.BuyMerch(ctx, buyerID, testMerch.Name, models.BuyOptions{Quantity: 1, Recipient: "self"})
+	assert.ErrorContains(t, err, "yourself")
 }
@@ -2,11 +2,19 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/stretchr/testify/mock"
 )
 
+// MockTransactor мок для менеджера транзакций, выполняющий функцию без транзакции
+type MockTransactor struct{}
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 // MockUserRepository мок для репозитория пользователей
 type MockUserRepository struct {
 	mock.Mock
@@ -33,11 +41,39 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*models.Use
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) UpdateCoins(ctx context.Context, userID int64, amount int64) error {
 	args := m.Called(ctx, userID, amount)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ForceUpdateCoins(ctx context.Context, userID int64, amount int64) error {
+	args := m.Called(ctx, userID, amount)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RefundCoins(ctx context.Context, userID, amount int64, spentAt time.Time) error {
+	args := m.Called(ctx, userID, amount, spentAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetTransfersOnHold(ctx context.Context, userID int64, hold bool) error {
+	args := m.Called(ctx, userID, hold)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetLeaderboardOptOut(ctx context.Context, userID int64, optOut bool) error {
+	args := m.Called(ctx, userID, optOut)
+	return args.Error(0)
+}
+
 // MockTransactionRepository мок для репозитория транзакций
 type MockTransactionRepository struct {
 	mock.Mock
@@ -53,6 +89,22 @@ func (m *MockTransactionRepository) GetUserTransactions(ctx context.Context, use
 	return args.Get(0).([]models.Transaction), args.Error(1)
 }
 
+func (m *MockTransactionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Transaction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetOutgoingStats(ctx context.Context, fromUserID, toUserID int64, since time.Time) (*models.TransferStats, error) {
+	args := m.Called(ctx, fromUserID, toUserID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransferStats), args.Error(1)
+}
+
 // MockUserMerchRepository мок для репозитория купленного мерча
 type MockUserMerchRepository struct {
 	mock.Mock
@@ -67,3 +119,715 @@ func (m *MockUserMerchRepository) GetUserMerch(ctx context.Context, userID int64
 	args := m.Called(ctx, userID)
 	return args.Get(0).([]models.UserMerch), args.Error(1)
 }
+
+func (m *MockUserMerchRepository) CancelByOrder(ctx context.Context, orderID int64) error {
+	args := m.Called(ctx, orderID)
+	return args.Error(0)
+}
+
+func (m *MockUserMerchRepository) CountPurchased(ctx context.Context, userID, merchID int64, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, merchID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserMerchRepository) GetGifts(ctx context.Context, userID int64) ([]models.Gift, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Gift), args.Error(1)
+}
+
+func (m *MockUserMerchRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.UserMerch, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserMerch), args.Error(1)
+}
+
+func (m *MockUserMerchRepository) SetStatus(ctx context.Context, id int64, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockUserMerchRepository) GetByIDsForUpdate(ctx context.Context, ids []int64) ([]models.UserMerch, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]models.UserMerch), args.Error(1)
+}
+
+func (m *MockUserMerchRepository) Transfer(ctx context.Context, ids []int64, toUserID int64) error {
+	args := m.Called(ctx, ids, toUserID)
+	return args.Error(0)
+}
+
+// MockCartRepository мок для репозитория корзины
+type MockCartRepository struct {
+	mock.Mock
+}
+
+func (m *MockCartRepository) GetItems(ctx context.Context, userID int64) ([]models.CartItem, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.CartItem), args.Error(1)
+}
+
+func (m *MockCartRepository) AddItem(ctx context.Context, userID, merchID int64, variantID *int64, quantity int) error {
+	args := m.Called(ctx, userID, merchID, variantID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) SetQuantity(ctx context.Context, userID, merchID int64, variantID *int64, quantity int) error {
+	args := m.Called(ctx, userID, merchID, variantID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) RemoveItem(ctx context.Context, userID, merchID int64, variantID *int64) error {
+	args := m.Called(ctx, userID, merchID, variantID)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) Clear(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockWishlistRepository мок для репозитория списка желаний
+type MockWishlistRepository struct {
+	mock.Mock
+}
+
+func (m *MockWishlistRepository) Add(ctx context.Context, userID, merchID int64) error {
+	args := m.Called(ctx, userID, merchID)
+	return args.Error(0)
+}
+
+func (m *MockWishlistRepository) Remove(ctx context.Context, userID, merchID int64) error {
+	args := m.Called(ctx, userID, merchID)
+	return args.Error(0)
+}
+
+func (m *MockWishlistRepository) GetItems(ctx context.Context, userID int64) ([]models.WishlistItem, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.WishlistItem), args.Error(1)
+}
+
+func (m *MockWishlistRepository) GetAll(ctx context.Context) ([]models.WishlistItem, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.WishlistItem), args.Error(1)
+}
+
+func (m *MockWishlistRepository) SyncStock(ctx context.Context) ([]models.WishlistItem, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.WishlistItem), args.Error(1)
+}
+
+func (m *MockWishlistRepository) SetNotifiedDiscount(ctx context.Context, userID, merchID int64, discountID *int64) error {
+	args := m.Called(ctx, userID, merchID, discountID)
+	return args.Error(0)
+}
+
+// MockNotificationRepository мок для репозитория уведомлений
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	args := m.Called(ctx, notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) GetUserNotifications(ctx context.Context, userID int64, unreadOnly bool) ([]models.Notification, error) {
+	args := m.Called(ctx, userID, unreadOnly)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, userID, notificationID int64) error {
+	args := m.Called(ctx, userID, notificationID)
+	return args.Error(0)
+}
+
+// MockDiscountRepository мок для репозитория скидок
+type MockDiscountRepository struct {
+	mock.Mock
+}
+
+func (m *MockDiscountRepository) Create(ctx context.Context, discount *models.Discount) error {
+	args := m.Called(ctx, discount)
+	return args.Error(0)
+}
+
+func (m *MockDiscountRepository) GetActive(ctx context.Context, at time.Time) ([]models.Discount, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]models.Discount), args.Error(1)
+}
+
+func (m *MockDiscountRepository) GetAll(ctx context.Context) ([]models.Discount, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Discount), args.Error(1)
+}
+
+// MockPromoCodeRepository мок для репозитория промокодов
+type MockPromoCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromoCodeRepository) Create(ctx context.Context, promo *models.PromoCode) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *MockPromoCodeRepository) GetAll(ctx context.Context) ([]models.PromoCode, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) CountUserRedemptions(ctx context.Context, promoCodeID, userID int64) (int, error) {
+	args := m.Called(ctx, promoCodeID, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) Redeem(ctx context.Context, promoCodeID, userID, orderID int64) error {
+	args := m.Called(ctx, promoCodeID, userID, orderID)
+	return args.Error(0)
+}
+
+// newTestPricing создает сервис цен без промокодов с заданными действующими скидками
+func newTestPricing(discounts ...models.Discount) PricingService {
+	mockDiscountRepo := new(MockDiscountRepository)
+	mockDiscountRepo.On("GetActive", mock.Anything, mock.Anything).Return(append([]models.Discount{}, discounts...), nil)
+
+	return NewPricingService(new(MockMerchRepository), mockDiscountRepo, new(MockPromoCodeRepository))
+}
+
+// MockReturnRepository мок для репозитория заявок на возврат
+type MockReturnRepository struct {
+	mock.Mock
+}
+
+func (m *MockReturnRepository) Create(ctx context.Context, ret *models.MerchReturn) error {
+	args := m.Called(ctx, ret)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.MerchReturn, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MerchReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) GetUserReturns(ctx context.Context, userID int64) ([]models.MerchReturn, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.MerchReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) GetByStatus(ctx context.Context, status string) ([]models.MerchReturn, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]models.MerchReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) Resolve(ctx context.Context, ret *models.MerchReturn) error {
+	args := m.Called(ctx, ret)
+	return args.Error(0)
+}
+
+// MockTradeRepository мок для репозитория предложений обмена
+type MockTradeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTradeRepository) Create(ctx context.Context, trade *models.Trade) error {
+	args := m.Called(ctx, trade)
+	return args.Error(0)
+}
+
+func (m *MockTradeRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Trade, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Trade), args.Error(1)
+}
+
+func (m *MockTradeRepository) GetUserTrades(ctx context.Context, userID int64) ([]models.Trade, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Trade), args.Error(1)
+}
+
+func (m *MockTradeRepository) SetStatus(ctx context.Context, id int64, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+// MockMarketRepository мок для репозитория объявлений маркетплейса
+type MockMarketRepository struct {
+	mock.Mock
+}
+
+func (m *MockMarketRepository) Create(ctx context.Context, listing *models.MarketListing) error {
+	args := m.Called(ctx, listing)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.MarketListing, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MarketListing), args.Error(1)
+}
+
+func (m *MockMarketRepository) Find(ctx context.Context, filter models.MarketFilter) ([]models.MarketListing, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.MarketListing), args.Int(1), args.Error(2)
+}
+
+func (m *MockMarketRepository) GetUserListings(ctx context.Context, sellerID int64) ([]models.MarketListing, error) {
+	args := m.Called(ctx, sellerID)
+	return args.Get(0).([]models.MarketListing), args.Error(1)
+}
+
+func (m *MockMarketRepository) Close(ctx context.Context, listing *models.MarketListing) error {
+	args := m.Called(ctx, listing)
+	return args.Error(0)
+}
+
+// MockAuctionRepository мок для репозитория аукционов
+type MockAuctionRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuctionRepository) Create(ctx context.Context, auction *models.Auction) error {
+	args := m.Called(ctx, auction)
+	return args.Error(0)
+}
+
+func (m *MockAuctionRepository) GetByID(ctx context.Context, id int64) (*models.Auction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Auction), args.Error(1)
+}
+
+func (m *MockAuctionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Auction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Auction), args.Error(1)
+}
+
+func (m *MockAuctionRepository) GetOpen(ctx context.Context) ([]models.Auction, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Auction), args.Error(1)
+}
+
+func (m *MockAuctionRepository) LockDue(ctx context.Context, limit int) ([]models.Auction, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.Auction), args.Error(1)
+}
+
+func (m *MockAuctionRepository) Close(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAuctionRepository) GetBids(ctx context.Context, auctionID int64) ([]models.AuctionBid, error) {
+	args := m.Called(ctx, auctionID)
+	return args.Get(0).([]models.AuctionBid), args.Error(1)
+}
+
+func (m *MockAuctionRepository) SaveBid(ctx context.Context, bid *models.AuctionBid) error {
+	args := m.Called(ctx, bid)
+	return args.Error(0)
+}
+
+func (m *MockAuctionRepository) SetBidStatus(ctx context.Context, bidID int64, status string) error {
+	args := m.Called(ctx, bidID, status)
+	return args.Error(0)
+}
+
+// MockHoldRepository мок для репозитория удержаний монет
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepository) Create(ctx context.Context, hold *models.CoinHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) GetByID(ctx context.Context, id int64) (*models.CoinHold, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CoinHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetActive(ctx context.Context, userID int64) ([]models.CoinHold, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.CoinHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) Capture(ctx context.Context, id int64) (*models.CoinHold, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CoinHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) Resolve(ctx context.Context, id int64, status string) (*models.CoinHold, error) {
+	args := m.Called(ctx, id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CoinHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) LockExpired(ctx context.Context, limit int) ([]models.CoinHold, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.CoinHold), args.Error(1)
+}
+
+// MockCoinLotRepository мок для репозитория партий монет
+type MockCoinLotRepository struct {
+	mock.Mock
+}
+
+func (m *MockCoinLotRepository) GetActive(ctx context.Context, userID int64) ([]models.CoinLot, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.CoinLot), args.Error(1)
+}
+
+func (m *MockCoinLotRepository) GetUsersWithExpired(ctx context.Context, grantedBefore time.Time, limit int) ([]int64, error) {
+	args := m.Called(ctx, grantedBefore, limit)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockCoinLotRepository) SumRemaining(ctx context.Context, userID int64, grantedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, userID, grantedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCoinLotRepository) MarkWarned(ctx context.Context, grantedBefore time.Time, limit int) ([]models.CoinLot, error) {
+	args := m.Called(ctx, grantedBefore, limit)
+	return args.Get(0).([]models.CoinLot), args.Error(1)
+}
+
+// MockAchievementRepository мок для репозитория достижений
+type MockAchievementRepository struct {
+	mock.Mock
+}
+
+func (m *MockAchievementRepository) GetByCode(ctx context.Context, code string) (*models.Achievement, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Achievement), args.Error(1)
+}
+
+func (m *MockAchievementRepository) Award(ctx context.Context, userID int64, code string, level int) (bool, error) {
+	args := m.Called(ctx, userID, code, level)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAchievementRepository) GetUserAchievements(ctx context.Context, userID int64) ([]models.UserAchievement, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.UserAchievement), args.Error(1)
+}
+
+func (m *MockAchievementRepository) GetDueAnniversaries(ctx context.Context, code string, limit int) ([]models.WorkAnniversary, error) {
+	args := m.Called(ctx, code, limit)
+	return args.Get(0).([]models.WorkAnniversary), args.Error(1)
+}
+
+// MockLeaderboardRepository мок для репозитория рейтингов
+type MockLeaderboardRepository struct {
+	mock.Mock
+}
+
+func (m *MockLeaderboardRepository) GetTop(ctx context.Context, board, period string, limit int) ([]models.LeaderboardEntry, error) {
+	args := m.Called(ctx, board, period, limit)
+	return args.Get(0).([]models.LeaderboardEntry), args.Error(1)
+}
+
+func (m *MockLeaderboardRepository) Refresh(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// MockTeamRepository мок для репозитория команд
+type MockTeamRepository struct {
+	mock.Mock
+}
+
+func (m *MockTeamRepository) Create(ctx context.Context, team *models.Team) error {
+	args := m.Called(ctx, team)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) GetByID(ctx context.Context, id int64) (*models.Team, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepository) GetUserTeams(ctx context.Context, userID int64) ([]models.Team, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepository) GetMembers(ctx context.Context, teamID int64) ([]models.TeamMember, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).([]models.TeamMember), args.Error(1)
+}
+
+func (m *MockTeamRepository) GetMember(ctx context.Context, teamID, userID int64) (*models.TeamMember, error) {
+	args := m.Called(ctx, teamID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TeamMember), args.Error(1)
+}
+
+func (m *MockTeamRepository) SetMember(ctx context.Context, member *models.TeamMember) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepository) RemoveMember(ctx context.Context, teamID, userID int64) error {
+	args := m.Called(ctx, teamID, userID)
+	return args.Error(0)
+}
+
+// MockRaffleRepository мок для репозитория розыгрышей
+type MockRaffleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRaffleRepository) Create(ctx context.Context, raffle *models.Raffle) error {
+	args := m.Called(ctx, raffle)
+	return args.Error(0)
+}
+
+func (m *MockRaffleRepository) GetByID(ctx context.Context, id int64) (*models.Raffle, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Raffle), args.Error(1)
+}
+
+func (m *MockRaffleRepository) GetOpen(ctx context.Context) ([]models.Raffle, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Raffle), args.Error(1)
+}
+
+func (m *MockRaffleRepository) AddEntry(ctx context.Context, entry *models.RaffleEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockRaffleRepository) GetEntries(ctx context.Context, raffleID int64) ([]models.RaffleEntry, error) {
+	args := m.Called(ctx, raffleID)
+	return args.Get(0).([]models.RaffleEntry), args.Error(1)
+}
+
+func (m *MockRaffleRepository) LockDue(ctx context.Context, limit int) ([]models.Raffle, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.Raffle), args.Error(1)
+}
+
+func (m *MockRaffleRepository) AddWinner(ctx context.Context, winner *models.RaffleWinner) error {
+	args := m.Called(ctx, winner)
+	return args.Error(0)
+}
+
+func (m *MockRaffleRepository) GetWinners(ctx context.Context, raffleID int64) ([]models.RaffleWinner, error) {
+	args := m.Called(ctx, raffleID)
+	return args.Get(0).([]models.RaffleWinner), args.Error(1)
+}
+
+func (m *MockRaffleRepository) MarkDrawn(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockOrderRepository мок для репозитория заказов
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetUserOrders(ctx context.Context, userID int64) ([]models.Order, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByID(ctx context.Context, id int64) (*models.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+// MockPaymentRequestRepository мок для репозитория запросов на перевод монет
+type MockPaymentRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRequestRepository) Create(ctx context.Context, request *models.PaymentRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRequestRepository) Resolve(ctx context.Context, id, payerID int64, status string) (*models.PaymentRequest, error) {
+	args := m.Called(ctx, id, payerID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentRequest), args.Error(1)
+}
+
+func (m *MockPaymentRequestRepository) GetIncoming(ctx context.Context, payerID int64) ([]models.PaymentRequest, error) {
+	args := m.Called(ctx, payerID)
+	return args.Get(0).([]models.PaymentRequest), args.Error(1)
+}
+
+func (m *MockPaymentRequestRepository) GetOutgoing(ctx context.Context, requesterID int64) ([]models.PaymentRequest, error) {
+	args := m.Called(ctx, requesterID)
+	return args.Get(0).([]models.PaymentRequest), args.Error(1)
+}
+
+// MockScheduledTransferRepository мок для репозитория запланированных переводов
+type MockScheduledTransferRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduledTransferRepository) Create(ctx context.Context, schedule *models.ScheduledTransfer) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTransferRepository) GetUserSchedules(ctx context.Context, userID int64) ([]models.ScheduledTransfer, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.ScheduledTransfer), args.Error(1)
+}
+
+func (m *MockScheduledTransferRepository) SetStatus(ctx context.Context, id, userID int64, fromStatuses []string, status string) error {
+	args := m.Called(ctx, id, userID, fromStatuses, status)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTransferRepository) LockDue(ctx context.Context, limit int) ([]models.ScheduledTransfer, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.ScheduledTransfer), args.Error(1)
+}
+
+func (m *MockScheduledTransferRepository) Update(ctx context.Context, schedule *models.ScheduledTransfer) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+// MockFraudRepository мок для репозитория поиска подозрительных переводов
+type MockFraudRepository struct {
+	mock.Mock
+}
+
+func (m *MockFraudRepository) FindCircularTransfers(ctx context.Context, since time.Time) ([]models.FraudSignal, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]models.FraudSignal), args.Error(1)
+}
+
+func (m *MockFraudRepository) FindNewAccountFunnels(ctx context.Context, since, accountsCreatedAfter time.Time, minSenders int) ([]models.FraudSignal, error) {
+	args := m.Called(ctx, since, accountsCreatedAfter, minSenders)
+	return args.Get(0).([]models.FraudSignal), args.Error(1)
+}
+
+func (m *MockFraudRepository) FindBursts(ctx context.Context, since, baselineSince time.Time, minTransfers int) ([]models.FraudSignal, error) {
+	args := m.Called(ctx, since, baselineSince, minTransfers)
+	return args.Get(0).([]models.FraudSignal), args.Error(1)
+}
+
+// MockAlertRepository мок для репозитория оповещений
+type MockAlertRepository struct {
+	mock.Mock
+}
+
+func (m *MockAlertRepository) Create(ctx context.Context, alert *models.Alert) (bool, error) {
+	args := m.Called(ctx, alert)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAlertRepository) GetByStatus(ctx context.Context, status string) ([]models.Alert, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]models.Alert), args.Error(1)
+}
+
+func (m *MockAlertRepository) Review(ctx context.Context, id, reviewerID int64, status string) (*models.Alert, error) {
+	args := m.Called(ctx, id, reviewerID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Alert), args.Error(1)
+}
+
+// MockDisputeRepository мок для репозитория споров
+type MockDisputeRepository struct {
+	mock.Mock
+}
+
+func (m *MockDisputeRepository) Create(ctx context.Context, dispute *models.Dispute) error {
+	args := m.Called(ctx, dispute)
+	return args.Error(0)
+}
+
+func (m *MockDisputeRepository) GetUserDisputes(ctx context.Context, userID int64) ([]models.Dispute, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) GetByStatus(ctx context.Context, status string) ([]models.Dispute, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]models.Dispute), args.Error(1)
+}
+
+func (m *MockDisputeRepository) Resolve(ctx context.Context, id, adminID int64, status, resolution string) error {
+	args := m.Called(ctx, id, adminID, status, resolution)
+	return args.Error(0)
+}
+
+func (m *MockDisputeRepository) ResolveByTransaction(ctx context.Context, transactionID, adminID int64, status, resolution string) error {
+	args := m.Called(ctx, transactionID, adminID, status, resolution)
+	return args.Error(0)
+}
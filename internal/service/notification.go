@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type notificationServiceImpl struct {
+	notificationRepo repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
+	return &notificationServiceImpl{
+		notificationRepo: notificationRepo,
+	}
+}
+
+func (s *notificationServiceImpl) GetNotifications(ctx context.Context, userID int64, unreadOnly bool) ([]models.Notification, error) {
+	return s.notificationRepo.GetUserNotifications(ctx, userID, unreadOnly)
+}
+
+func (s *notificationServiceImpl) MarkRead(ctx context.Context, userID, notificationID int64) error {
+	return s.notificationRepo.MarkRead(ctx, userID, notificationID)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// orderTransitions описывает допустимые переходы между статусами заказа.
+// Отменить заказ можно, пока он не передан в доставку
+var orderTransitions = map[string][]string{
+	models.OrderPlaced:         {models.OrderConfirmed, models.OrderCancelled},
+	models.OrderConfirmed:      {models.OrderReadyForPickup, models.OrderShipped, models.OrderCancelled},
+	models.OrderReadyForPickup: {models.OrderDelivered, models.OrderCancelled},
+	models.OrderShipped:        {models.OrderDelivered},
+}
+
+type orderServiceImpl struct {
+	transactor    repository.Transactor
+	userRepo      repository.UserRepository
+	merchRepo     repository.MerchRepository
+	userMerchRepo repository.UserMerchRepository
+	orderRepo     repository.OrderRepository
+	publisher     events.Publisher
+}
+
+func NewOrderService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, publisher events.Publisher) OrderService {
+	return &orderServiceImpl{
+		transactor:    transactor,
+		userRepo:      userRepo,
+		merchRepo:     merchRepo,
+		userMerchRepo: userMerchRepo,
+		orderRepo:     orderRepo,
+		publisher:     publisher,
+	}
+}
+
+func (s *orderServiceImpl) GetUserOrders(ctx context.Context, userID int64) ([]models.Order, error) {
+	return s.orderRepo.GetUserOrders(ctx, userID)
+}
+
+func (s *orderServiceImpl) GetOrder(ctx context.Context, userID, orderID int64) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+
+	return order, nil
+}
+
+func (s *orderServiceImpl) CancelOrder(ctx context.Context, userID, orderID int64) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := s.orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if order.UserID != userID {
+			return errors.New("order not found")
+		}
+
+		return s.changeStatus(ctx, order, models.OrderCancelled)
+	})
+}
+
+func (s *orderServiceImpl) UpdateStatus(ctx context.Context, orderID int64, status string) (*models.Order, error) {
+	var order *models.Order
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = s.orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		return s.changeStatus(ctx, order, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == models.OrderDelivered {
+		s.publisher.Publish(ctx, events.OrderDelivered{UserID: order.UserID, OrderID: order.ID})
+	}
+	return order, nil
+}
+
+// changeStatus проверяет переход и меняет статус заказа. При отмене монеты
+// возвращаются покупателю, товар возвращается на склад, а мерч из заказа помечается отмененным.
+// Должен вызываться внутри транзакции с заблокированным заказом
+func (s *orderServiceImpl) changeStatus(ctx context.Context, order *models.Order, status string) error {
+	if !canTransition(order.Status, status) {
+		return fmt.Errorf("cannot change order status from %s to %s", order.Status, status)
+	}
+
+	if status == models.OrderCancelled {
+		if err := s.userRepo.RefundCoins(ctx, order.UserID, order.Total, order.CreatedAt); err != nil {
+			return fmt.Errorf("failed to refund coins: %w", err)
+		}
+
+		if err := s.userMerchRepo.CancelByOrder(ctx, order.ID); err != nil {
+			return fmt.Errorf("failed to cancel purchased merch: %w", err)
+		}
+
+		for _, item := range order.Items {
+			var err error
+			if item.VariantID != nil {
+				err = s.merchRepo.RestockVariant(ctx, *item.VariantID, item.Quantity)
+			} else {
+				err = s.merchRepo.Restock(ctx, item.MerchID, item.Quantity)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restock merch: %w", err)
+			}
+		}
+	}
+
+	if err := s.orderRepo.UpdateStatus(ctx, order.ID, status); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	order.Status = status
+
+	return nil
+}
+
+func canTransition(from, to string) bool {
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
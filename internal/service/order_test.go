@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewOrderService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, events.NewBus())
+
+	ctx := context.Background()
+	order := &models.Order{
+		ID:     5,
+		UserID: 1,
+		Status: models.OrderConfirmed,
+		Total:  80,
+		Items:  []models.OrderItem{{MerchID: 3, Quantity: 2, Price: 40}},
+	}
+
+	// Настраиваем моки
+	mockOrderRepo.On("GetByIDForUpdate", ctx, order.ID).Return(order, nil)
+	mockUserRepo.On("RefundCoins", ctx, order.UserID, int64(80), order.CreatedAt).Return(nil)
+	mockUserMerchRepo.On("CancelByOrder", ctx, order.ID).Return(nil)
+	mockMerchRepo.On("Restock", ctx, int64(3), 2).Return(nil)
+	mockOrderRepo.On("UpdateStatus", ctx, order.ID, models.OrderCancelled).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.CancelOrder(ctx, order.UserID, order.ID)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockOrderRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockMerchRepo.AssertExpectations(t)
+}
+
+func TestOrderService_CancelOrder_AlreadyShipped(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewOrderService(new(MockTransactor), mockUserRepo, new(MockMerchRepository), new(MockUserMerchRepository), mockOrderRepo, events.NewBus())
+
+	ctx := context.Background()
+	order := &models.Order{ID: 5, UserID: 1, Status: models.OrderShipped, Total: 80}
+
+	// Настраиваем моки
+	mockOrderRepo.On("GetByIDForUpdate", ctx, order.ID).Return(order, nil)
+
+	// Вызываем тестируемый метод
+	err := service.CancelOrder(ctx, order.UserID, order.ID)
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot change order status")
+	mockUserRepo.AssertNotCalled(t, "RefundCoins", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderService_CancelOrder_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewOrderService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), new(MockUserMerchRepository), mockOrderRepo, events.NewBus())
+
+	ctx := context.Background()
+	order := &models.Order{ID: 5, UserID: 1, Status: models.OrderPlaced, Total: 80}
+
+	// Настраиваем моки
+	mockOrderRepo.On("GetByIDForUpdate", ctx, order.ID).Return(order, nil)
+
+	// Вызываем тестируемый метод
+	err := service.CancelOrder(ctx, 2, order.ID)
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "order not found")
+	mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderService_UpdateStatus(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewOrderService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), new(MockUserMerchRepository), mockOrderRepo, events.NewBus())
+
+	ctx := context.Background()
+	order := &models.Order{ID: 5, UserID: 1, Status: models.OrderConfirmed, Total: 80}
+
+	// Настраиваем моки
+	mockOrderRepo.On("GetByIDForUpdate", ctx, order.ID).Return(order, nil)
+	mockOrderRepo.On("UpdateStatus", ctx, order.ID, models.OrderShipped).Return(nil)
+
+	// Вызываем тестируемый метод
+	updated, err := service.UpdateStatus(ctx, order.ID, models.OrderShipped)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, models.OrderShipped, updated.Status)
+
+	_, err = service.UpdateStatus(ctx, order.ID, models.OrderPlaced)
+	assert.Error(t, err)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestOrderService_UpdateStatus_DeliveredPublishesEvent(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+
+	bus := events.NewBus()
+	var delivered []events.OrderDelivered
+	bus.Subscribe(events.OrderDeliveredEvent, func(ctx context.Context, event events.Event) error {
+		delivered = append(delivered, event.(events.OrderDelivered))
+		return nil
+	})
+	service := NewOrderService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), new(MockUserMerchRepository), mockOrderRepo, bus)
+
+	ctx := context.Background()
+	order := &models.Order{ID: 5, UserID: 1, Status: models.OrderShipped, Total: 80}
+
+	// Настраиваем моки
+	mockOrderRepo.On("GetByIDForUpdate", ctx, order.ID).Return(order, nil)
+	mockOrderRepo.On("UpdateStatus", ctx, order.ID, models.OrderDelivered).Return(nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.UpdateStatus(ctx, order.ID, models.OrderDelivered)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, []events.OrderDelivered{{UserID: 1, OrderID: 5}}, delivered)
+	mockOrderRepo.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type paymentRequestServiceImpl struct {
+	transactor  repository.Transactor
+	userRepo    repository.UserRepository
+	requestRepo repository.PaymentRequestRepository
+	userService UserService
+	ttl         time.Duration
+}
+
+func NewPaymentRequestService(transactor repository.Transactor, userRepo repository.UserRepository, requestRepo repository.PaymentRequestRepository, userService UserService, ttl time.Duration) PaymentRequestService {
+	return &paymentRequestServiceImpl{
+		transactor:  transactor,
+		userRepo:    userRepo,
+		requestRepo: requestRepo,
+		userService: userService,
+		ttl:         ttl,
+	}
+}
+
+func (s *paymentRequestServiceImpl) CreateRequest(ctx context.Context, requesterID int64, payerUsername string, amount int64, comment string) (*models.PaymentRequest, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	// Получаем пользователя, у которого запрашиваются монеты
+	payer, err := s.userRepo.GetByUsername(ctx, payerUsername)
+	if err != nil {
+		return nil, fmt.Errorf("payer not found: %w", err)
+	}
+
+	if payer.ID == requesterID {
+		return nil, errors.New("cannot request coins from yourself")
+	}
+
+	request := &models.PaymentRequest{
+		RequesterID: requesterID,
+		PayerID:     payer.ID,
+		Payer:       payer.Username,
+		Amount:      amount,
+		Comment:     comment,
+		Status:      models.PaymentRequestPending,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+
+	err = s.requestRepo.Create(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (s *paymentRequestServiceImpl) Approve(ctx context.Context, payerID, requestID int64) error {
+	// Смена статуса и перевод монет выполняются в одной транзакции,
+	// поэтому при нехватке средств запрос остается ожидающим
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		request, err := s.requestRepo.Resolve(ctx, requestID, payerID, models.PaymentRequestApproved)
+		if err != nil {
+			return fmt.Errorf("failed to approve payment request: %w", err)
+		}
+
+		return s.userService.SendCoins(ctx, payerID, request.Requester, request.Amount)
+	})
+}
+
+func (s *paymentRequestServiceImpl) Decline(ctx context.Context, payerID, requestID int64) error {
+	_, err := s.requestRepo.Resolve(ctx, requestID, payerID, models.PaymentRequestDeclined)
+	if err != nil {
+		return fmt.Errorf("failed to decline payment request: %w", err)
+	}
+
+	return nil
+}
+
+func (s *paymentRequestServiceImpl) GetIncoming(ctx context.Context, userID int64) ([]models.PaymentRequest, error) {
+	return s.requestRepo.GetIncoming(ctx, userID)
+}
+
+func (s *paymentRequestServiceImpl) GetOutgoing(ctx context.Context, userID int64) ([]models.PaymentRequest, error) {
+	return s.requestRepo.GetOutgoing(ctx, userID)
+}
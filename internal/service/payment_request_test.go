@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPaymentRequestService_CreateRequest(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRequestRepo := new(MockPaymentRequestRepository)
+
+	service := NewPaymentRequestService(new(MockTransactor), mockUserRepo, mockRequestRepo, nil, time.Hour)
+
+	ctx := context.Background()
+	requesterID := int64(1)
+	payer := &models.User{ID: 2, Username: "payer"}
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByUsername", ctx, payer.Username).Return(payer, nil)
+	mockRequestRepo.On("Create", ctx, mock.AnythingOfType("*models.PaymentRequest")).Return(nil)
+
+	// Вызываем тестируемый метод
+	request, err := service.CreateRequest(ctx, requesterID, payer.Username, 50, "lunch")
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, models.PaymentRequestPending, request.Status)
+	assert.Equal(t, payer.ID, request.PayerID)
+	assert.True(t, request.ExpiresAt.After(time.Now()))
+	mockUserRepo.AssertExpectations(t)
+	mockRequestRepo.AssertExpectations(t)
+}
+
+func TestPaymentRequestService_CreateRequest_Self(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRequestRepo := new(MockPaymentRequestRepository)
+
+	service := NewPaymentRequestService(new(MockTransactor), mockUserRepo, mockRequestRepo, nil, time.Hour)
+
+	ctx := context.Background()
+	user := &models.User{ID: 1, Username: "self"}
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByUsername", ctx, user.Username).Return(user, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.CreateRequest(ctx, user.ID, user.Username, 50, "")
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	mockRequestRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPaymentRequestService_Approve(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockRequestRepo := new(MockPaymentRequestRepository)
+
+	transactor := new(MockTransactor)
+	userService := NewUserService(transactor, mockUserRepo, mockTransactionRepo, new(MockUserMerchRepository), NewTransferPolicy(mockTransactionRepo, config.TransferLimits{}), noCoinExpiry(), events.NewBus())
+	service := NewPaymentRequestService(transactor, mockUserRepo, mockRequestRepo, userService, time.Hour)
+
+	ctx := context.Background()
+	payerID := int64(2)
+	requester := &models.User{ID: 1, Username: "requester"}
+	request := &models.PaymentRequest{
+		ID:          10,
+		RequesterID: requester.ID,
+		Requester:   requester.Username,
+		PayerID:     payerID,
+		Amount:      50,
+		Status:      models.PaymentRequestApproved,
+	}
+
+	// Настраиваем моки
+	mockRequestRepo.On("Resolve", ctx, request.ID, payerID, models.PaymentRequestApproved).Return(request, nil)
+	mockUserRepo.On("GetByUsername", ctx, requester.Username).Return(requester, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, payerID).Return(&models.User{ID: payerID, Username: "payer"}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, payerID, -request.Amount).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, requester.ID, request.Amount).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.AnythingOfType("*models.Transaction")).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.Approve(ctx, payerID, request.ID)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockRequestRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestPaymentRequestService_Approve_AlreadyResolved(t *testing.T) {
+	mockRequestRepo := new(MockPaymentRequestRepository)
+
+	service := NewPaymentRequestService(new(MockTransactor), new(MockUserRepository), mockRequestRepo, nil, time.Hour)
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockRequestRepo.On("Resolve", ctx, int64(10), int64(2), models.PaymentRequestApproved).
+		Return(nil, errors.New("payment request not found or already resolved"))
+
+	// Вызываем тестируемый метод
+	err := service.Approve(ctx, 2, 10)
+
+	// Проверяем результаты
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to approve payment request")
+	mockRequestRepo.AssertExpectations(t)
+}
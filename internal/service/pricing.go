@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type pricingServiceImpl struct {
+	merchRepo    repository.MerchRepository
+	discountRepo repository.DiscountRepository
+	promoRepo    repository.PromoCodeRepository
+}
+
+func NewPricingService(merchRepo repository.MerchRepository, discountRepo repository.DiscountRepository, promoRepo repository.PromoCodeRepository) PricingService {
+	return &pricingServiceImpl{
+		merchRepo:    merchRepo,
+		discountRepo: discountRepo,
+		promoRepo:    promoRepo,
+	}
+}
+
+// Apply рассчитывает цены позиций заказа: к базовой цене применяется наибольшая из действующих скидок,
+// затем промокод. Промокод блокируется до конца транзакции, поэтому Apply
+// должен вызываться внутри транзакции оформления заказа
+func (s *pricingServiceImpl) Apply(ctx context.Context, userID int64, order *models.Order, promoCode string) error {
+	now := time.Now()
+
+	discounts, err := s.discountRepo.GetActive(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to get active discounts: %w", err)
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.BasePrice = item.Price
+		item.Discount, item.DiscountID = bestDiscount(discounts, item)
+		item.Price = item.BasePrice - item.Discount
+	}
+
+	if promoCode != "" {
+		if err := s.applyPromo(ctx, userID, order, promoCode, now); err != nil {
+			return err
+		}
+	}
+
+	order.Total = 0
+	for _, item := range order.Items {
+		order.Total += item.Price * int64(item.Quantity)
+	}
+
+	return nil
+}
+
+// applyPromo проверяет ограничения промокода и применяет его к подходящим позициям
+func (s *pricingServiceImpl) applyPromo(ctx context.Context, userID int64, order *models.Order, code string, now time.Time) error {
+	promo, err := s.promoRepo.GetByCodeForUpdate(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	if promo.StartsAt != nil && now.Before(*promo.StartsAt) || promo.EndsAt != nil && !now.Before(*promo.EndsAt) {
+		return errors.New("promo code is not active")
+	}
+	if promo.MaxUses != nil && promo.Used >= *promo.MaxUses {
+		return errors.New("promo code usage limit reached")
+	}
+	if promo.MaxUsesPerUser != nil {
+		used, err := s.promoRepo.CountUserRedemptions(ctx, promo.ID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to count promo code usage: %w", err)
+		}
+		if used >= *promo.MaxUsesPerUser {
+			return errors.New("promo code already used the maximum number of times")
+		}
+	}
+
+	applied := false
+	for i := range order.Items {
+		item := &order.Items[i]
+		if !appliesTo(promo.MerchID, promo.Category, item) {
+			continue
+		}
+
+		off := reduction(promo.Percent, promo.Amount, item.Price)
+		item.Discount += off
+		item.Price -= off
+		applied = true
+	}
+	if !applied {
+		return errors.New("promo code does not apply to this order")
+	}
+
+	order.PromoCodeID = &promo.ID
+	return nil
+}
+
+// Redeem записывает использование промокода, примененного к созданному заказу
+func (s *pricingServiceImpl) Redeem(ctx context.Context, order *models.Order) error {
+	if order.PromoCodeID == nil {
+		return nil
+	}
+
+	return s.promoRepo.Redeem(ctx, *order.PromoCodeID, order.UserID, order.ID)
+}
+
+func (s *pricingServiceImpl) CreateDiscount(ctx context.Context, input models.CreateDiscountRequest) (*models.Discount, error) {
+	if !input.EndsAt.After(input.StartsAt) {
+		return nil, errors.New("discount must end after it starts")
+	}
+
+	discount := &models.Discount{
+		Name:     input.Name,
+		StartsAt: input.StartsAt,
+		EndsAt:   input.EndsAt,
+	}
+
+	var err error
+	discount.Percent, discount.Amount, err = discountValue(input.Percent, input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	discount.MerchID, discount.Category, err = s.scope(ctx, input.Item, input.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.discountRepo.Create(ctx, discount); err != nil {
+		return nil, fmt.Errorf("failed to create discount: %w", err)
+	}
+
+	return discount, nil
+}
+
+func (s *pricingServiceImpl) GetDiscounts(ctx context.Context) ([]models.Discount, error) {
+	return s.discountRepo.GetAll(ctx)
+}
+
+func (s *pricingServiceImpl) CreatePromoCode(ctx context.Context, input models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		return nil, errors.New("promo code must end after it starts")
+	}
+
+	promo := &models.PromoCode{
+		Code:     strings.TrimSpace(input.Code),
+		StartsAt: input.StartsAt,
+		EndsAt:   input.EndsAt,
+	}
+	if promo.Code == "" {
+		return nil, errors.New("promo code is required")
+	}
+	if input.MaxUses > 0 {
+		promo.MaxUses = &input.MaxUses
+	}
+	if input.MaxUsesPerUser > 0 {
+		promo.MaxUsesPerUser = &input.MaxUsesPerUser
+	}
+
+	var err error
+	promo.Percent, promo.Amount, err = discountValue(input.Percent, input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	promo.MerchID, promo.Category, err = s.scope(ctx, input.Item, input.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.promoRepo.Create(ctx, promo); err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return promo, nil
+}
+
+func (s *pricingServiceImpl) GetPromoCodes(ctx context.Context) ([]models.PromoCode, error) {
+	return s.promoRepo.GetAll(ctx)
+}
+
+// scope определяет, на что действует скидка: на товар, на категорию или на весь каталог
+func (s *pricingServiceImpl) scope(ctx context.Context, itemName, category string) (*int64, *string, error) {
+	if itemName != "" && category != "" {
+		return nil, nil, errors.New("specify either an item or a category, not both")
+	}
+
+	if itemName != "" {
+		merch, err := s.merchRepo.GetByName(ctx, itemName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("merch not found: %w", err)
+		}
+		return &merch.ID, nil, nil
+	}
+
+	if category != "" {
+		return nil, &category, nil
+	}
+
+	return nil, nil, nil
+}
+
+// discountValue проверяет, что задан ровно один из процента и фиксированной суммы
+func discountValue(percent int, amount int64) (*int, *int64, error) {
+	switch {
+	case percent > 0 && amount > 0:
+		return nil, nil, errors.New("specify either a percent or an amount, not both")
+	case percent > 0:
+		return &percent, nil, nil
+	case amount > 0:
+		return nil, &amount, nil
+	default:
+		return nil, nil, errors.New("percent or amount is required")
+	}
+}
+
+// bestDiscount выбирает из действующих скидок наибольшую для позиции по ее базовой цене
+func bestDiscount(discounts []models.Discount, item *models.OrderItem) (int64, *int64) {
+	var (
+		best int64
+		id   *int64
+	)
+
+	for i := range discounts {
+		discount := &discounts[i]
+		if !appliesTo(discount.MerchID, discount.Category, item) {
+			continue
+		}
+
+		off := reduction(discount.Percent, discount.Amount, item.BasePrice)
+		if off > best {
+			best = off
+			id = &discount.ID
+		}
+	}
+
+	return best, id
+}
+
+// appliesTo проверяет, действует ли скидка с заданной областью на позицию заказа
+func appliesTo(merchID *int64, category *string, item *models.OrderItem) bool {
+	if merchID != nil {
+		return *merchID == item.MerchID
+	}
+	if category != nil {
+		return *category == item.Category
+	}
+	return true
+}
+
+// reduction возвращает размер скидки на единицу товара, не превышающий его цену
+func reduction(percent *int, amount *int64, price int64) int64 {
+	var off int64
+	if percent != nil {
+		off = price * int64(*percent) / 100
+	} else if amount != nil {
+		off = *amount
+	}
+
+	if off > price {
+		return price
+	}
+	return off
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPricingService_Apply_BestDiscount(t *testing.T) {
+	merchID := int64(1)
+	category := "apparel"
+	percent := 10
+	amount := int64(50)
+
+	service := newTestPricing(
+		models.Discount{ID: 1, Category: &category, Percent: &percent},
+		models.Discount{ID: 2, MerchID: &merchID, Amount: &amount},
+	)
+
+	order := &models.Order{Items: []models.OrderItem{
+		{MerchID: 1, Category: category, Quantity: 2, Price: 300},
+		{MerchID: 2, Category: category, Quantity: 1, Price: 100},
+		{MerchID: 3, Quantity: 1, Price: 20},
+	}}
+
+	err := service.Apply(context.Background(), 1, order, "")
+
+	assert.NoError(t, err)
+	// Для первой позиции фиксированная скидка 50 больше 10%
+	assert.Equal(t, int64(300), order.Items[0].BasePrice)
+	assert.Equal(t, int64(50), order.Items[0].Discount)
+	assert.Equal(t, int64(2), *order.Items[0].DiscountID)
+	assert.Equal(t, int64(90), order.Items[1].Price)
+	assert.Nil(t, order.Items[2].DiscountID)
+	assert.Equal(t, int64(250*2+90+20), order.Total)
+}
+
+func TestPricingService_Apply_PromoCode(t *testing.T) {
+	mockDiscountRepo := new(MockDiscountRepository)
+	mockPromoRepo := new(MockPromoCodeRepository)
+
+	service := NewPricingService(new(MockMerchRepository), mockDiscountRepo, mockPromoRepo)
+
+	ctx := context.Background()
+	percent := 20
+	perUser := 1
+	promo := &models.PromoCode{ID: 3, Code: "SPRING", Percent: &percent, MaxUsesPerUser: &perUser}
+
+	mockDiscountRepo.On("GetActive", ctx, mock.AnythingOfType("time.Time")).Return([]models.Discount{}, nil)
+	mockPromoRepo.On("GetByCodeForUpdate", ctx, promo.Code).Return(promo, nil)
+	mockPromoRepo.On("CountUserRedemptions", ctx, promo.ID, int64(1)).Return(0, nil)
+
+	order := &models.Order{UserID: 1, Items: []models.OrderItem{{MerchID: 1, Quantity: 1, Price: 500}}}
+
+	err := service.Apply(ctx, 1, order, promo.Code)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(400), order.Total)
+	assert.Equal(t, int64(100), order.Items[0].Discount)
+	assert.Equal(t, promo.ID, *order.PromoCodeID)
+
+	order.ID = 10
+	mockPromoRepo.On("Redeem", ctx, promo.ID, int64(1), order.ID).Return(nil)
+	assert.NoError(t, service.Redeem(ctx, order))
+	mockPromoRepo.AssertExpectations(t)
+}
+
+func TestPricingService_Apply_PromoCodeLimits(t *testing.T) {
+	mockDiscountRepo := new(MockDiscountRepository)
+	mockPromoRepo := new(MockPromoCodeRepository)
+
+	service := NewPricingService(new(MockMerchRepository), mockDiscountRepo, mockPromoRepo)
+
+	ctx := context.Background()
+	amount := int64(10)
+	maxUses := 5
+	perUser := 1
+	expired := time.Now().Add(-time.Hour)
+	otherMerch := int64(9)
+
+	mockDiscountRepo.On("GetActive", ctx, mock.AnythingOfType("time.Time")).Return([]models.Discount{}, nil)
+	mockPromoRepo.On("GetByCodeForUpdate", ctx, "USED_UP").Return(&models.PromoCode{ID: 1, Amount: &amount, MaxUses: &maxUses, Used: 5}, nil)
+	mockPromoRepo.On("GetByCodeForUpdate", ctx, "ONCE").Return(&models.PromoCode{ID: 2, Amount: &amount, MaxUsesPerUser: &perUser}, nil)
+	mockPromoRepo.On("CountUserRedemptions", ctx, int64(2), int64(1)).Return(1, nil)
+	mockPromoRepo.On("GetByCodeForUpdate", ctx, "OLD").Return(&models.PromoCode{ID: 3, Amount: &amount, EndsAt: &expired}, nil)
+	mockPromoRepo.On("GetByCodeForUpdate", ctx, "OTHER").Return(&models.PromoCode{ID: 4, Amount: &amount, MerchID: &otherMerch}, nil)
+
+	cases := map[string]string{
+		"USED_UP": "usage limit reached",
+		"ONCE":    "maximum number of times",
+		"OLD":     "not active",
+		"OTHER":   "does not apply",
+	}
+
+	for code, message := range cases {
+		order := &models.Order{UserID: 1, Items: []models.OrderItem{{MerchID: 1, Quantity: 1, Price: 100}}}
+
+		err := service.Apply(ctx, 1, order, code)
+
+		assert.Error(t, err, code)
+		assert.Contains(t, err.Error(), message, code)
+	}
+}
+
+func TestPricingService_CreateDiscount_Validation(t *testing.T) {
+	service := NewPricingService(new(MockMerchRepository), new(MockDiscountRepository), new(MockPromoCodeRepository))
+
+	now := time.Now()
+
+	_, err := service.CreateDiscount(context.Background(), models.CreateDiscountRequest{
+		Name: "sale", Percent: 10, Amount: 10, StartsAt: now, EndsAt: now.Add(time.Hour),
+	})
+	assert.Error(t, err)
+
+	_, err = service.CreateDiscount(context.Background(), models.CreateDiscountRequest{
+		Name: "sale", Percent: 10, StartsAt: now, EndsAt: now.Add(-time.Hour),
+	})
+	assert.Error(t, err)
+}
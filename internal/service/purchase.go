@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// LimitExceededError возвращается, когда покупка превышает ограничение на товар
+type LimitExceededError struct {
+	MerchName string
+	Limit     int
+	Period    string
+	Remaining int
+}
+
+func (e *LimitExceededError) Error() string {
+	period := "in total"
+	if e.Period != "" {
+		period = "per " + e.Period
+	}
+	return fmt.Sprintf("purchase limit for %s is %d %s, you may buy %d more", e.MerchName, e.Limit, period, e.Remaining)
+}
+
+// purchaseLine представляет товар, его вариант и количество в оформляемом заказе
+type purchaseLine struct {
+	merch    *models.MerchItem
+	variant  *models.MerchVariant
+	quantity int
+}
+
+// price возвращает цену единицы с учетом цены варианта
+func (l purchaseLine) price() int64 {
+	if l.variant != nil && l.variant.Price != nil {
+		return *l.variant.Price
+	}
+	return l.merch.Price
+}
+
+// gift описывает получателя покупки в подарок и записку к подарку
+type gift struct {
+	recipientID int64
+	note        *string
+}
+
+// purchaser оформляет покупку нескольких позиций одним заказом.
+// Используется и для покупки одного товара, и для оформления корзины,
+// поэтому все проверки покупки применяются к заказу целиком
+type purchaser struct {
+	userRepo      repository.UserRepository
+	merchRepo     repository.MerchRepository
+	userMerchRepo repository.UserMerchRepository
+	orderRepo     repository.OrderRepository
+	pricing       PricingEngine
+}
+
+// placeOrder проверяет ограничения на товары, списывает товар со склада, рассчитывает цены
+// со скидками и промокодом, списывает монеты у пользователя, создает заказ и записи о купленном мерче.
+// Если задан gift, мерч получает указанный пользователь, а ограничения на товар проверяются для него.
+// Должен вызываться внутри транзакции, в которой заблокированы покупатель и получатель,
+// иначе параллельные покупки могут обойти ограничения
+func (p *purchaser) placeOrder(ctx context.Context, userID int64, lines []purchaseLine, promoCode string, gift *gift) (*models.Order, error) {
+	if len(lines) == 0 {
+		return nil, errors.New("nothing to purchase")
+	}
+
+	order := &models.Order{
+		UserID: userID,
+		Status: models.OrderPlaced,
+		Items:  make([]models.OrderItem, 0, len(lines)),
+	}
+
+	// Владелец мерча — покупатель или получатель подарка
+	ownerID := userID
+	if gift != nil {
+		if gift.recipientID == userID {
+			return nil, errors.New("cannot gift merch to yourself")
+		}
+		ownerID = gift.recipientID
+		order.RecipientID = &gift.recipientID
+		order.GiftNote = gift.note
+	}
+
+	for _, line := range lines {
+		if line.quantity <= 0 {
+			return nil, errors.New("quantity must be positive")
+		}
+
+		if err := checkPurchaseLimit(ctx, p.userMerchRepo, ownerID, line); err != nil {
+			return nil, err
+		}
+
+		item := models.OrderItem{
+			MerchID:   line.merch.ID,
+			MerchName: line.merch.Name,
+			Quantity:  line.quantity,
+			Price:     line.price(),
+		}
+		if line.merch.Category != nil {
+			item.Category = *line.merch.Category
+		}
+
+		// Остаток товара с вариантами учитывается по каждому варианту
+		var err error
+		switch {
+		case line.variant != nil:
+			if line.variant.MerchID != line.merch.ID {
+				return nil, fmt.Errorf("variant %s does not belong to %s", line.variant.SKU, line.merch.Name)
+			}
+			item.VariantID = &line.variant.ID
+			item.Attributes = line.variant.Attributes
+			err = p.merchRepo.DecrementVariantStock(ctx, line.variant.ID, line.quantity)
+		case line.merch.HasVariants:
+			return nil, fmt.Errorf("%s comes in several variants, choose one by SKU", line.merch.Name)
+		default:
+			err = p.merchRepo.DecrementStock(ctx, line.merch.ID, line.quantity)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot buy %d x %s: %w", line.quantity, line.merch.Name, err)
+		}
+
+		order.Items = append(order.Items, item)
+	}
+
+	// Рассчитываем итоговые цены, списанная сумма сохраняется в позициях заказа
+	if err := p.pricing.Apply(ctx, userID, order, promoCode); err != nil {
+		return nil, err
+	}
+
+	// Списываем монеты у пользователя за весь заказ
+	err := p.userRepo.UpdateCoins(ctx, userID, -order.Total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deduct coins: %w", err)
+	}
+
+	err = p.orderRepo.Create(ctx, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	if err := p.pricing.Redeem(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to redeem promo code: %w", err)
+	}
+
+	// Каждая купленная единица товара хранится отдельной записью
+	for _, item := range order.Items {
+		for i := 0; i < item.Quantity; i++ {
+			userMerch := &models.UserMerch{
+				UserID:    ownerID,
+				MerchID:   item.MerchID,
+				VariantID: item.VariantID,
+				PricePaid: item.Price,
+				OrderID:   &order.ID,
+			}
+			if gift != nil {
+				userMerch.GivenBy = &userID
+				userMerch.GiftNote = gift.note
+			}
+
+			if err := p.userMerchRepo.Create(ctx, userMerch); err != nil {
+				return nil, fmt.Errorf("failed to record purchase: %w", err)
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// lockUsers блокирует пользователей до конца транзакции в порядке возрастания идентификаторов,
+// чтобы встречные покупки и обмены не приводили к взаимной блокировке
+func lockUsers(ctx context.Context, userRepo repository.UserRepository, userIDs ...int64) (map[int64]*models.User, error) {
+	ids := append([]int64(nil), userIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	users := make(map[int64]*models.User, len(ids))
+	for _, id := range ids {
+		if _, ok := users[id]; ok {
+			continue
+		}
+		user, err := userRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		users[id] = user
+	}
+
+	return users, nil
+}
+
+// checkPurchaseLimit проверяет, что покупка не превышает ограничение на товар с учетом прошлых покупок
+func checkPurchaseLimit(ctx context.Context, userMerchRepo repository.UserMerchRepository, userID int64, line purchaseLine) error {
+	if line.merch.PurchaseLimit == nil {
+		return nil
+	}
+	limit := *line.merch.PurchaseLimit
+
+	period := ""
+	since := time.Time{}
+	if line.merch.PurchaseLimitPeriod != nil {
+		period = *line.merch.PurchaseLimitPeriod
+		since = limitPeriodStart(period, time.Now())
+	}
+
+	purchased, err := userMerchRepo.CountPurchased(ctx, userID, line.merch.ID, since)
+	if err != nil {
+		return fmt.Errorf("failed to count purchases: %w", err)
+	}
+
+	if purchased+line.quantity > limit {
+		left := limit - purchased
+		if left < 0 {
+			left = 0
+		}
+		return &LimitExceededError{
+			MerchName: line.merch.Name,
+			Limit:     limit,
+			Period:    period,
+			Remaining: left,
+		}
+	}
+
+	return nil
+}
+
+// limitPeriodStart возвращает начало скользящего окна ограничения
+func limitPeriodStart(period string, now time.Time) time.Time {
+	switch period {
+	case models.PurchaseLimitDay:
+		return now.AddDate(0, 0, -1)
+	case models.PurchaseLimitWeek:
+		return now.AddDate(0, 0, -7)
+	case models.PurchaseLimitMonth:
+		return now.AddDate(0, -1, 0)
+	default:
+		return time.Time{}
+	}
+}
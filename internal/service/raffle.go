@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// raffleBatchSize — сколько розыгрышей проводится за один запуск
+const raffleBatchSize = 20
+
+type raffleServiceImpl struct {
+	transactor       repository.Transactor
+	userRepo         repository.UserRepository
+	merchRepo        repository.MerchRepository
+	userMerchRepo    repository.UserMerchRepository
+	transactionRepo  repository.TransactionRepository
+	raffleRepo       repository.RaffleRepository
+	notificationRepo repository.NotificationRepository
+	sinkAccount      string
+}
+
+func NewRaffleService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, transactionRepo repository.TransactionRepository, raffleRepo repository.RaffleRepository, notificationRepo repository.NotificationRepository, sinkAccount string) RaffleService {
+	return &raffleServiceImpl{
+		transactor:       transactor,
+		userRepo:         userRepo,
+		merchRepo:        merchRepo,
+		userMerchRepo:    userMerchRepo,
+		transactionRepo:  transactionRepo,
+		raffleRepo:       raffleRepo,
+		notificationRepo: notificationRepo,
+		sinkAccount:      sinkAccount,
+	}
+}
+
+// CreateRaffle создает розыгрыш и резервирует разыгрываемые единицы товара на складе.
+// Seed генерируется сразу, а публикуется только его хеш
+func (s *raffleServiceImpl) CreateRaffle(ctx context.Context, adminID int64, input models.CreateRaffleRequest) (*models.Raffle, error) {
+	if !input.DrawAt.After(time.Now()) {
+		return nil, errors.New("draw time must be in the future")
+	}
+	if input.Quantity <= 0 || input.TicketPrice <= 0 {
+		return nil, errors.New("quantity and ticket price must be positive")
+	}
+
+	merch, err := s.merchRepo.GetByName(ctx, input.Item)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	seed, err := newRaffleSeed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate seed: %w", err)
+	}
+
+	raffle := &models.Raffle{
+		MerchID:     merch.ID,
+		Item:        merch.Name,
+		Quantity:    input.Quantity,
+		TicketPrice: input.TicketPrice,
+		DrawAt:      input.DrawAt,
+		Seed:        &seed,
+		SeedHash:    raffleSeedHash(seed),
+		CreatedBy:   &adminID,
+	}
+
+	switch {
+	case input.Variant != "":
+		variant, err := s.merchRepo.GetVariantBySKU(ctx, input.Variant)
+		if err != nil {
+			return nil, err
+		}
+		if variant.MerchID != merch.ID {
+			return nil, fmt.Errorf("variant %s does not belong to %s", input.Variant, merch.Name)
+		}
+		raffle.VariantID = &variant.ID
+		raffle.Variant = &variant.SKU
+	case merch.HasVariants:
+		return nil, fmt.Errorf("%s comes in several variants, choose one by SKU", merch.Name)
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if raffle.VariantID != nil {
+			err = s.merchRepo.DecrementVariantStock(ctx, *raffle.VariantID, raffle.Quantity)
+		} else {
+			err = s.merchRepo.DecrementStock(ctx, merch.ID, raffle.Quantity)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot reserve %d of %s: %w", raffle.Quantity, merch.Name, err)
+		}
+
+		return s.raffleRepo.Create(ctx, raffle)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raffle: %w", err)
+	}
+
+	// Seed остается секретным до розыгрыша даже для администратора
+	raffle.Seed = nil
+	return raffle, nil
+}
+
+func (s *raffleServiceImpl) GetRaffles(ctx context.Context) ([]models.Raffle, error) {
+	return s.raffleRepo.GetOpen(ctx)
+}
+
+// GetRaffle возвращает розыгрыш с купленными билетами, шансами пользователя на выигрыш
+// и, после розыгрыша, раскрытым seed и победителями
+func (s *raffleServiceImpl) GetRaffle(ctx context.Context, userID, raffleID int64) (*models.Raffle, error) {
+	raffle, err := s.raffleRepo.GetByID(ctx, raffleID)
+	if err != nil {
+		return nil, err
+	}
+
+	raffle.Entries, err = s.raffleRepo.GetEntries(ctx, raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entries: %w", err)
+	}
+
+	for _, entry := range raffle.Entries {
+		if entry.UserID == userID {
+			raffle.MyTickets += entry.Tickets
+		}
+	}
+
+	if raffle.Status == models.RaffleDrawn {
+		raffle.Winners, err = s.raffleRepo.GetWinners(ctx, raffleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get winners: %w", err)
+		}
+		return raffle, nil
+	}
+
+	raffle.WinChance = winChance(raffle.MyTickets, raffle.TicketsSold, raffle.Quantity)
+	return raffle, nil
+}
+
+// BuyTickets покупает билеты розыгрыша. Монеты за билеты зачисляются на системный аккаунт
+func (s *raffleServiceImpl) BuyTickets(ctx context.Context, userID, raffleID int64, quantity int) (*models.RaffleEntry, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	var entry *models.RaffleEntry
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		raffle, err := s.raffleRepo.GetByID(ctx, raffleID)
+		if err != nil {
+			return err
+		}
+		if raffle.Status != models.RaffleOpen || !time.Now().Before(raffle.DrawAt) {
+			return errors.New("raffle is closed for entries")
+		}
+
+		sink, err := s.userRepo.GetByUsername(ctx, s.sinkAccount)
+		if err != nil {
+			return fmt.Errorf("sink account not found: %w", err)
+		}
+		if !sink.IsSystem {
+			return fmt.Errorf("sink account %s is not a system account", sink.Username)
+		}
+
+		cost := raffle.TicketPrice * int64(quantity)
+		if err := s.userRepo.UpdateCoins(ctx, userID, -cost); err != nil {
+			return fmt.Errorf("failed to deduct coins: %w", err)
+		}
+		if err := s.userRepo.UpdateCoins(ctx, sink.ID, cost); err != nil {
+			return fmt.Errorf("failed to credit sink account: %w", err)
+		}
+
+		transaction := &models.Transaction{
+			FromUserID:  userID,
+			ToUserID:    sink.ID,
+			Amount:      cost,
+			Description: fmt.Sprintf("%d ticket(s) for raffle #%d", quantity, raffle.ID),
+			Kind:        models.TransactionRaffle,
+		}
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
+
+		// Номера билетов присваиваются при записи, она же повторно проверяет, что розыгрыш открыт
+		entry = &models.RaffleEntry{RaffleID: raffle.ID, UserID: userID, Tickets: quantity}
+		return s.raffleRepo.AddEntry(ctx, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to buy tickets: %w", err)
+	}
+
+	return entry, nil
+}
+
+// DrawDue проводит розыгрыши, время которых наступило. Выбранные розыгрыши остаются
+// заблокированными до конца транзакции, поэтому другие экземпляры приложения их не увидят
+func (s *raffleServiceImpl) DrawDue(ctx context.Context) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		raffles, err := s.raffleRepo.LockDue(ctx, raffleBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to lock due raffles: %w", err)
+		}
+
+		for i := range raffles {
+			raffle := &raffles[i]
+
+			// Каждый розыгрыш проводится во вложенной транзакции, чтобы ошибка
+			// в одном не мешала провести остальные
+			err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				return s.draw(ctx, raffle)
+			})
+			if err != nil {
+				log.Printf("Drawing raffle %d failed: %v", raffle.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// draw выбирает выигравшие билеты по seed и выдает товар их владельцам.
+// Непроданные единицы возвращаются на склад
+func (s *raffleServiceImpl) draw(ctx context.Context, raffle *models.Raffle) error {
+	if raffle.Seed == nil {
+		return errors.New("raffle seed is missing")
+	}
+
+	entries, err := s.raffleRepo.GetEntries(ctx, raffle.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get entries: %w", err)
+	}
+
+	tickets := drawTickets(*raffle.Seed, raffle.TicketsSold, raffle.Quantity)
+	for i, ticket := range tickets {
+		owner := ticketOwner(entries, ticket)
+		if owner == nil {
+			return fmt.Errorf("ticket %d has no owner", ticket)
+		}
+
+		userMerch := &models.UserMerch{
+			UserID:    owner.UserID,
+			MerchID:   raffle.MerchID,
+			VariantID: raffle.VariantID,
+		}
+		if err := s.userMerchRepo.Create(ctx, userMerch); err != nil {
+			return fmt.Errorf("failed to record won item: %w", err)
+		}
+
+		winner := &models.RaffleWinner{RaffleID: raffle.ID, UserID: owner.UserID, Ticket: ticket, Position: i + 1}
+		if err := s.raffleRepo.AddWinner(ctx, winner); err != nil {
+			return fmt.Errorf("failed to record winner: %w", err)
+		}
+
+		notification := &models.Notification{
+			UserID:  owner.UserID,
+			Kind:    models.NotificationRaffleWon,
+			MerchID: &raffle.MerchID,
+			Message: fmt.Sprintf("Your ticket #%d won %s in raffle #%d", ticket, raffle.Item, raffle.ID),
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			return fmt.Errorf("failed to create notification: %w", err)
+		}
+	}
+
+	if unsold := raffle.Quantity - len(tickets); unsold > 0 {
+		if raffle.VariantID != nil {
+			err = s.merchRepo.RestockVariant(ctx, *raffle.VariantID, unsold)
+		} else {
+			err = s.merchRepo.Restock(ctx, raffle.MerchID, unsold)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restock unsold items: %w", err)
+		}
+	}
+
+	return s.raffleRepo.MarkDrawn(ctx, raffle.ID)
+}
+
+// newRaffleSeed генерирует случайный seed розыгрыша
+func newRaffleSeed() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// raffleSeedHash возвращает публикуемый заранее хеш seed: hex(sha256(seed))
+func raffleSeedHash(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// drawTickets детерминированно выбирает min(quantity, sold) разных выигравших билетов.
+// На шаге k берутся первые 8 байт sha256("<seed>:<k>") как big-endian число, номер билета —
+// остаток от деления на sold плюс 1. Уже выбранные билеты пропускаются.
+// По раскрытому seed любой может повторить вычисление
+func drawTickets(seed string, sold, quantity int) []int {
+	if sold <= 0 || quantity <= 0 {
+		return nil
+	}
+	if quantity > sold {
+		quantity = sold
+	}
+
+	tickets := make([]int, 0, quantity)
+	drawn := make(map[int]bool, quantity)
+	for k := 0; len(tickets) < quantity; k++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", seed, k)))
+		ticket := int(binary.BigEndian.Uint64(sum[:8])%uint64(sold)) + 1
+		if drawn[ticket] {
+			continue
+		}
+		drawn[ticket] = true
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets
+}
+
+// ticketOwner находит покупку, к которой относится билет. Покупки отсортированы по первому номеру
+func ticketOwner(entries []models.RaffleEntry, ticket int) *models.RaffleEntry {
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].FirstTicket+entries[i].Tickets > ticket
+	})
+	if i == len(entries) || entries[i].FirstTicket > ticket {
+		return nil
+	}
+	return &entries[i]
+}
+
+// winChance возвращает вероятность выиграть хотя бы одну единицу при текущем числе
+// проданных билетов: 1 - C(sold-mine, quantity) / C(sold, quantity)
+func winChance(mine, sold, quantity int) float64 {
+	if mine <= 0 || sold <= 0 {
+		return 0
+	}
+	if quantity >= sold {
+		return 1
+	}
+
+	lose := 1.0
+	for i := 0; i < quantity; i++ {
+		if sold-mine-i <= 0 {
+			return 1
+		}
+		lose *= float64(sold-mine-i) / float64(sold-i)
+	}
+
+	return 1 - lose
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDrawTickets(t *testing.T) {
+	seed := "4f1c0e9a7b2d"
+
+	tickets := drawTickets(seed, 50, 5)
+
+	// Результат полностью определяется seed, поэтому его можно проверить повторным вычислением
+	assert.Equal(t, tickets, drawTickets(seed, 50, 5))
+	assert.Len(t, tickets, 5)
+
+	seen := make(map[int]bool)
+	for _, ticket := range tickets {
+		assert.True(t, ticket >= 1 && ticket <= 50)
+		assert.False(t, seen[ticket], "ticket %d drawn twice", ticket)
+		seen[ticket] = true
+	}
+
+	// Если билетов продано меньше, чем разыгрывается единиц, выигрывают все билеты
+	assert.ElementsMatch(t, []int{1, 2, 3}, drawTickets(seed, 3, 5))
+	assert.Empty(t, drawTickets(seed, 0, 5))
+}
+
+func TestWinChance(t *testing.T) {
+	assert.Equal(t, 0.0, winChance(0, 10, 1))
+	assert.InDelta(t, 0.3, winChance(3, 10, 1), 1e-9)
+	// 1 - (8/10 * 7/9)
+	assert.InDelta(t, 1-56.0/90.0, winChance(2, 10, 2), 1e-9)
+	assert.Equal(t, 1.0, winChance(1, 3, 3))
+	assert.Equal(t, 1.0, winChance(9, 10, 2))
+}
+
+func TestTicketOwner(t *testing.T) {
+	entries := []models.RaffleEntry{
+		{UserID: 2, FirstTicket: 1, Tickets: 3},
+		{UserID: 3, FirstTicket: 4, Tickets: 1},
+		{UserID: 2, FirstTicket: 5, Tickets: 2},
+	}
+
+	assert.Equal(t, int64(2), ticketOwner(entries, 3).UserID)
+	assert.Equal(t, int64(3), ticketOwner(entries, 4).UserID)
+	assert.Equal(t, int64(2), ticketOwner(entries, 6).UserID)
+	assert.Nil(t, ticketOwner(entries, 7))
+}
+
+func TestRaffleService_BuyTickets(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockRaffleRepo := new(MockRaffleRepository)
+
+	service := NewRaffleService(new(MockTransactor), mockUserRepo, new(MockMerchRepository), new(MockUserMerchRepository), mockTransactionRepo, mockRaffleRepo, new(MockNotificationRepository), "system")
+
+	ctx := context.Background()
+	raffle := &models.Raffle{ID: 1, TicketPrice: 15, Quantity: 2, DrawAt: time.Now().Add(time.Hour), Status: models.RaffleOpen}
+
+	// Настраиваем моки
+	mockRaffleRepo.On("GetByID", ctx, int64(1)).Return(raffle, nil)
+	mockUserRepo.On("GetByUsername", ctx, "system").Return(&models.User{ID: 100, Username: "system", IsSystem: true}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(2), int64(-45)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(100), int64(45)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 2 && transaction.ToUserID == 100 && transaction.Amount == 45 && transaction.Kind == models.TransactionRaffle
+	})).Return(nil)
+	mockRaffleRepo.On("AddEntry", ctx, mock.MatchedBy(func(entry *models.RaffleEntry) bool {
+		return entry.RaffleID == 1 && entry.UserID == 2 && entry.Tickets == 3
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.RaffleEntry).FirstTicket = 8
+	}).Return(nil)
+
+	// Вызываем тестируемый метод
+	entry, err := service.BuyTickets(ctx, 2, 1, 3)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, 8, entry.FirstTicket)
+	mockUserRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockRaffleRepo.AssertExpectations(t)
+}
+
+func TestRaffleService_BuyTickets_AfterDrawTime(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRaffleRepo := new(MockRaffleRepository)
+
+	service := NewRaffleService(new(MockTransactor), mockUserRepo, new(MockMerchRepository), new(MockUserMerchRepository), new(MockTransactionRepository), mockRaffleRepo, new(MockNotificationRepository), "system")
+
+	ctx := context.Background()
+	raffle := &models.Raffle{ID: 1, TicketPrice: 15, Quantity: 2, DrawAt: time.Now().Add(-time.Minute), Status: models.RaffleOpen}
+
+	// Настраиваем моки
+	mockRaffleRepo.On("GetByID", ctx, int64(1)).Return(raffle, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.BuyTickets(ctx, 2, 1, 1)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "closed for entries")
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRaffleService_GetRaffle_Odds(t *testing.T) {
+	mockRaffleRepo := new(MockRaffleRepository)
+
+	service := NewRaffleService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), new(MockUserMerchRepository), new(MockTransactionRepository), mockRaffleRepo, new(MockNotificationRepository), "system")
+
+	ctx := context.Background()
+	raffle := &models.Raffle{ID: 1, Quantity: 1, TicketsSold: 4, Status: models.RaffleOpen}
+	entries := []models.RaffleEntry{
+		{RaffleID: 1, UserID: 2, FirstTicket: 1, Tickets: 1},
+		{RaffleID: 1, UserID: 3, FirstTicket: 2, Tickets: 2},
+		{RaffleID: 1, UserID: 2, FirstTicket: 4, Tickets: 1},
+	}
+
+	// Настраиваем моки
+	mockRaffleRepo.On("GetByID", ctx, int64(1)).Return(raffle, nil)
+	mockRaffleRepo.On("GetEntries", ctx, int64(1)).Return(entries, nil)
+
+	// Вызываем тестируемый метод
+	result, err := service.GetRaffle(ctx, 2, 1)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.MyTickets)
+	assert.InDelta(t, 0.5, result.WinChance, 1e-9)
+	mockRaffleRepo.AssertNotCalled(t, "GetWinners", mock.Anything, mock.Anything)
+}
+
+func TestRaffleService_DrawDue(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockRaffleRepo := new(MockRaffleRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	service := NewRaffleService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, mockUserMerchRepo, new(MockTransactionRepository), mockRaffleRepo, mockNotificationRepo, "system")
+
+	ctx := context.Background()
+	seed := "c0ffee"
+	// Разыгрываются три единицы, а продано только два билета: оба выигрывают, одна единица возвращается на склад
+	raffles := []models.Raffle{{ID: 1, MerchID: 6, Item: "hoody", Quantity: 3, TicketsSold: 2, Seed: &seed, Status: models.RaffleOpen}}
+	entries := []models.RaffleEntry{
+		{RaffleID: 1, UserID: 2, FirstTicket: 1, Tickets: 1},
+		{RaffleID: 1, UserID: 3, FirstTicket: 2, Tickets: 1},
+	}
+
+	// Настраиваем моки
+	mockRaffleRepo.On("LockDue", ctx, raffleBatchSize).Return(raffles, nil)
+	mockRaffleRepo.On("GetEntries", ctx, int64(1)).Return(entries, nil)
+	for _, userID := range []int64{2, 3} {
+		userID := userID
+		mockUserMerchRepo.On("Create", ctx, mock.MatchedBy(func(item *models.UserMerch) bool {
+			return item.UserID == userID && item.MerchID == 6 && item.PricePaid == 0
+		})).Return(nil).Once()
+		mockRaffleRepo.On("AddWinner", ctx, mock.MatchedBy(func(winner *models.RaffleWinner) bool {
+			return winner.UserID == userID && winner.Ticket == int(userID-1)
+		})).Return(nil).Once()
+		mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(notification *models.Notification) bool {
+			return notification.UserID == userID && notification.Kind == models.NotificationRaffleWon
+		})).Return(nil).Once()
+	}
+	mockMerchRepo.On("Restock", ctx, int64(6), 1).Return(nil)
+	mockRaffleRepo.On("MarkDrawn", ctx, int64(1)).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.DrawDue(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockMerchRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockRaffleRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type returnServiceImpl struct {
+	transactor      repository.Transactor
+	userRepo        repository.UserRepository
+	merchRepo       repository.MerchRepository
+	userMerchRepo   repository.UserMerchRepository
+	orderRepo       repository.OrderRepository
+	transactionRepo repository.TransactionRepository
+	returnRepo      repository.ReturnRepository
+	window          time.Duration
+}
+
+func NewReturnService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, transactionRepo repository.TransactionRepository, returnRepo repository.ReturnRepository, window time.Duration) ReturnService {
+	return &returnServiceImpl{
+		transactor:      transactor,
+		userRepo:        userRepo,
+		merchRepo:       merchRepo,
+		userMerchRepo:   userMerchRepo,
+		orderRepo:       orderRepo,
+		transactionRepo: transactionRepo,
+		returnRepo:      returnRepo,
+		window:          window,
+	}
+}
+
+// RequestReturn создает заявку на возврат или обмен предмета из инвентаря пользователя.
+// Вернуть можно предмет из доставленного заказа в течение окна возврата с момента покупки.
+// Выигрыши аукционов и розыгрышей куплены не в магазине и возврату не подлежат
+func (s *returnServiceImpl) RequestReturn(ctx context.Context, userID int64, input models.CreateReturnRequest) (*models.MerchReturn, error) {
+	ret := &models.MerchReturn{
+		UserMerchID: input.ItemID,
+		UserID:      userID,
+		Kind:        models.ReturnKindReturn,
+		Reason:      strings.TrimSpace(input.Reason),
+	}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		item, err := s.userMerchRepo.GetByIDForUpdate(ctx, input.ItemID)
+		if err != nil {
+			return err
+		}
+
+		if item.UserID != userID {
+			return errors.New("item not found")
+		}
+		if item.Status != models.UserMerchOwned {
+			return fmt.Errorf("item is %s and cannot be returned", item.Status)
+		}
+		if time.Since(item.CreatedAt) > s.window {
+			return errors.New("return window has expired")
+		}
+		// Монеты за предмет, полученный через обмен, платил не текущий владелец
+		if item.TradedAt != nil {
+			return errors.New("traded items cannot be returned")
+		}
+
+		if item.OrderID == nil {
+			return errors.New("only items bought in the shop can be returned")
+		}
+
+		// Пока заказ не доставлен, его можно отменить целиком
+		order, err := s.orderRepo.GetByID(ctx, *item.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderDelivered {
+			return errors.New("only delivered items can be returned, cancel the order instead")
+		}
+
+		if input.ExchangeFor != "" {
+			line, err := s.exchangeTarget(ctx, input.ExchangeFor, input.ExchangeVariant)
+			if err != nil {
+				return err
+			}
+			if line.merch.ID == item.MerchID && sameID(variantID(line.variant), item.VariantID) {
+				return errors.New("choose a different item to exchange for")
+			}
+			if err := s.checkExchange(ctx, item, line); err != nil {
+				return err
+			}
+
+			ret.Kind = models.ReturnKindExchange
+			ret.ExchangeMerchID = &line.merch.ID
+			ret.ExchangeVariantID = variantID(line.variant)
+		}
+
+		return s.returnRepo.Create(ctx, ret)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request return: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (s *returnServiceImpl) GetUserReturns(ctx context.Context, userID int64) ([]models.MerchReturn, error) {
+	return s.returnRepo.GetUserReturns(ctx, userID)
+}
+
+func (s *returnServiceImpl) GetReturns(ctx context.Context, status string) ([]models.MerchReturn, error) {
+	return s.returnRepo.GetByStatus(ctx, status)
+}
+
+// ApproveReturn помечает предмет возвращенным и возвращает его на склад.
+// При возврате монеты за предмет возвращаются тому, кто за него заплатил,
+// при обмене пользователь получает новый товар и доплачивает разницу в цене
+func (s *returnServiceImpl) ApproveReturn(ctx context.Context, adminID, returnID int64, resolution string) (*models.MerchReturn, error) {
+	var ret *models.MerchReturn
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		ret, err = s.returnRepo.GetByIDForUpdate(ctx, returnID)
+		if err != nil {
+			return err
+		}
+		if ret.Status != models.ReturnPending {
+			return errors.New("return request is already resolved")
+		}
+
+		item, err := s.userMerchRepo.GetByIDForUpdate(ctx, ret.UserMerchID)
+		if err != nil {
+			return err
+		}
+		if item.Status != models.UserMerchOwned {
+			return fmt.Errorf("item is %s and cannot be returned", item.Status)
+		}
+		if item.UserID != ret.UserID || item.TradedAt != nil {
+			return errors.New("item has been traded and cannot be returned")
+		}
+
+		if err := s.userMerchRepo.SetStatus(ctx, item.ID, models.UserMerchReturned); err != nil {
+			return fmt.Errorf("failed to mark item returned: %w", err)
+		}
+
+		if item.VariantID != nil {
+			err = s.merchRepo.RestockVariant(ctx, *item.VariantID, 1)
+		} else {
+			err = s.merchRepo.Restock(ctx, item.MerchID, 1)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restock merch: %w", err)
+		}
+
+		var transaction *models.Transaction
+		if ret.Kind == models.ReturnKindExchange {
+			transaction, err = s.exchange(ctx, ret, item)
+		} else {
+			transaction, err = s.refund(ctx, ret, item)
+		}
+		if err != nil {
+			return err
+		}
+		if transaction != nil {
+			ret.TransactionID = &transaction.ID
+		}
+
+		ret.Status = models.ReturnApproved
+		ret.Resolution = resolution
+		ret.ResolvedBy = &adminID
+
+		return s.returnRepo.Resolve(ctx, ret)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve return: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (s *returnServiceImpl) RejectReturn(ctx context.Context, adminID, returnID int64, resolution string) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		ret, err := s.returnRepo.GetByIDForUpdate(ctx, returnID)
+		if err != nil {
+			return err
+		}
+
+		ret.Status = models.ReturnRejected
+		ret.Resolution = resolution
+		ret.ResolvedBy = &adminID
+
+		return s.returnRepo.Resolve(ctx, ret)
+	})
+}
+
+// refund возвращает уплаченную за предмет сумму. За подарок монеты получает даритель
+func (s *returnServiceImpl) refund(ctx context.Context, ret *models.MerchReturn, item *models.UserMerch) (*models.Transaction, error) {
+	ret.Refund = item.PricePaid
+	if item.PricePaid == 0 {
+		return nil, nil
+	}
+
+	payerID := item.UserID
+	if item.GivenBy != nil {
+		payerID = *item.GivenBy
+	}
+
+	if err := s.userRepo.RefundCoins(ctx, payerID, item.PricePaid, item.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to refund coins: %w", err)
+	}
+
+	transaction := &models.Transaction{
+		ToUserID:    payerID,
+		Amount:      item.PricePaid,
+		Description: fmt.Sprintf("Refund for %s", ret.MerchName),
+		Kind:        models.TransactionRefund,
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// exchange выдает пользователю новый товар по текущей цене и списывает разницу с уплаченной суммой
+func (s *returnServiceImpl) exchange(ctx context.Context, ret *models.MerchReturn, item *models.UserMerch) (*models.Transaction, error) {
+	merch, err := s.merchRepo.GetByID(ctx, *ret.ExchangeMerchID)
+	if err != nil {
+		return nil, err
+	}
+
+	line := purchaseLine{merch: merch, quantity: 1}
+	if ret.ExchangeVariantID != nil {
+		variants, err := s.merchRepo.GetVariants(ctx, []int64{merch.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merch variants: %w", err)
+		}
+		for i := range variants {
+			if variants[i].ID == *ret.ExchangeVariantID {
+				line.variant = &variants[i]
+			}
+		}
+		if line.variant == nil {
+			return nil, errors.New("merch variant not found")
+		}
+	}
+
+	if err := s.checkExchange(ctx, item, line); err != nil {
+		return nil, err
+	}
+	price := line.price()
+
+	if line.variant != nil {
+		err = s.merchRepo.DecrementVariantStock(ctx, line.variant.ID, 1)
+	} else {
+		err = s.merchRepo.DecrementStock(ctx, merch.ID, 1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot exchange for %s: %w", merch.Name, err)
+	}
+
+	// Новый предмет наследует заказ, дарителя и время покупки исходного: окно возврата
+	// не начинается заново, а при возврате монеты получит тот, кто за него платил
+	replacement := &models.UserMerch{
+		UserID:    item.UserID,
+		MerchID:   merch.ID,
+		VariantID: variantID(line.variant),
+		PricePaid: price,
+		OrderID:   item.OrderID,
+		GivenBy:   item.GivenBy,
+		GiftNote:  item.GiftNote,
+		CreatedAt: item.CreatedAt,
+	}
+	if err := s.userMerchRepo.Create(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("failed to record exchanged item: %w", err)
+	}
+
+	ret.Charge = price - item.PricePaid
+	if ret.Charge == 0 {
+		return nil, nil
+	}
+
+	if err := s.userRepo.UpdateCoins(ctx, item.UserID, -ret.Charge); err != nil {
+		return nil, fmt.Errorf("failed to charge price difference: %w", err)
+	}
+
+	transaction := &models.Transaction{
+		FromUserID:  item.UserID,
+		Amount:      ret.Charge,
+		Description: fmt.Sprintf("Exchange of %s for %s", ret.MerchName, merch.Name),
+		Kind:        models.TransactionExchange,
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, fmt.Errorf("failed to record exchange charge: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// checkExchange проверяет, что предмет можно обменять на товар: новый товар не дешевле уплаченного,
+// подарок меняется только на товар той же цены, чтобы при возврате дарителю вернулась ровно его сумма,
+// и обмен не обходит ограничение на покупку товара
+func (s *returnServiceImpl) checkExchange(ctx context.Context, item *models.UserMerch, line purchaseLine) error {
+	price := line.price()
+	if price < item.PricePaid {
+		return fmt.Errorf("exchange item must cost at least %d coins", item.PricePaid)
+	}
+	if item.GivenBy != nil && price != item.PricePaid {
+		return fmt.Errorf("gifts can only be exchanged for items costing %d coins", item.PricePaid)
+	}
+
+	return checkPurchaseLimit(ctx, s.userMerchRepo, item.UserID, line)
+}
+
+// exchangeTarget находит товар и вариант, на который обменивается предмет
+func (s *returnServiceImpl) exchangeTarget(ctx context.Context, merchName, sku string) (purchaseLine, error) {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return purchaseLine{}, fmt.Errorf("merch not found: %w", err)
+	}
+
+	line := purchaseLine{merch: merch, quantity: 1}
+	switch {
+	case sku != "":
+		line.variant, err = s.merchRepo.GetVariantBySKU(ctx, sku)
+		if err != nil {
+			return purchaseLine{}, err
+		}
+		if line.variant.MerchID != merch.ID {
+			return purchaseLine{}, fmt.Errorf("variant %s does not belong to %s", sku, merch.Name)
+		}
+	case merch.HasVariants:
+		return purchaseLine{}, fmt.Errorf("%s comes in several variants, choose one by SKU", merch.Name)
+	}
+
+	return line, nil
+}
+
+func variantID(variant *models.MerchVariant) *int64 {
+	if variant == nil {
+		return nil
+	}
+	return &variant.ID
+}
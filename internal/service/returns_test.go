@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReturnService_RequestReturn(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockReturnRepo := new(MockReturnRepository)
+
+	service := NewReturnService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, mockUserMerchRepo, mockOrderRepo, new(MockTransactionRepository), mockReturnRepo, 14*24*time.Hour)
+
+	ctx := context.Background()
+	userID := int64(1)
+	orderID := int64(7)
+
+	// Настраиваем моки
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(100)).Return(&models.UserMerch{
+		ID: 100, UserID: userID, MerchID: 1, PricePaid: 80, OrderID: &orderID,
+		Status: models.UserMerchOwned, CreatedAt: time.Now().Add(-time.Hour),
+	}, nil)
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(101)).Return(&models.UserMerch{
+		ID: 101, UserID: userID, MerchID: 1, PricePaid: 80,
+		Status: models.UserMerchOwned, CreatedAt: time.Now().Add(-30 * 24 * time.Hour),
+	}, nil)
+	mockOrderRepo.On("GetByID", ctx, orderID).Return(&models.Order{ID: orderID, Status: models.OrderDelivered}, nil)
+	mockMerchRepo.On("GetByName", ctx, "cup").Return(&models.MerchItem{ID: 2, Name: "cup", Price: 20}, nil)
+	mockMerchRepo.On("GetByName", ctx, "hoody").Return(&models.MerchItem{ID: 6, Name: "hoody", Price: 300}, nil)
+	mockReturnRepo.On("Create", ctx, mock.AnythingOfType("*models.MerchReturn")).Return(nil)
+
+	// Вызываем тестируемый метод
+	ret, err := service.RequestReturn(ctx, userID, models.CreateReturnRequest{ItemID: 100, Reason: "не подошел размер"})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReturnKindReturn, ret.Kind)
+
+	ret, err = service.RequestReturn(ctx, userID, models.CreateReturnRequest{ItemID: 100, ExchangeFor: "hoody"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReturnKindExchange, ret.Kind)
+	assert.Equal(t, int64(6), *ret.ExchangeMerchID)
+
+	// Обменять можно только на товар не дешевле
+	_, err = service.RequestReturn(ctx, userID, models.CreateReturnRequest{ItemID: 100, ExchangeFor: "cup"})
+	assert.ErrorContains(t, err, "at least 80")
+
+	// Окно возврата истекло
+	_, err = service.RequestReturn(ctx, userID, models.CreateReturnRequest{ItemID: 101})
+	assert.ErrorContains(t, err, "expired")
+
+	// Чужой предмет вернуть нельзя
+	_, err = service.RequestReturn(ctx, 2, models.CreateReturnRequest{ItemID: 100})
+	assert.Error(t, err)
+
+	mockReturnRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestReturnService_ApproveReturn_RefundsGiver(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockReturnRepo := new(MockReturnRepository)
+
+	service := NewReturnService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, new(MockOrderRepository), mockTransactionRepo, mockReturnRepo, 14*24*time.Hour)
+
+	ctx := context.Background()
+	adminID := int64(42)
+	giverID := int64(3)
+
+	// Настраиваем моки
+	mockReturnRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.MerchReturn{
+		ID: 1, UserMerchID: 100, UserID: 1, MerchName: "t-shirt", Kind: models.ReturnKindReturn, Status: models.ReturnPending,
+	}, nil)
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(100)).Return(&models.UserMerch{
+		ID: 100, UserID: 1, MerchID: 1, PricePaid: 72, GivenBy: &giverID, Status: models.UserMerchOwned,
+	}, nil)
+	mockUserMerchRepo.On("SetStatus", ctx, int64(100), models.UserMerchReturned).Return(nil)
+	mockMerchRepo.On("Restock", ctx, int64(1), 1).Return(nil)
+	mockUserRepo.On("RefundCoins", ctx, giverID, int64(72), mock.AnythingOfType("time.Time")).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 0 && transaction.ToUserID == giverID && transaction.Amount == 72 &&
+			transaction.Kind == models.TransactionRefund
+	})).Return(nil)
+	mockReturnRepo.On("Resolve", ctx, mock.MatchedBy(func(ret *models.MerchReturn) bool {
+		return ret.Status == models.ReturnApproved && ret.Refund == 72 && *ret.ResolvedBy == adminID
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	ret, err := service.ApproveReturn(ctx, adminID, 1, "")
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(72), ret.Refund)
+	mockUserRepo.AssertExpectations(t)
+	mockMerchRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockReturnRepo.AssertExpectations(t)
+}
+
+func TestReturnService_ApproveReturn_Exchange(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockReturnRepo := new(MockReturnRepository)
+
+	service := NewReturnService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, new(MockOrderRepository), mockTransactionRepo, mockReturnRepo, 14*24*time.Hour)
+
+	ctx := context.Background()
+	userID := int64(1)
+	hoodyID := int64(6)
+	variantID := int64(11)
+	variantPrice := int64(350)
+	orderID := int64(7)
+	purchasedAt := time.Now().Add(-48 * time.Hour)
+
+	// Настраиваем моки
+	mockReturnRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(&models.MerchReturn{
+		ID: 2, UserMerchID: 100, UserID: userID, MerchName: "t-shirt", Kind: models.ReturnKindExchange,
+		ExchangeMerchID: &hoodyID, ExchangeVariantID: &variantID, Status: models.ReturnPending,
+	}, nil)
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(100)).Return(&models.UserMerch{
+		ID: 100, UserID: userID, MerchID: 1, PricePaid: 80, OrderID: &orderID, Status: models.UserMerchOwned, CreatedAt: purchasedAt,
+	}, nil)
+	mockUserMerchRepo.On("SetStatus", ctx, int64(100), models.UserMerchReturned).Return(nil)
+	mockMerchRepo.On("Restock", ctx, int64(1), 1).Return(nil)
+	mockMerchRepo.On("GetByID", ctx, hoodyID).Return(&models.MerchItem{ID: hoodyID, Name: "hoody", Price: 300, HasVariants: true}, nil)
+	mockMerchRepo.On("GetVariants", ctx, []int64{hoodyID}).Return([]models.MerchVariant{
+		{ID: 10, MerchID: hoodyID, SKU: "HOODY-S"},
+		{ID: variantID, MerchID: hoodyID, SKU: "HOODY-XL", Price: &variantPrice},
+	}, nil)
+	mockMerchRepo.On("DecrementVariantStock", ctx, variantID, 1).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.MatchedBy(func(userMerch *models.UserMerch) bool {
+		// Окно возврата нового предмета отсчитывается от исходной покупки
+		return userMerch.UserID == userID && userMerch.MerchID == hoodyID && *userMerch.VariantID == variantID && userMerch.PricePaid == 350 &&
+			*userMerch.OrderID == orderID && userMerch.CreatedAt.Equal(purchasedAt)
+	})).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, userID, int64(-270)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == userID && transaction.Amount == 270 && transaction.Kind == models.TransactionExchange
+	})).Return(nil)
+	mockReturnRepo.On("Resolve", ctx, mock.AnythingOfType("*models.MerchReturn")).Return(nil)
+
+	// Вызываем тестируемый метод
+	ret, err := service.ApproveReturn(ctx, 42, 2, "")
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(270), ret.Charge)
+	assert.Equal(t, models.ReturnApproved, ret.Status)
+	mockUserRepo.AssertExpectations(t)
+	mockMerchRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestReturnService_RequestReturn_NotFromShop(t *testing.T) {
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockReturnRepo := new(MockReturnRepository)
+
+	service := NewReturnService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), mockUserMerchRepo, new(MockOrderRepository), new(MockTransactionRepository), mockReturnRepo, 14*24*time.Hour)
+
+	ctx := context.Background()
+
+	// Настраиваем моки: предмет выигран на аукционе и не привязан к заказу
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(100)).Return(&models.UserMerch{
+		ID: 100, UserID: 1, MerchID: 1, PricePaid: 500, Status: models.UserMerchOwned, CreatedAt: time.Now().Add(-time.Hour),
+	}, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.RequestReturn(ctx, 1, models.CreateReturnRequest{ItemID: 100})
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "only items bought in the shop")
+	mockReturnRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReturnService_RequestReturn_ExchangeRules(t *testing.T) {
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockReturnRepo := new(MockReturnRepository)
+
+	service := NewReturnService(new(MockTransactor), new(MockUserRepository), mockMerchRepo, mockUserMerchRepo, mockOrderRepo, new(MockTransactionRepository), mockReturnRepo, 14*24*time.Hour)
+
+	ctx := context.Background()
+	userID, giverID, orderID := int64(1), int64(3), int64(7)
+	limit := 1
+
+	// Настраиваем моки
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(100)).Return(&models.UserMerch{
+		ID: 100, UserID: userID, MerchID: 1, PricePaid: 80, OrderID: &orderID, GivenBy: &giverID,
+		Status: models.UserMerchOwned, CreatedAt: time.Now().Add(-time.Hour),
+	}, nil)
+	mockUserMerchRepo.On("GetByIDForUpdate", ctx, int64(101)).Return(&models.UserMerch{
+		ID: 101, UserID: userID, MerchID: 1, PricePaid: 80, OrderID: &orderID,
+		Status: models.UserMerchOwned, CreatedAt: time.Now().Add(-time.Hour),
+	}, nil)
+	mockOrderRepo.On("GetByID", ctx, orderID).Return(&models.Order{ID: orderID, Status: models.OrderDelivered}, nil)
+	mockMerchRepo.On("GetByName", ctx, "hoody").Return(&models.MerchItem{ID: 6, Name: "hoody", Price: 300}, nil)
+	mockMerchRepo.On("GetByName", ctx, "pink-hoody").Return(&models.MerchItem{ID: 8, Name: "pink-hoody", Price: 80, PurchaseLimit: &limit}, nil)
+	mockUserMerchRepo.On("CountPurchased", ctx, userID, int64(8), time.Time{}).Return(1, nil)
+
+	// Вызываем тестируемый метод и проверяем результаты
+	// Подарок с доплатой обменять нельзя: при возврате дарителю вернулись бы монеты получателя
+	_, err := service.RequestReturn(ctx, userID, models.CreateReturnRequest{ItemID: 100, ExchangeFor: "hoody"})
+	assert.ErrorContains(t, err, "gifts can only be exchanged for items costing 80 coins")
+
+	// Обмен не обходит ограничение на покупку товара
+	_, err = service.RequestReturn(ctx, userID, models.CreateReturnRequest{ItemID: 101, ExchangeFor: "pink-hoody"})
+	var limitErr *LimitExceededError
+	assert.ErrorAs(t, err, &limitErr)
+
+	mockReturnRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
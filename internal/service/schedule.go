@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// scheduleBatchSize — сколько переводов обрабатывается за один запуск планировщика
+const scheduleBatchSize = 100
+
+type scheduleServiceImpl struct {
+	transactor   repository.Transactor
+	userRepo     repository.UserRepository
+	scheduleRepo repository.ScheduledTransferRepository
+	userService  UserService
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+func NewScheduleService(transactor repository.Transactor, userRepo repository.UserRepository, scheduleRepo repository.ScheduledTransferRepository, userService UserService, maxAttempts int, retryBackoff time.Duration) ScheduleService {
+	return &scheduleServiceImpl{
+		transactor:   transactor,
+		userRepo:     userRepo,
+		scheduleRepo: scheduleRepo,
+		userService:  userService,
+		maxAttempts:  maxAttempts,
+		retryBackoff: retryBackoff,
+	}
+}
+
+func (s *scheduleServiceImpl) CreateSchedule(ctx context.Context, userID int64, input models.CreateScheduledTransferRequest) (*models.ScheduledTransfer, error) {
+	if input.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	recurrence := input.Recurrence
+	if recurrence == "" {
+		recurrence = models.RecurrenceOnce
+	}
+	if !isValidRecurrence(recurrence) {
+		return nil, fmt.Errorf("unknown recurrence: %s", recurrence)
+	}
+	// Прошедшая дата выполнила бы разовый перевод сразу, а регулярный привязала бы к произвольному прошлому
+	if !input.RunAt.After(time.Now()) {
+		return nil, errors.New("runAt must be in the future")
+	}
+
+	// Получаем пользователя-получателя
+	toUser, err := s.userRepo.GetByUsername(ctx, input.ToUser)
+	if err != nil {
+		return nil, fmt.Errorf("recipient not found: %w", err)
+	}
+
+	if toUser.ID == userID {
+		return nil, errors.New("cannot schedule transfer to yourself")
+	}
+
+	schedule := &models.ScheduledTransfer{
+		FromUserID: userID,
+		ToUserID:   toUser.ID,
+		ToUsername: toUser.Username,
+		Amount:     input.Amount,
+		Comment:    input.Comment,
+		Recurrence: recurrence,
+		Status:     models.ScheduleActive,
+		NextRunAt:  input.RunAt,
+		AnchorAt:   input.RunAt,
+	}
+
+	err = s.scheduleRepo.Create(ctx, schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (s *scheduleServiceImpl) GetUserSchedules(ctx context.Context, userID int64) ([]models.ScheduledTransfer, error) {
+	return s.scheduleRepo.GetUserSchedules(ctx, userID)
+}
+
+func (s *scheduleServiceImpl) Pause(ctx context.Context, userID, scheduleID int64) error {
+	return s.scheduleRepo.SetStatus(ctx, scheduleID, userID, []string{models.ScheduleActive}, models.SchedulePaused)
+}
+
+func (s *scheduleServiceImpl) Resume(ctx context.Context, userID, scheduleID int64) error {
+	return s.scheduleRepo.SetStatus(ctx, scheduleID, userID, []string{models.SchedulePaused}, models.ScheduleActive)
+}
+
+func (s *scheduleServiceImpl) Cancel(ctx context.Context, userID, scheduleID int64) error {
+	return s.scheduleRepo.SetStatus(ctx, scheduleID, userID,
+		[]string{models.ScheduleActive, models.SchedulePaused}, models.ScheduleCancelled)
+}
+
+// ProcessDue выполняет переводы, срок которых наступил. Выбранные строки
+// остаются заблокированными до конца транзакции, поэтому другие экземпляры
+// приложения их не увидят
+func (s *scheduleServiceImpl) ProcessDue(ctx context.Context) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		schedules, err := s.scheduleRepo.LockDue(ctx, scheduleBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to lock due transfers: %w", err)
+		}
+
+		for i := range schedules {
+			schedule := &schedules[i]
+			now := time.Now()
+
+			// Перевод выполняется во вложенной транзакции: при ошибке откатывается
+			// только он, а запись о неудачной попытке сохраняется
+			err := s.userService.SendCoins(ctx, schedule.FromUserID, schedule.ToUsername, schedule.Amount)
+			if err != nil {
+				log.Printf("Scheduled transfer %d failed: %v", schedule.ID, err)
+				s.registerFailure(schedule, err, now)
+			} else {
+				s.registerSuccess(schedule, now)
+			}
+			schedule.LastRunAt = &now
+
+			if err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+				return fmt.Errorf("failed to update scheduled transfer %d: %w", schedule.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// registerSuccess переносит перевод на следующий запуск или завершает разовый
+func (s *scheduleServiceImpl) registerSuccess(schedule *models.ScheduledTransfer, now time.Time) {
+	schedule.Attempts = 0
+	schedule.LastError = ""
+
+	if schedule.Recurrence == models.RecurrenceOnce {
+		schedule.Status = models.ScheduleCompleted
+		return
+	}
+	schedule.NextRunAt = nextOccurrence(schedule.AnchorAt, schedule.Recurrence, now)
+}
+
+// registerFailure планирует повтор с экспоненциальной задержкой. Когда попытки
+// исчерпаны, разовый перевод помечается неудачным, а регулярный переносится
+// на следующий период. Повторы сдвигают только NextRunAt, период считается от AnchorAt
+func (s *scheduleServiceImpl) registerFailure(schedule *models.ScheduledTransfer, err error, now time.Time) {
+	schedule.Attempts++
+	schedule.LastError = err.Error()
+
+	if schedule.Attempts < s.maxAttempts {
+		schedule.NextRunAt = now.Add(s.retryBackoff << (schedule.Attempts - 1))
+		return
+	}
+
+	if schedule.Recurrence == models.RecurrenceOnce {
+		schedule.Status = models.ScheduleFailed
+		return
+	}
+	schedule.Attempts = 0
+	schedule.NextRunAt = nextOccurrence(schedule.AnchorAt, schedule.Recurrence, now)
+}
+
+// nextOccurrence возвращает ближайший после now момент запуска для регулярного перевода.
+// Запуски отсчитываются от первого планового запуска anchor, поэтому задержки
+// не смещают расписание
+func nextOccurrence(anchor time.Time, recurrence string, now time.Time) time.Time {
+	next := anchor
+	for i := 1; !next.After(now); i++ {
+		switch recurrence {
+		case models.RecurrenceDaily:
+			next = anchor.AddDate(0, 0, i)
+		case models.RecurrenceWeekly:
+			next = anchor.AddDate(0, 0, 7*i)
+		case models.RecurrenceMonthly:
+			next = addMonths(anchor, i)
+		default:
+			return now
+		}
+	}
+	return next
+}
+
+// addMonths прибавляет months месяцев к t. Если в получившемся месяце нет такого дня,
+// берется его последний день: ежемесячный перевод 31 января выполняется 29 февраля, а не 2 марта
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month+time.Month(months), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func isValidRecurrence(recurrence string) bool {
+	switch recurrence {
+	case models.RecurrenceOnce, models.RecurrenceDaily, models.RecurrenceWeekly, models.RecurrenceMonthly:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScheduleService_ProcessDue(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockScheduleRepo := new(MockScheduledTransferRepository)
+
+	transactor := new(MockTransactor)
+	userService := NewUserService(transactor, mockUserRepo, mockTransactionRepo, new(MockUserMerchRepository), NewTransferPolicy(mockTransactionRepo, config.TransferLimits{}), noCoinExpiry(), events.NewBus())
+	service := NewScheduleService(transactor, mockUserRepo, mockScheduleRepo, userService, 3, time.Minute)
+
+	ctx := context.Background()
+	recipient := &models.User{ID: 2, Username: "recipient"}
+	runAt := time.Now().Add(-time.Hour)
+
+	// Создаем ежемесячный перевод, срок которого наступил
+	due := []models.ScheduledTransfer{{
+		ID:         1,
+		FromUserID: 1,
+		ToUserID:   recipient.ID,
+		ToUsername: recipient.Username,
+		Amount:     100,
+		Recurrence: models.RecurrenceMonthly,
+		Status:     models.ScheduleActive,
+		NextRunAt:  runAt,
+		AnchorAt:   runAt,
+	}}
+
+	// Настраиваем моки
+	mockScheduleRepo.On("LockDue", ctx, scheduleBatchSize).Return(due, nil)
+	mockUserRepo.On("GetByUsername", ctx, recipient.Username).Return(recipient, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1, Username: "sender"}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), int64(-100)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, recipient.ID, int64(100)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.AnythingOfType("*models.Transaction")).Return(nil)
+	mockScheduleRepo.On("Update", ctx, mock.MatchedBy(func(s *models.ScheduledTransfer) bool {
+		return s.Status == models.ScheduleActive && s.NextRunAt.Equal(addMonths(runAt, 1)) && s.Attempts == 0
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.ProcessDue(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockScheduleRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_ProcessDue_InsufficientFunds(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockScheduleRepo := new(MockScheduledTransferRepository)
+
+	transactor := new(MockTransactor)
+	userService := NewUserService(transactor, mockUserRepo, new(MockTransactionRepository), new(MockUserMerchRepository), NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{}), noCoinExpiry(), events.NewBus())
+	service := NewScheduleService(transactor, mockUserRepo, mockScheduleRepo, userService, 3, time.Minute)
+
+	ctx := context.Background()
+	recipient := &models.User{ID: 2, Username: "recipient"}
+
+	// Создаем разовый перевод, у которого осталась последняя попытка
+	due := []models.ScheduledTransfer{{
+		ID:         1,
+		FromUserID: 1,
+		ToUserID:   recipient.ID,
+		ToUsername: recipient.Username,
+		Amount:     100,
+		Recurrence: models.RecurrenceOnce,
+		Status:     models.ScheduleActive,
+		Attempts:   2,
+		NextRunAt:  time.Now().Add(-time.Minute),
+	}}
+
+	// Настраиваем моки
+	mockScheduleRepo.On("LockDue", ctx, scheduleBatchSize).Return(due, nil)
+	mockUserRepo.On("GetByUsername", ctx, recipient.Username).Return(recipient, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(&models.User{ID: 1, Username: "sender"}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(1), int64(-100)).Return(errors.New("insufficient funds"))
+	mockScheduleRepo.On("Update", ctx, mock.MatchedBy(func(s *models.ScheduledTransfer) bool {
+		return s.Status == models.ScheduleFailed && s.Attempts == 3 && s.LastError != ""
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	err := service.ProcessDue(ctx)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockScheduleRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestScheduleService_RegisterFailure_Backoff(t *testing.T) {
+	service := &scheduleServiceImpl{maxAttempts: 5, retryBackoff: time.Minute}
+	now := time.Now()
+
+	schedule := &models.ScheduledTransfer{Recurrence: models.RecurrenceOnce, Status: models.ScheduleActive, Attempts: 2}
+	service.registerFailure(schedule, errors.New("insufficient funds"), now)
+
+	// Третья попытка откладывается на 4 минуты
+	assert.Equal(t, 3, schedule.Attempts)
+	assert.Equal(t, models.ScheduleActive, schedule.Status)
+	assert.True(t, schedule.NextRunAt.Equal(now.Add(4*time.Minute)))
+}
+
+func TestScheduleService_RegisterSuccess_AfterRetry(t *testing.T) {
+	service := &scheduleServiceImpl{maxAttempts: 5, retryBackoff: time.Minute}
+	anchor := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	// Еженедельный перевод прошел со второй попытки спустя час после планового запуска
+	schedule := &models.ScheduledTransfer{
+		Recurrence: models.RecurrenceWeekly,
+		Status:     models.ScheduleActive,
+		Attempts:   1,
+		NextRunAt:  anchor.Add(time.Hour),
+		AnchorAt:   anchor,
+	}
+	service.registerSuccess(schedule, anchor.Add(time.Hour))
+
+	// Следующий запуск приходится на плановое время, а не на время повтора
+	assert.Equal(t, 0, schedule.Attempts)
+	assert.True(t, schedule.NextRunAt.Equal(anchor.AddDate(0, 0, 7)))
+}
+
+func TestNextOccurrence_MonthEnd(t *testing.T) {
+	anchor := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	// Февральский запуск переносится на последний день месяца, а мартовский возвращается на 31 число
+	next := nextOccurrence(anchor, models.RecurrenceMonthly, anchor)
+	assert.True(t, next.Equal(time.Date(2026, time.February, 28, 9, 0, 0, 0, time.UTC)))
+
+	next = nextOccurrence(anchor, models.RecurrenceMonthly, next)
+	assert.True(t, next.Equal(time.Date(2026, time.March, 31, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestScheduleService_CreateSchedule_PastRunAt(t *testing.T) {
+	mockScheduleRepo := new(MockScheduledTransferRepository)
+
+	service := NewScheduleService(new(MockTransactor), new(MockUserRepository), mockScheduleRepo, nil, 3, time.Minute)
+
+	// Вызываем тестируемый метод
+	_, err := service.CreateSchedule(context.Background(), 1, models.CreateScheduledTransferRequest{
+		ToUser:     "recipient",
+		Amount:     100,
+		RunAt:      time.Now().Add(-time.Hour),
+		Recurrence: models.RecurrenceMonthly,
+	})
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "runAt must be in the future")
+	mockScheduleRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
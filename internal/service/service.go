@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/haqer0002/avito-shop/internal/repository"
+	"github.com/haqer0002/avito-shop/internal/storage"
 )
 
 // AuthService представляет интерфейс сервиса аутентификации
@@ -13,6 +18,7 @@ type AuthService interface {
 	GenerateToken(ctx context.Context, username, password string) (string, error)
 	ParseToken(token string) (int64, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	IsAdmin(ctx context.Context, userID int64) (bool, error)
 }
 
 // UserService представляет интерфейс сервиса пользователей
@@ -21,24 +27,267 @@ type UserService interface {
 	SendCoins(ctx context.Context, fromUserID int64, toUsername string, amount int64) error
 }
 
+// TransferPolicy представляет интерфейс политики, проверяющей перевод до его выполнения
+type TransferPolicy interface {
+	Check(ctx context.Context, sender, recipient *models.User, amount int64) error
+}
+
 // MerchService представляет интерфейс сервиса мерча
 type MerchService interface {
-	BuyMerch(ctx context.Context, userID int64, merchName string) error
-	GetAllMerch(ctx context.Context) ([]models.MerchItem, error)
+	BuyMerch(ctx context.Context, userID int64, merchName string, opts models.BuyOptions) (*models.Order, error)
+	GetAllMerch(ctx context.Context, filter models.MerchFilter) ([]models.MerchItem, int, error)
+	GetCategories(ctx context.Context) ([]models.MerchCategory, error)
+	UpdateMerch(ctx context.Context, merchName string, input models.UpdateMerchRequest) (*models.MerchItem, error)
+	ChangePrice(ctx context.Context, adminID int64, merchName string, price int64) (*models.PriceChange, error)
+	GetPriceHistory(ctx context.Context, merchName string) ([]models.PriceChange, error)
+	UploadImage(ctx context.Context, merchName string, r io.Reader) (*models.MerchImage, error)
+	DeleteImage(ctx context.Context, merchName string, imageID int64) error
+}
+
+// PricingEngine рассчитывает цены позиций заказа с учетом скидок и промокода
+type PricingEngine interface {
+	Apply(ctx context.Context, userID int64, order *models.Order, promoCode string) error
+	Redeem(ctx context.Context, order *models.Order) error
+}
+
+// PricingService представляет интерфейс сервиса скидок и промокодов
+type PricingService interface {
+	PricingEngine
+	CreateDiscount(ctx context.Context, input models.CreateDiscountRequest) (*models.Discount, error)
+	GetDiscounts(ctx context.Context) ([]models.Discount, error)
+	CreatePromoCode(ctx context.Context, input models.CreatePromoCodeRequest) (*models.PromoCode, error)
+	GetPromoCodes(ctx context.Context) ([]models.PromoCode, error)
+}
+
+// CartService представляет интерфейс сервиса корзины
+type CartService interface {
+	GetCart(ctx context.Context, userID int64) (*models.Cart, error)
+	AddItem(ctx context.Context, userID int64, merchName, variantSKU string, quantity int) error
+	SetQuantity(ctx context.Context, userID int64, merchName, variantSKU string, quantity int) error
+	RemoveItem(ctx context.Context, userID int64, merchName, variantSKU string) error
+	Checkout(ctx context.Context, userID int64, promoCode string) (*models.Order, error)
+}
+
+// WishlistService представляет интерфейс сервиса списка желаний
+type WishlistService interface {
+	GetWishlist(ctx context.Context, userID int64) (*models.Wishlist, error)
+	AddItem(ctx context.Context, userID int64, merchName string) error
+	RemoveItem(ctx context.Context, userID int64, merchName string) error
+	// Notify рассылает уведомления об изменениях товаров из списков желаний, вызывается периодически
+	Notify(ctx context.Context) error
+}
+
+// NotificationService представляет интерфейс сервиса уведомлений
+type NotificationService interface {
+	GetNotifications(ctx context.Context, userID int64, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(ctx context.Context, userID, notificationID int64) error
+}
+
+// ReturnService представляет интерфейс сервиса возврата и обмена мерча
+type ReturnService interface {
+	RequestReturn(ctx context.Context, userID int64, input models.CreateReturnRequest) (*models.MerchReturn, error)
+	GetUserReturns(ctx context.Context, userID int64) ([]models.MerchReturn, error)
+	GetReturns(ctx context.Context, status string) ([]models.MerchReturn, error)
+	ApproveReturn(ctx context.Context, adminID, returnID int64, resolution string) (*models.MerchReturn, error)
+	RejectReturn(ctx context.Context, adminID, returnID int64, resolution string) error
+}
+
+// TradeService представляет интерфейс сервиса обмена предметами и монетами между пользователями
+type TradeService interface {
+	ProposeTrade(ctx context.Context, userID int64, input models.CreateTradeRequest) (*models.Trade, error)
+	CounterTrade(ctx context.Context, userID, tradeID int64, terms models.TradeTerms) (*models.Trade, error)
+	AcceptTrade(ctx context.Context, userID, tradeID int64) (*models.Trade, error)
+	RejectTrade(ctx context.Context, userID, tradeID int64) error
+	CancelTrade(ctx context.Context, userID, tradeID int64) error
+	GetUserTrades(ctx context.Context, userID int64) ([]models.Trade, error)
+}
+
+// MarketService представляет интерфейс сервиса маркетплейса, на котором пользователи перепродают мерч
+type MarketService interface {
+	CreateListing(ctx context.Context, userID int64, input models.CreateListingRequest) (*models.MarketListing, error)
+	CancelListing(ctx context.Context, userID, listingID int64) error
+	BuyListing(ctx context.Context, userID, listingID int64) (*models.MarketListing, error)
+	GetListings(ctx context.Context, filter models.MarketFilter) ([]models.MarketListing, int, error)
+	GetUserListings(ctx context.Context, userID int64) ([]models.MarketListing, error)
+}
+
+// CoinExpiryService представляет интерфейс сервиса сгорания монет
+type CoinExpiryService interface {
+	GetExpiringSoon(ctx context.Context, userID int64) ([]models.ExpiringCoins, error)
+	// ExpireDue списывает сгоревшие монеты, вызывается периодически
+	ExpireDue(ctx context.Context) error
+	// WarnDue предупреждает о скором сгорании монет, вызывается периодически
+	WarnDue(ctx context.Context) error
+}
+
+// EscrowService представляет интерфейс сервиса удержания монет под незавершенные сделки
+type EscrowService interface {
+	Hold(ctx context.Context, userID, amount int64, reason string, expiresAt time.Time) (*models.CoinHold, error)
+	Capture(ctx context.Context, holdID int64) (*models.CoinHold, error)
+	Release(ctx context.Context, holdID int64) (*models.CoinHold, error)
+	GetUserHolds(ctx context.Context, userID int64) ([]models.CoinHold, error)
+	// ExpireDue снимает истекшие удержания, вызывается периодически
+	ExpireDue(ctx context.Context) error
+}
+
+// AuctionService представляет интерфейс сервиса аукционов
+type AuctionService interface {
+	CreateAuction(ctx context.Context, adminID int64, input models.CreateAuctionRequest) (*models.Auction, error)
+	GetAuctions(ctx context.Context) ([]models.Auction, error)
+	GetAuction(ctx context.Context, auctionID int64) (*models.Auction, error)
+	PlaceBid(ctx context.Context, userID, auctionID, amount int64) (*models.AuctionBid, error)
+	// CloseDue закрывает завершившиеся аукционы, вызывается периодически
+	CloseDue(ctx context.Context) error
+}
+
+// AchievementService представляет интерфейс сервиса достижений
+type AchievementService interface {
+	GetUserAchievements(ctx context.Context, userID int64) ([]models.UserAchievement, error)
+	// HandleEvent проверяет правила достижений при доменном событии
+	HandleEvent(ctx context.Context, event events.Event) error
+	// AwardAnniversaries выдает достижения за годовщины работы, вызывается периодически
+	AwardAnniversaries(ctx context.Context) error
+}
+
+// LeaderboardService представляет интерфейс сервиса рейтингов переводов
+type LeaderboardService interface {
+	GetLeaderboard(ctx context.Context, board, period string, limit int) (*models.Leaderboard, error)
+	SetOptOut(ctx context.Context, userID int64, optOut bool) error
+	// Refresh пересчитывает рейтинги, вызывается периодически
+	Refresh(ctx context.Context) error
+}
+
+// TeamService представляет интерфейс сервиса команд и их общих кошельков
+type TeamService interface {
+	CreateTeam(ctx context.Context, input models.CreateTeamRequest) (*models.Team, error)
+	SetMember(ctx context.Context, teamID int64, input models.SetTeamMemberRequest) (*models.TeamMember, error)
+	RemoveMember(ctx context.Context, teamID int64, username string) error
+	Grant(ctx context.Context, teamID int64, input models.TeamGrantRequest) (*models.Transaction, error)
+	GetUserTeams(ctx context.Context, userID int64) ([]models.Team, error)
+	GetTeam(ctx context.Context, userID, teamID int64) (*models.Team, error)
+	GetTransactions(ctx context.Context, userID, teamID int64) ([]models.Transaction, error)
+	SendCoins(ctx context.Context, managerID, teamID int64, input models.SendCoinRequest) (*models.Transaction, error)
+	BuyMerch(ctx context.Context, managerID, teamID int64, merchName string, opts models.BuyOptions) (*models.Order, error)
+}
+
+// RaffleService представляет интерфейс сервиса розыгрышей
+type RaffleService interface {
+	CreateRaffle(ctx context.Context, adminID int64, input models.CreateRaffleRequest) (*models.Raffle, error)
+	GetRaffles(ctx context.Context) ([]models.Raffle, error)
+	GetRaffle(ctx context.Context, userID, raffleID int64) (*models.Raffle, error)
+	BuyTickets(ctx context.Context, userID, raffleID int64, quantity int) (*models.RaffleEntry, error)
+	// DrawDue проводит розыгрыши, время которых наступило, вызывается периодически
+	DrawDue(ctx context.Context) error
+}
+
+// OrderService представляет интерфейс сервиса заказов
+type OrderService interface {
+	GetUserOrders(ctx context.Context, userID int64) ([]models.Order, error)
+	GetOrder(ctx context.Context, userID, orderID int64) (*models.Order, error)
+	CancelOrder(ctx context.Context, userID, orderID int64) error
+	UpdateStatus(ctx context.Context, orderID int64, status string) (*models.Order, error)
+}
+
+// PaymentRequestService представляет интерфейс сервиса запросов на перевод монет
+type PaymentRequestService interface {
+	CreateRequest(ctx context.Context, requesterID int64, payerUsername string, amount int64, comment string) (*models.PaymentRequest, error)
+	Approve(ctx context.Context, payerID, requestID int64) error
+	Decline(ctx context.Context, payerID, requestID int64) error
+	GetIncoming(ctx context.Context, userID int64) ([]models.PaymentRequest, error)
+	GetOutgoing(ctx context.Context, userID int64) ([]models.PaymentRequest, error)
+}
+
+// ScheduleService представляет интерфейс сервиса запланированных переводов
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, userID int64, input models.CreateScheduledTransferRequest) (*models.ScheduledTransfer, error)
+	GetUserSchedules(ctx context.Context, userID int64) ([]models.ScheduledTransfer, error)
+	Pause(ctx context.Context, userID, scheduleID int64) error
+	Resume(ctx context.Context, userID, scheduleID int64) error
+	Cancel(ctx context.Context, userID, scheduleID int64) error
+	ProcessDue(ctx context.Context) error
+}
+
+// FraudService представляет интерфейс сервиса обнаружения подозрительных переводов
+type FraudService interface {
+	Analyze(ctx context.Context) error
+	GetAlerts(ctx context.Context, status string) ([]models.Alert, error)
+	ReviewAlert(ctx context.Context, adminID, alertID int64, input models.ReviewAlertRequest) (*models.Alert, error)
+	SetHold(ctx context.Context, userID int64, hold bool) error
+}
+
+// DisputeService представляет интерфейс сервиса споров и отмены переводов
+type DisputeService interface {
+	OpenDispute(ctx context.Context, userID, transactionID int64, reason string) (*models.Dispute, error)
+	GetUserDisputes(ctx context.Context, userID int64) ([]models.Dispute, error)
+	GetDisputes(ctx context.Context, status string) ([]models.Dispute, error)
+	RejectDispute(ctx context.Context, adminID, disputeID int64, resolution string) error
+	ReverseTransaction(ctx context.Context, adminID, transactionID int64, input models.ReverseTransactionRequest) (*models.Transaction, error)
 }
 
 // Service представляет все сервисы приложения
 type Service struct {
-	Auth  AuthService
-	User  UserService
-	Merch MerchService
+	Auth            AuthService
+	User            UserService
+	Merch           MerchService
+	Cart            CartService
+	Wishlist        WishlistService
+	Notifications   NotificationService
+	Pricing         PricingService
+	Orders          OrderService
+	Returns         ReturnService
+	Trades          TradeService
+	Market          MarketService
+	Escrow          EscrowService
+	CoinExpiry      CoinExpiryService
+	Auctions        AuctionService
+	Raffles         RaffleService
+	Achievements    AchievementService
+	Leaderboards    LeaderboardService
+	Teams           TeamService
+	PaymentRequests PaymentRequestService
+	Schedules       ScheduleService
+	Fraud           FraudService
+	Disputes        DisputeService
 }
 
 // NewService создает новый экземпляр Service
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, cfg *config.Config) *Service {
+	bus := events.NewBus()
+	achievementService := NewAchievementService(repos.Transactor, repos.Users, repos.Transactions, repos.Achievements, repos.Notifications)
+	// Правила достижений проверяются при регистрации, переводах и доставке заказов
+	bus.Subscribe(events.UserCreatedEvent, achievementService.HandleEvent)
+	bus.Subscribe(events.CoinsSentEvent, achievementService.HandleEvent)
+	bus.Subscribe(events.OrderDeliveredEvent, achievementService.HandleEvent)
+
+	transferPolicy := NewTransferPolicy(repos.Transactions, cfg.TransferLimits)
+	coinExpiryService := NewCoinExpiryService(repos.Transactor, repos.Users, repos.CoinLots, repos.Transactions, repos.Notifications, cfg.CoinExpiry)
+	userService := NewUserService(repos.Transactor, repos.Users, repos.Transactions, repos.UserMerch, transferPolicy, coinExpiryService, bus)
+	pricingService := NewPricingService(repos.Merch, repos.Discounts, repos.PromoCodes)
+	mediaStore := storage.NewLocalStore(cfg.Media.Dir, cfg.Media.URLPrefix)
+	escrowService := NewEscrowService(repos.Transactor, repos.Holds)
+
 	return &Service{
-		Auth:  NewAuthService(repos.Users),
-		User:  NewUserService(repos.Users, repos.Transactions, repos.UserMerch),
-		Merch: NewMerchService(repos.Users, repos.Merch, repos.UserMerch),
+		Auth:            NewAuthService(repos.Users, bus),
+		User:            userService,
+		Merch:           NewMerchService(repos.Transactor, repos.Users, repos.Merch, repos.UserMerch, repos.Orders, pricingService, mediaStore, cfg.Media, bus),
+		Cart:            NewCartService(repos.Transactor, repos.Users, repos.Merch, repos.UserMerch, repos.Orders, repos.Carts, pricingService, bus),
+		Wishlist:        NewWishlistService(repos.Transactor, repos.Users, repos.Merch, repos.Wishlists, repos.Discounts, repos.Notifications),
+		Notifications:   NewNotificationService(repos.Notifications),
+		Pricing:         pricingService,
+		Orders:          NewOrderService(repos.Transactor, repos.Users, repos.Merch, repos.UserMerch, repos.Orders, bus),
+		Returns:         NewReturnService(repos.Transactor, repos.Users, repos.Merch, repos.UserMerch, repos.Orders, repos.Transactions, repos.Returns, cfg.ReturnWindow),
+		Trades:          NewTradeService(repos.Transactor, repos.Users, repos.UserMerch, repos.Orders, repos.Transactions, repos.Trades, transferPolicy, escrowService, cfg.TradeOfferTTL),
+		Market:          NewMarketService(repos.Transactor, repos.Users, repos.UserMerch, repos.Orders, repos.Transactions, repos.Market, transferPolicy, cfg.Market),
+		Escrow:          escrowService,
+		CoinExpiry:      coinExpiryService,
+		Auctions:        NewAuctionService(repos.Transactor, repos.Merch, repos.UserMerch, repos.Transactions, repos.Auctions, repos.Notifications, escrowService, cfg.Escrow.AuctionHoldGrace),
+		Raffles:         NewRaffleService(repos.Transactor, repos.Users, repos.Merch, repos.UserMerch, repos.Transactions, repos.Raffles, repos.Notifications, cfg.Raffle.SinkAccount),
+		Achievements:    achievementService,
+		Leaderboards:    NewLeaderboardService(repos.Users, repos.Leaderboards),
+		Teams:           NewTeamService(repos.Transactor, repos.Users, repos.Merch, repos.UserMerch, repos.Orders, repos.Transactions, repos.Teams, transferPolicy, pricingService),
+		PaymentRequests: NewPaymentRequestService(repos.Transactor, repos.Users, repos.PaymentRequests, userService, cfg.PaymentRequestTTL),
+		Schedules:       NewScheduleService(repos.Transactor, repos.Users, repos.Schedules, userService, cfg.ScheduleMaxAttempts, cfg.ScheduleRetryBackoff),
+		Fraud:           NewFraudService(repos.Transactor, repos.Users, repos.Fraud, repos.Alerts, cfg.Fraud),
+		Disputes:        NewDisputeService(repos.Transactor, repos.Users, repos.Transactions, repos.Disputes),
 	}
 }
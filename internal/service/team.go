@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// teamWalletPrefix — префикс имени служебного аккаунта, на котором хранится кошелек команды
+const teamWalletPrefix = "team:"
+
+type teamServiceImpl struct {
+	transactor      repository.Transactor
+	userRepo        repository.UserRepository
+	merchRepo       repository.MerchRepository
+	transactionRepo repository.TransactionRepository
+	teamRepo        repository.TeamRepository
+	policy          TransferPolicy
+	purchaser       *purchaser
+}
+
+func NewTeamService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, transactionRepo repository.TransactionRepository, teamRepo repository.TeamRepository, policy TransferPolicy, pricing PricingEngine) TeamService {
+	return &teamServiceImpl{
+		transactor:      transactor,
+		userRepo:        userRepo,
+		merchRepo:       merchRepo,
+		transactionRepo: transactionRepo,
+		teamRepo:        teamRepo,
+		policy:          policy,
+		purchaser: &purchaser{
+			userRepo:      userRepo,
+			merchRepo:     merchRepo,
+			userMerchRepo: userMerchRepo,
+			orderRepo:     orderRepo,
+			pricing:       pricing,
+		},
+	}
+}
+
+// CreateTeam создает команду вместе со служебным аккаунтом ее кошелька.
+// Войти под аккаунтом кошелька нельзя: пароль не является хешем. Кошелек помечается
+// системным аккаунтом, поэтому не получает достижений
+func (s *teamServiceImpl) CreateTeam(ctx context.Context, input models.CreateTeamRequest) (*models.Team, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("team name is required")
+	}
+
+	team := &models.Team{Name: name}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		wallet := &models.User{Username: teamWalletPrefix + name, Password: "!", IsSystem: true}
+		if err := s.userRepo.Create(ctx, wallet); err != nil {
+			return fmt.Errorf("failed to create team wallet: %w", err)
+		}
+
+		team.WalletUserID = wallet.ID
+		if err := s.teamRepo.Create(ctx, team); err != nil {
+			return fmt.Errorf("failed to create team: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// SetMember добавляет пользователя в команду или меняет его роль
+func (s *teamServiceImpl) SetMember(ctx context.Context, teamID int64, input models.SetTeamMemberRequest) (*models.TeamMember, error) {
+	switch input.Role {
+	case "":
+		input.Role = models.TeamRoleMember
+	case models.TeamRoleMember, models.TeamRoleManager:
+	default:
+		return nil, fmt.Errorf("unknown team role %q", input.Role)
+	}
+
+	if _, err := s.teamRepo.GetByID(ctx, teamID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, input.Username)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	member := &models.TeamMember{
+		TeamID:   teamID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     input.Role,
+	}
+	if err := s.teamRepo.SetMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to save team member: %w", err)
+	}
+
+	return member, nil
+}
+
+// RemoveMember исключает пользователя из команды
+func (s *teamServiceImpl) RemoveMember(ctx context.Context, teamID int64, username string) error {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	return s.teamRepo.RemoveMember(ctx, teamID, user.ID)
+}
+
+// Grant пополняет кошелек команды. Грант выпускает новые монеты и записывается в историю команды
+func (s *teamServiceImpl) Grant(ctx context.Context, teamID int64, input models.TeamGrantRequest) (*models.Transaction, error) {
+	if input.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Grant to team %s", team.Name)
+	if comment := strings.TrimSpace(input.Comment); comment != "" {
+		description += ": " + comment
+	}
+
+	transaction := &models.Transaction{
+		ToUserID:    team.WalletUserID,
+		Amount:      input.Amount,
+		Description: description,
+		Kind:        models.TransactionTeamGrant,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateCoins(ctx, team.WalletUserID, input.Amount); err != nil {
+			return fmt.Errorf("failed to credit team wallet: %w", err)
+		}
+
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// GetUserTeams возвращает команды пользователя
+func (s *teamServiceImpl) GetUserTeams(ctx context.Context, userID int64) ([]models.Team, error) {
+	return s.teamRepo.GetUserTeams(ctx, userID)
+}
+
+// GetTeam возвращает команду с участниками. Команду видят только ее участники
+func (s *teamServiceImpl) GetTeam(ctx context.Context, userID, teamID int64) (*models.Team, error) {
+	member, err := s.teamRepo.GetMember(ctx, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	team.Role = member.Role
+
+	team.Members, err = s.teamRepo.GetMembers(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+
+	return team, nil
+}
+
+// GetTransactions возвращает историю кошелька команды. Историю видят только участники команды
+func (s *teamServiceImpl) GetTransactions(ctx context.Context, userID, teamID int64) ([]models.Transaction, error) {
+	if _, err := s.teamRepo.GetMember(ctx, teamID, userID); err != nil {
+		return nil, err
+	}
+
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transactionRepo.GetUserTransactions(ctx, team.WalletUserID)
+}
+
+// SendCoins переводит монеты с кошелька команды ее участнику. Перевод выполняет менеджер,
+// он проходит политику переводов от имени кошелька и записывается в историю так же,
+// как перевод между пользователями
+func (s *teamServiceImpl) SendCoins(ctx context.Context, managerID, teamID int64, input models.SendCoinRequest) (*models.Transaction, error) {
+	if input.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	team, manager, err := s.managedTeam(ctx, managerID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.teamMember(ctx, team, input.ToUser)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.UserID == managerID {
+		return nil, errors.New("managers cannot send team coins to themselves")
+	}
+
+	transaction := &models.Transaction{
+		FromUserID:  team.WalletUserID,
+		ToUserID:    recipient.UserID,
+		Amount:      input.Amount,
+		Description: fmt.Sprintf("Team %s: sent by %s to %s", team.Name, manager.Username, recipient.Username),
+		Kind:        models.TransactionTransfer,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		users, err := lockUsers(ctx, s.userRepo, team.WalletUserID, recipient.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.policy.Check(ctx, users[team.WalletUserID], users[recipient.UserID], input.Amount); err != nil {
+			return err
+		}
+
+		if err := s.userRepo.UpdateCoins(ctx, team.WalletUserID, -input.Amount); err != nil {
+			return fmt.Errorf("failed to deduct coins from team wallet: %w", err)
+		}
+
+		if err := s.userRepo.UpdateCoins(ctx, recipient.UserID, input.Amount); err != nil {
+			return fmt.Errorf("failed to add coins to recipient: %w", err)
+		}
+
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// BuyMerch покупает мерч участнику команды за монеты кошелька команды. Покупку выполняет менеджер,
+// мерч приходит участнику как подарок от команды, а списание записывается в историю команды
+func (s *teamServiceImpl) BuyMerch(ctx context.Context, managerID, teamID int64, merchName string, opts models.BuyOptions) (*models.Order, error) {
+	team, manager, err := s.managedTeam(ctx, managerID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Recipient == "" {
+		return nil, errors.New("recipient is required")
+	}
+	recipient, err := s.teamMember(ctx, team, opts.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.UserID == managerID {
+		return nil, errors.New("managers cannot buy team merch for themselves")
+	}
+
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return nil, fmt.Errorf("merch not found: %w", err)
+	}
+
+	line := purchaseLine{merch: merch, quantity: opts.Quantity}
+	if opts.VariantSKU != "" {
+		line.variant, err = s.merchRepo.GetVariantBySKU(ctx, opts.VariantSKU)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	note := strings.TrimSpace(opts.GiftNote)
+	if note == "" {
+		note = fmt.Sprintf("From team %s", team.Name)
+	}
+	present := &gift{recipientID: recipient.UserID, note: &note}
+
+	var order *models.Order
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Блокируем кошелек и получателя, как при покупке в подарок
+		if _, err := lockUsers(ctx, s.userRepo, team.WalletUserID, recipient.UserID); err != nil {
+			return err
+		}
+
+		order, err = s.purchaser.placeOrder(ctx, team.WalletUserID, []purchaseLine{line}, opts.PromoCode, present)
+		if err != nil {
+			return err
+		}
+
+		transaction := &models.Transaction{
+			FromUserID:  team.WalletUserID,
+			Amount:      order.Total,
+			Description: fmt.Sprintf("Team %s: order #%d for %s by %s", team.Name, order.ID, recipient.Username, manager.Username),
+			Kind:        models.TransactionTeamBuy,
+		}
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// managedTeam возвращает команду и проверяет, что пользователь — ее менеджер
+func (s *teamServiceImpl) managedTeam(ctx context.Context, userID, teamID int64) (*models.Team, *models.TeamMember, error) {
+	member, err := s.teamRepo.GetMember(ctx, teamID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member.Role != models.TeamRoleManager {
+		return nil, nil, errors.New("only team managers can spend from the team wallet")
+	}
+
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return team, member, nil
+}
+
+// teamMember находит участника команды по имени пользователя
+func (s *teamServiceImpl) teamMember(ctx context.Context, team *models.Team, username string) (*models.TeamMember, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("recipient not found: %w", err)
+	}
+
+	member, err := s.teamRepo.GetMember(ctx, team.ID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a member of team %s", user.Username, team.Name)
+	}
+
+	return member, nil
+}
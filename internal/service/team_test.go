@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testTeam — команда с кошельком на служебном аккаунте 100, менеджером 1 и участником 2
+var testTeam = &models.Team{ID: 7, Name: "platform", WalletUserID: 100, Balance: 1000}
+
+// testLead и testDev — менеджер и участник testTeam
+var (
+	testLead = &models.TeamMember{TeamID: testTeam.ID, UserID: 1, Username: "lead", Role: models.TeamRoleManager}
+	testDev  = &models.TeamMember{TeamID: testTeam.ID, UserID: 2, Username: "dev", Role: models.TeamRoleMember}
+)
+
+func TestTeamService_CreateTeam(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(nil, config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, nil, nil, nil, nil, mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+
+	// Настраиваем моки: кошелек команды — служебный аккаунт без начального баланса, под которым нельзя войти
+	mockUserRepo.On("Create", ctx, mock.MatchedBy(func(user *models.User) bool {
+		return user.Username == "team:platform" && user.Password == "!" && user.Coins == 0 && user.IsSystem
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.User).ID = 100
+	}).Return(nil)
+	mockTeamRepo.On("Create", ctx, mock.MatchedBy(func(team *models.Team) bool {
+		return team.Name == "platform" && team.WalletUserID == 100
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	team, err := service.CreateTeam(ctx, models.CreateTeamRequest{Name: " platform "})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), team.WalletUserID)
+	mockUserRepo.AssertExpectations(t)
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestTeamService_Grant(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, nil, nil, nil, mockTransactionRepo, mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockUserRepo.On("UpdateCoins", ctx, testTeam.WalletUserID, int64(5000)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == 0 && transaction.ToUserID == testTeam.WalletUserID && transaction.Amount == 5000 &&
+			transaction.Kind == models.TransactionTeamGrant && transaction.Description == "Grant to team platform: Q3 swag budget"
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.Grant(ctx, testTeam.ID, models.TeamGrantRequest{Amount: 5000, Comment: "Q3 swag budget"})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestTeamService_SendCoins(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, nil, nil, nil, mockTransactionRepo, mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testLead.UserID).Return(testLead, nil)
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockUserRepo.On("GetByUsername", ctx, "dev").Return(&models.User{ID: 2, Username: "dev"}, nil)
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testDev.UserID).Return(testDev, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(&models.User{ID: 2}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, testTeam.WalletUserID).Return(&models.User{ID: testTeam.WalletUserID, Coins: 1000}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, testTeam.WalletUserID, int64(-300)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, int64(2), int64(300)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == testTeam.WalletUserID && transaction.ToUserID == 2 && transaction.Amount == 300 &&
+			transaction.Kind == models.TransactionTransfer && transaction.Description == "Team platform: sent by lead to dev"
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.SendCoins(ctx, 1, testTeam.ID, models.SendCoinRequest{ToUser: "dev", Amount: 300})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockUserRepo.AssertExpectations(t)
+	mockTeamRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestTeamService_SendCoins_Forbidden(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, nil, nil, nil, new(MockTransactionRepository), mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testLead.UserID).Return(testLead, nil)
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testDev.UserID).Return(testDev, nil)
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, int64(3)).Return(nil, errors.New("not a team member"))
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockUserRepo.On("GetByUsername", ctx, "lead").Return(&models.User{ID: 1, Username: "lead"}, nil)
+	mockUserRepo.On("GetByUsername", ctx, "outsider").Return(&models.User{ID: 3, Username: "outsider"}, nil)
+
+	// Вызываем тестируемый метод и проверяем результаты
+	// Обычный участник не распоряжается кошельком
+	_, err := service.SendCoins(ctx, 2, testTeam.ID, models.SendCoinRequest{ToUser: "lead", Amount: 100})
+	assert.ErrorContains(t, err, "only team managers")
+
+	// Монеты команды получают только ее участники
+	_, err = service.SendCoins(ctx, 1, testTeam.ID, models.SendCoinRequest{ToUser: "outsider", Amount: 100})
+	assert.ErrorContains(t, err, "outsider is not a member of team platform")
+
+	// Менеджер не может наградить сам себя
+	_, err = service.SendCoins(ctx, 1, testTeam.ID, models.SendCoinRequest{ToUser: "lead", Amount: 100})
+	assert.ErrorContains(t, err, "themselves")
+
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTeamService_BuyMerch(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, mockMerchRepo, mockUserMerchRepo, mockOrderRepo, mockTransactionRepo, mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+	limit := 3
+	testMerch := &models.MerchItem{ID: 10, Name: "hoody", Price: 300, PurchaseLimit: &limit}
+
+	// Настраиваем моки
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testLead.UserID).Return(testLead, nil)
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockUserRepo.On("GetByUsername", ctx, "dev").Return(&models.User{ID: 2, Username: "dev"}, nil)
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testDev.UserID).Return(testDev, nil)
+	mockMerchRepo.On("GetByName", ctx, testMerch.Name).Return(testMerch, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(&models.User{ID: 2}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, testTeam.WalletUserID).Return(&models.User{ID: testTeam.WalletUserID, Coins: 1000}, nil)
+	// Ограничение на товар считается по получателю, а не по кошельку команды
+	mockUserMerchRepo.On("CountPurchased", ctx, int64(2), testMerch.ID, time.Time{}).Return(0, nil)
+	mockMerchRepo.On("DecrementStock", ctx, testMerch.ID, 2).Return(nil)
+	// Заказ оплачивается с кошелька команды, мерч получает участник
+	mockUserRepo.On("UpdateCoins", ctx, testTeam.WalletUserID, int64(-600)).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.MatchedBy(func(order *models.Order) bool {
+		return order.UserID == testTeam.WalletUserID && *order.RecipientID == 2 && *order.GiftNote == "From team platform"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Order).ID = 42
+	}).Return(nil)
+	mockUserMerchRepo.On("Create", ctx, mock.MatchedBy(func(userMerch *models.UserMerch) bool {
+		return userMerch.UserID == 2 && *userMerch.GivenBy == testTeam.WalletUserID
+	})).Return(nil).Twice()
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == testTeam.WalletUserID && transaction.Amount == 600 &&
+			transaction.Kind == models.TransactionTeamBuy && transaction.Description == "Team platform: order #42 for dev by lead"
+	})).Return(nil)
+
+	// Вызываем тестируемый метод
+	order, err := service.BuyMerch(ctx, 1, testTeam.ID, testMerch.Name, models.BuyOptions{Quantity: 2, Recipient: "dev"})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), order.Total)
+	mockUserRepo.AssertExpectations(t)
+	mockMerchRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+	mockTeamRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestTeamService_SendCoins_WalletOnHold(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, new(MockMerchRepository), new(MockUserMerchRepository), new(MockOrderRepository), mockTransactionRepo, mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+
+	// Настраиваем моки: исходящие переводы кошелька заблокированы до проверки
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testLead.UserID).Return(testLead, nil)
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testDev.UserID).Return(testDev, nil)
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockUserRepo.On("GetByUsername", ctx, "dev").Return(&models.User{ID: 2, Username: "dev"}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, testTeam.WalletUserID).Return(&models.User{ID: testTeam.WalletUserID, Coins: 1000, OnHold: true}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(&models.User{ID: 2}, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.SendCoins(ctx, 1, testTeam.ID, models.SendCoinRequest{ToUser: "dev", Amount: 300})
+
+	// Проверяем результаты
+	var violation *PolicyViolationError
+	assert.ErrorAs(t, err, &violation)
+	assert.Equal(t, RuleAccountOnHold, violation.Rule)
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTeamService_BuyMerch_ForManager(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockMerchRepo := new(MockMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, mockMerchRepo, new(MockUserMerchRepository), mockOrderRepo, new(MockTransactionRepository), mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testLead.UserID).Return(testLead, nil)
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockUserRepo.On("GetByUsername", ctx, "lead").Return(&models.User{ID: 1, Username: "lead"}, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.BuyMerch(ctx, 1, testTeam.ID, "hoody", models.BuyOptions{Quantity: 1, Recipient: "lead"})
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "themselves")
+	mockMerchRepo.AssertNotCalled(t, "GetByName", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestTeamService_GetTransactions(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTeamRepo := new(MockTeamRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTeamService(new(MockTransactor), mockUserRepo, nil, nil, nil, mockTransactionRepo, mockTeamRepo, policy, newTestPricing())
+
+	ctx := context.Background()
+	history := []models.Transaction{{ID: 1, ToUserID: testTeam.WalletUserID, Amount: 5000, Kind: models.TransactionTeamGrant}}
+
+	// Настраиваем моки
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, testDev.UserID).Return(testDev, nil)
+	mockTeamRepo.On("GetMember", ctx, testTeam.ID, int64(3)).Return(nil, errors.New("not a team member"))
+	mockTeamRepo.On("GetByID", ctx, testTeam.ID).Return(testTeam, nil)
+	mockTransactionRepo.On("GetUserTransactions", ctx, testTeam.WalletUserID).Return(history, nil)
+
+	// Вызываем тестируемый метод
+	transactions, err := service.GetTransactions(ctx, 2, testTeam.ID)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, history, transactions)
+
+	// Историю команды не видят посторонние
+	_, err = service.GetTransactions(ctx, 3, testTeam.ID)
+	assert.ErrorContains(t, err, "not a team member")
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type tradeServiceImpl struct {
+	transactor      repository.Transactor
+	userRepo        repository.UserRepository
+	userMerchRepo   repository.UserMerchRepository
+	orderRepo       repository.OrderRepository
+	transactionRepo repository.TransactionRepository
+	tradeRepo       repository.TradeRepository
+	policy          TransferPolicy
+	escrow          EscrowService
+	ttl             time.Duration
+}
+
+func NewTradeService(transactor repository.Transactor, userRepo repository.UserRepository, userMerchRepo repository.UserMerchRepository, orderRepo repository.OrderRepository, transactionRepo repository.TransactionRepository, tradeRepo repository.TradeRepository, policy TransferPolicy, escrow EscrowService, ttl time.Duration) TradeService {
+	return &tradeServiceImpl{
+		transactor:      transactor,
+		userRepo:        userRepo,
+		userMerchRepo:   userMerchRepo,
+		orderRepo:       orderRepo,
+		transactionRepo: transactionRepo,
+		tradeRepo:       tradeRepo,
+		policy:          policy,
+		escrow:          escrow,
+		ttl:             ttl,
+	}
+}
+
+// ProposeTrade создает предложение обмена другому пользователю
+func (s *tradeServiceImpl) ProposeTrade(ctx context.Context, userID int64, input models.CreateTradeRequest) (*models.Trade, error) {
+	recipient, err := s.userRepo.GetByUsername(ctx, input.ToUser)
+	if err != nil {
+		return nil, fmt.Errorf("recipient not found: %w", err)
+	}
+
+	if recipient.ID == userID {
+		return nil, errors.New("cannot trade with yourself")
+	}
+
+	var trade *models.Trade
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		trade, err = s.create(ctx, userID, recipient.ID, recipient.Username, nil, input.TradeTerms)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to propose trade: %w", err)
+	}
+
+	return trade, nil
+}
+
+// CounterTrade отклоняет полученное предложение и отправляет инициатору встречное
+func (s *tradeServiceImpl) CounterTrade(ctx context.Context, userID, tradeID int64, terms models.TradeTerms) (*models.Trade, error) {
+	var trade *models.Trade
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		original, err := s.pendingTrade(ctx, tradeID, func(t *models.Trade) int64 { return t.RecipientID }, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.resolve(ctx, original, models.TradeCountered); err != nil {
+			return err
+		}
+
+		trade, err = s.create(ctx, userID, original.ProposerID, original.Proposer, &original.ID, terms)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to counter trade: %w", err)
+	}
+
+	return trade, nil
+}
+
+// AcceptTrade принимает предложение: предметы меняют владельцев, а монеты переводятся
+// между сторонами в одной транзакции. Предложенные монеты списываются из удержания. Если хотя бы один предмет недоступен или не хватает монет,
+// обмен не выполняется и предложение остается ожидающим
+func (s *tradeServiceImpl) AcceptTrade(ctx context.Context, userID, tradeID int64) (*models.Trade, error) {
+	var trade *models.Trade
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		trade, err = s.pendingTrade(ctx, tradeID, func(t *models.Trade) int64 { return t.RecipientID }, userID)
+		if err != nil {
+			return err
+		}
+
+		users, err := lockUsers(ctx, s.userRepo, trade.ProposerID, trade.RecipientID)
+		if err != nil {
+			return err
+		}
+
+		offered := tradeItemIDs(trade.OfferedItems)
+		requested := tradeItemIDs(trade.RequestedItems)
+		if _, _, err := s.lockItems(ctx, trade.ProposerID, offered, trade.RecipientID, requested); err != nil {
+			return err
+		}
+
+		proposer, recipient := users[trade.ProposerID], users[trade.RecipientID]
+		if err := s.pay(ctx, trade, proposer, recipient, trade.OfferedCoins, trade.HoldID); err != nil {
+			return err
+		}
+		if err := s.pay(ctx, trade, recipient, proposer, trade.RequestedCoins, nil); err != nil {
+			return err
+		}
+
+		if len(offered) > 0 {
+			if err := s.userMerchRepo.Transfer(ctx, offered, trade.RecipientID); err != nil {
+				return fmt.Errorf("failed to transfer offered items: %w", err)
+			}
+		}
+		if len(requested) > 0 {
+			if err := s.userMerchRepo.Transfer(ctx, requested, trade.ProposerID); err != nil {
+				return fmt.Errorf("failed to transfer requested items: %w", err)
+			}
+		}
+
+		if err := s.tradeRepo.SetStatus(ctx, trade.ID, models.TradeAccepted); err != nil {
+			return err
+		}
+		trade.Status = models.TradeAccepted
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept trade: %w", err)
+	}
+
+	return trade, nil
+}
+
+// RejectTrade отклоняет полученное предложение
+func (s *tradeServiceImpl) RejectTrade(ctx context.Context, userID, tradeID int64) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		trade, err := s.pendingTrade(ctx, tradeID, func(t *models.Trade) int64 { return t.RecipientID }, userID)
+		if err != nil {
+			return err
+		}
+
+		return s.resolve(ctx, trade, models.TradeRejected)
+	})
+}
+
+// CancelTrade отзывает отправленное предложение
+func (s *tradeServiceImpl) CancelTrade(ctx context.Context, userID, tradeID int64) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		trade, err := s.pendingTrade(ctx, tradeID, func(t *models.Trade) int64 { return t.ProposerID }, userID)
+		if err != nil {
+			return err
+		}
+
+		return s.resolve(ctx, trade, models.TradeCancelled)
+	})
+}
+
+func (s *tradeServiceImpl) GetUserTrades(ctx context.Context, userID int64) ([]models.Trade, error) {
+	return s.tradeRepo.GetUserTrades(ctx, userID)
+}
+
+// pendingTrade блокирует предложение и проверяет, что оно ожидает решения пользователя.
+// side указывает, какая из сторон может распоряжаться предложением
+func (s *tradeServiceImpl) pendingTrade(ctx context.Context, tradeID int64, side func(*models.Trade) int64, userID int64) (*models.Trade, error) {
+	trade, err := s.tradeRepo.GetByIDForUpdate(ctx, tradeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Чужие предложения не раскрываются
+	if side(trade) != userID {
+		return nil, errors.New("trade not found")
+	}
+	if trade.Status != models.TradePending {
+		return nil, fmt.Errorf("trade is already %s", trade.Status)
+	}
+
+	return trade, nil
+}
+
+// resolve закрывает предложение без обмена и возвращает инициатору удержанные монеты
+func (s *tradeServiceImpl) resolve(ctx context.Context, trade *models.Trade, status string) error {
+	if err := s.tradeRepo.SetStatus(ctx, trade.ID, status); err != nil {
+		return err
+	}
+
+	if trade.HoldID != nil {
+		if _, err := s.escrow.Release(ctx, *trade.HoldID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// create проверяет условия обмена и сохраняет предложение от proposerID к recipientID.
+// Предложенные монеты удерживаются до истечения предложения, чтобы к моменту принятия
+// инициатор не успел их потратить
+func (s *tradeServiceImpl) create(ctx context.Context, proposerID, recipientID int64, recipient string, counterOf *int64, terms models.TradeTerms) (*models.Trade, error) {
+	if err := validateTradeTerms(terms); err != nil {
+		return nil, err
+	}
+
+	offered, requested, err := s.lockItems(ctx, proposerID, terms.OfferItems, recipientID, terms.RequestItems)
+	if err != nil {
+		return nil, err
+	}
+
+	trade := &models.Trade{
+		ProposerID:     proposerID,
+		RecipientID:    recipientID,
+		OfferedCoins:   terms.OfferCoins,
+		RequestedCoins: terms.RequestCoins,
+		OfferedItems:   offered,
+		RequestedItems: requested,
+		Message:        strings.TrimSpace(terms.Message),
+		CounterOf:      counterOf,
+		ExpiresAt:      time.Now().Add(s.ttl),
+	}
+
+	if terms.OfferCoins > 0 {
+		hold, err := s.escrow.Hold(ctx, proposerID, terms.OfferCoins, fmt.Sprintf("Trade offer to %s", recipient), trade.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		trade.HoldID = &hold.ID
+	}
+
+	if err := s.tradeRepo.Create(ctx, trade); err != nil {
+		return nil, err
+	}
+
+	// Перечитываем предложение, чтобы вернуть имена участников и названия предметов
+	return s.tradeRepo.GetByIDForUpdate(ctx, trade.ID)
+}
+
+// lockItems блокирует предметы обеих сторон и проверяет, что они принадлежат своим владельцам
+// и могут быть переданы
+func (s *tradeServiceImpl) lockItems(ctx context.Context, proposerID int64, offered []int64, recipientID int64, requested []int64) ([]models.TradeItem, []models.TradeItem, error) {
+	ids := append(append([]int64(nil), offered...), requested...)
+	if len(ids) == 0 {
+		return []models.TradeItem{}, []models.TradeItem{}, nil
+	}
+
+	items, err := s.userMerchRepo.GetByIDsForUpdate(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	byID := make(map[int64]*models.UserMerch, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	collect := func(ids []int64, ownerID int64) ([]models.TradeItem, error) {
+		result := make([]models.TradeItem, 0, len(ids))
+		for _, id := range ids {
+			item, ok := byID[id]
+			if !ok || item.UserID != ownerID || item.Status != models.UserMerchOwned {
+				return nil, fmt.Errorf("item %d is not available for trade", id)
+			}
+
+			if err := checkDelivered(ctx, s.orderRepo, item); err != nil {
+				return nil, err
+			}
+
+			result = append(result, models.TradeItem{
+				UserMerchID: id,
+				OwnerID:     ownerID,
+				Variant:     item.VariantSKU,
+			})
+		}
+		return result, nil
+	}
+
+	offeredItems, err := collect(offered, proposerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	requestedItems, err := collect(requested, recipientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return offeredItems, requestedItems, nil
+}
+
+// checkDelivered проверяет, что предмет из заказа можно передать другому пользователю.
+// До доставки заказ можно отменить, и монеты вернулись бы покупателю за предмет, которым он уже не владеет
+func checkDelivered(ctx context.Context, orderRepo repository.OrderRepository, item *models.UserMerch) error {
+	if item.OrderID == nil {
+		return nil
+	}
+
+	order, err := orderRepo.GetByID(ctx, *item.OrderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != models.OrderDelivered {
+		return fmt.Errorf("item %d cannot change hands until its order is delivered", item.ID)
+	}
+
+	return nil
+}
+
+// pay переводит монеты одной из сторон обмена с проверкой по политике переводов.
+// Если монеты удержаны при создании предложения, списывается удержание
+func (s *tradeServiceImpl) pay(ctx context.Context, trade *models.Trade, from, to *models.User, amount int64, holdID *int64) error {
+	if amount == 0 {
+		return nil
+	}
+
+	if err := s.policy.Check(ctx, from, to, amount); err != nil {
+		return err
+	}
+
+	if holdID != nil {
+		if _, err := s.escrow.Capture(ctx, *holdID); err != nil {
+			return err
+		}
+	} else if err := s.userRepo.UpdateCoins(ctx, from.ID, -amount); err != nil {
+		return fmt.Errorf("%s cannot pay %d coins: %w", from.Username, amount, err)
+	}
+	if err := s.userRepo.UpdateCoins(ctx, to.ID, amount); err != nil {
+		return fmt.Errorf("failed to add coins to %s: %w", to.Username, err)
+	}
+
+	transaction := &models.Transaction{
+		FromUserID:  from.ID,
+		ToUserID:    to.ID,
+		Amount:      amount,
+		Description: fmt.Sprintf("Trade #%d", trade.ID),
+		Kind:        models.TransactionTrade,
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return fmt.Errorf("failed to create transaction record: %w", err)
+	}
+
+	return nil
+}
+
+// validateTradeTerms проверяет, что обе стороны что-то отдают и в обмене участвует хотя бы один предмет
+func validateTradeTerms(terms models.TradeTerms) error {
+	if terms.OfferCoins < 0 || terms.RequestCoins < 0 {
+		return errors.New("coin amounts cannot be negative")
+	}
+	if terms.OfferCoins > 0 && terms.RequestCoins > 0 {
+		return errors.New("coins can be offered by one side only")
+	}
+	if len(terms.OfferItems) == 0 && terms.OfferCoins == 0 {
+		return errors.New("offer at least one item or some coins")
+	}
+	if len(terms.RequestItems) == 0 && terms.RequestCoins == 0 {
+		return errors.New("request at least one item or some coins")
+	}
+	if len(terms.OfferItems) == 0 && len(terms.RequestItems) == 0 {
+		return errors.New("trade must include at least one item, send coins instead")
+	}
+
+	seen := make(map[int64]bool)
+	for _, id := range append(append([]int64(nil), terms.OfferItems...), terms.RequestItems...) {
+		if seen[id] {
+			return fmt.Errorf("item %d is listed more than once", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+func tradeItemIDs(items []models.TradeItem) []int64 {
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.UserMerchID
+	}
+	return ids
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTradeService_ProposeTrade(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockTradeRepo := new(MockTradeRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	service := NewTradeService(new(MockTransactor), mockUserRepo, mockUserMerchRepo, mockOrderRepo, new(MockTransactionRepository), mockTradeRepo, policy, NewEscrowService(new(MockTransactor), new(MockHoldRepository)), 72*time.Hour)
+
+	ctx := context.Background()
+	aliceID, bobID := int64(1), int64(2)
+	orderID := int64(7)
+	pendingOrderID := int64(8)
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByUsername", ctx, "bob").Return(&models.User{ID: bobID, Username: "bob"}, nil)
+	mockUserRepo.On("GetByUsername", ctx, "alice").Return(&models.User{ID: aliceID, Username: "alice"}, nil)
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{10, 20}).Return([]models.UserMerch{
+		{ID: 10, UserID: aliceID, MerchID: 1, OrderID: &orderID, Status: models.UserMerchOwned},
+		{ID: 20, UserID: bobID, MerchID: 2, Status: models.UserMerchOwned},
+	}, nil)
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{10, 21}).Return([]models.UserMerch{
+		{ID: 10, UserID: aliceID, MerchID: 1, OrderID: &orderID, Status: models.UserMerchOwned},
+		{ID: 21, UserID: bobID, MerchID: 2, OrderID: &pendingOrderID, Status: models.UserMerchOwned},
+	}, nil)
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{20}).Return([]models.UserMerch{
+		{ID: 20, UserID: bobID, MerchID: 2, Status: models.UserMerchOwned},
+	}, nil)
+	mockOrderRepo.On("GetByID", ctx, orderID).Return(&models.Order{ID: orderID, Status: models.OrderDelivered}, nil)
+	mockOrderRepo.On("GetByID", ctx, pendingOrderID).Return(&models.Order{ID: pendingOrderID, Status: models.OrderShipped}, nil)
+	mockTradeRepo.On("Create", ctx, mock.MatchedBy(func(trade *models.Trade) bool {
+		return trade.ProposerID == aliceID && trade.RecipientID == bobID &&
+			len(trade.OfferedItems) == 1 && trade.OfferedItems[0].OwnerID == aliceID &&
+			len(trade.RequestedItems) == 1 && trade.RequestedItems[0].OwnerID == bobID
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Trade).ID = 5
+	}).Return(nil)
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&models.Trade{ID: 5, ProposerID: aliceID, RecipientID: bobID, Status: models.TradePending}, nil)
+
+	// Вызываем тестируемый метод
+	trade, err := service.ProposeTrade(ctx, aliceID, models.CreateTradeRequest{
+		ToUser:     "bob",
+		TradeTerms: models.TradeTerms{OfferItems: []int64{10}, RequestItems: []int64{20}},
+	})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), trade.ID)
+
+	// Чужой предмет предложить нельзя
+	_, err = service.ProposeTrade(ctx, aliceID, models.CreateTradeRequest{
+		ToUser:     "bob",
+		TradeTerms: models.TradeTerms{OfferItems: []int64{20}, RequestCoins: 10},
+	})
+	assert.ErrorContains(t, err, "item 20 is not available")
+
+	// Предмет из недоставленного заказа обменять нельзя
+	_, err = service.ProposeTrade(ctx, aliceID, models.CreateTradeRequest{
+		ToUser:     "bob",
+		TradeTerms: models.TradeTerms{OfferItems: []int64{10}, RequestItems: []int64{21}},
+	})
+	assert.ErrorContains(t, err, "until its order is delivered")
+
+	// Обмен монет на монеты не имеет смысла
+	_, err = service.ProposeTrade(ctx, aliceID, models.CreateTradeRequest{
+		ToUser:     "bob",
+		TradeTerms: models.TradeTerms{OfferCoins: 10},
+	})
+	assert.Error(t, err)
+
+	_, err = service.ProposeTrade(ctx, aliceID, models.CreateTradeRequest{
+		ToUser:     "alice",
+		TradeTerms: models.TradeTerms{OfferItems: []int64{10}, RequestCoins: 10},
+	})
+	assert.ErrorContains(t, err, "yourself")
+
+	mockTradeRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestTradeService_AcceptTrade(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTradeRepo := new(MockTradeRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTradeService(new(MockTransactor), mockUserRepo, mockUserMerchRepo, new(MockOrderRepository), mockTransactionRepo, mockTradeRepo, policy, NewEscrowService(new(MockTransactor), new(MockHoldRepository)), 72*time.Hour)
+
+	ctx := context.Background()
+	aliceID, bobID := int64(1), int64(2)
+
+	// Настраиваем моки
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&models.Trade{
+		ID: 5, ProposerID: aliceID, RecipientID: bobID, RequestedCoins: 50, Status: models.TradePending,
+		OfferedItems: []models.TradeItem{{UserMerchID: 10, OwnerID: aliceID}, {UserMerchID: 11, OwnerID: aliceID}},
+	}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, aliceID).Return(&models.User{ID: aliceID, Username: "alice", Coins: 100}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, bobID).Return(&models.User{ID: bobID, Username: "bob", Coins: 100}, nil)
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{10, 11}).Return([]models.UserMerch{
+		{ID: 10, UserID: aliceID, MerchID: 1, Status: models.UserMerchOwned},
+		{ID: 11, UserID: aliceID, MerchID: 1, Status: models.UserMerchOwned},
+	}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, bobID, int64(-50)).Return(nil)
+	mockUserRepo.On("UpdateCoins", ctx, aliceID, int64(50)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == bobID && transaction.ToUserID == aliceID &&
+			transaction.Amount == 50 && transaction.Kind == models.TransactionTrade
+	})).Return(nil)
+	mockUserMerchRepo.On("Transfer", ctx, []int64{10, 11}, bobID).Return(nil)
+	mockTradeRepo.On("SetStatus", ctx, int64(5), models.TradeAccepted).Return(nil)
+
+	// Вызываем тестируемый метод: принять предложение может только получатель
+	_, err := service.AcceptTrade(ctx, aliceID, 5)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "trade not found")
+
+	trade, err := service.AcceptTrade(ctx, bobID, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.TradeAccepted, trade.Status)
+	mockUserRepo.AssertExpectations(t)
+	mockUserMerchRepo.AssertExpectations(t)
+	mockTransactionRepo.AssertExpectations(t)
+	mockTradeRepo.AssertExpectations(t)
+}
+
+func TestTradeService_AcceptTrade_ItemGone(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTradeRepo := new(MockTradeRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	service := NewTradeService(new(MockTransactor), mockUserRepo, mockUserMerchRepo, new(MockOrderRepository), new(MockTransactionRepository), mockTradeRepo, policy, NewEscrowService(new(MockTransactor), new(MockHoldRepository)), 72*time.Hour)
+
+	ctx := context.Background()
+	aliceID, bobID := int64(1), int64(2)
+
+	// Настраиваем моки
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&models.Trade{
+		ID: 5, ProposerID: aliceID, RecipientID: bobID, RequestedCoins: 50, Status: models.TradePending,
+		OfferedItems: []models.TradeItem{{UserMerchID: 10, OwnerID: aliceID}},
+	}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, aliceID).Return(&models.User{ID: aliceID}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, bobID).Return(&models.User{ID: bobID}, nil)
+	// Предмет успели вернуть в магазин
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{10}).Return([]models.UserMerch{
+		{ID: 10, UserID: aliceID, MerchID: 1, Status: models.UserMerchReturned},
+	}, nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.AcceptTrade(ctx, bobID, 5)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "not available")
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything, mock.Anything)
+	mockUserMerchRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything)
+	mockTradeRepo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTradeService_CounterTrade(t *testing.T) {
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTradeRepo := new(MockTradeRepository)
+	mockHoldRepo := new(MockHoldRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	service := NewTradeService(new(MockTransactor), new(MockUserRepository), mockUserMerchRepo, new(MockOrderRepository), new(MockTransactionRepository), mockTradeRepo, policy, NewEscrowService(new(MockTransactor), mockHoldRepo), 72*time.Hour)
+
+	ctx := context.Background()
+	aliceID, bobID := int64(1), int64(2)
+
+	// Настраиваем моки
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&models.Trade{
+		ID: 5, ProposerID: aliceID, Proposer: "alice", RecipientID: bobID, OfferedCoins: 30, HoldID: holdID(40), Status: models.TradePending,
+		RequestedItems: []models.TradeItem{{UserMerchID: 20, OwnerID: bobID}},
+	}, nil).Once()
+	mockTradeRepo.On("SetStatus", ctx, int64(5), models.TradeCountered).Return(nil)
+	// Встречное предложение закрывает исходное, и удержанные монеты возвращаются инициатору
+	mockHoldRepo.On("Resolve", ctx, int64(40), models.HoldReleased).Return(&models.CoinHold{ID: 40, UserID: aliceID, Amount: 30}, nil)
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{20}).Return([]models.UserMerch{
+		{ID: 20, UserID: bobID, MerchID: 2, Status: models.UserMerchOwned},
+	}, nil)
+	mockTradeRepo.On("Create", ctx, mock.MatchedBy(func(trade *models.Trade) bool {
+		return trade.ProposerID == bobID && trade.RecipientID == aliceID && *trade.CounterOf == 5 &&
+			trade.RequestedCoins == 60 && len(trade.OfferedItems) == 1
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Trade).ID = 6
+	}).Return(nil)
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(6)).Return(&models.Trade{ID: 6, ProposerID: bobID, RecipientID: aliceID, Status: models.TradePending}, nil)
+
+	// Вызываем тестируемый метод
+	trade, err := service.CounterTrade(ctx, bobID, 5, models.TradeTerms{OfferItems: []int64{20}, RequestCoins: 60})
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), trade.ID)
+	mockTradeRepo.AssertExpectations(t)
+	mockHoldRepo.AssertExpectations(t)
+}
+
+func TestTradeService_OfferedCoinsHeld(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockUserMerchRepo := new(MockUserMerchRepository)
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockTradeRepo := new(MockTradeRepository)
+	mockHoldRepo := new(MockHoldRepository)
+
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{})
+	service := NewTradeService(new(MockTransactor), mockUserRepo, mockUserMerchRepo, new(MockOrderRepository), mockTransactionRepo, mockTradeRepo, policy, NewEscrowService(new(MockTransactor), mockHoldRepo), 72*time.Hour)
+
+	ctx := context.Background()
+	aliceID, bobID := int64(1), int64(2)
+
+	// Настраиваем моки
+	mockUserRepo.On("GetByUsername", ctx, "bob").Return(&models.User{ID: bobID, Username: "bob"}, nil)
+	mockUserMerchRepo.On("GetByIDsForUpdate", ctx, []int64{20}).Return([]models.UserMerch{
+		{ID: 20, UserID: bobID, MerchID: 2, Status: models.UserMerchOwned},
+	}, nil)
+	mockHoldRepo.On("Create", ctx, mock.MatchedBy(func(hold *models.CoinHold) bool {
+		return hold.UserID == aliceID && hold.Amount == 40 && hold.Reason == "Trade offer to bob"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.CoinHold).ID = 30
+	}).Return(nil)
+	mockTradeRepo.On("Create", ctx, mock.MatchedBy(func(trade *models.Trade) bool {
+		return trade.OfferedCoins == 40 && *trade.HoldID == 30
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Trade).ID = 5
+	}).Return(nil)
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&models.Trade{
+		ID: 5, ProposerID: aliceID, RecipientID: bobID, OfferedCoins: 40, HoldID: holdID(30), Status: models.TradePending,
+		RequestedItems: []models.TradeItem{{UserMerchID: 20, OwnerID: bobID}},
+	}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, aliceID).Return(&models.User{ID: aliceID, Username: "alice", Coins: 100, HeldCoins: 40}, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, bobID).Return(&models.User{ID: bobID, Username: "bob"}, nil)
+	// При принятии монеты списываются из удержания, а не с доступного баланса
+	mockHoldRepo.On("Capture", ctx, int64(30)).Return(&models.CoinHold{ID: 30, UserID: aliceID, Amount: 40}, nil)
+	mockUserRepo.On("UpdateCoins", ctx, bobID, int64(40)).Return(nil)
+	mockTransactionRepo.On("Create", ctx, mock.MatchedBy(func(transaction *models.Transaction) bool {
+		return transaction.FromUserID == aliceID && transaction.ToUserID == bobID && transaction.Amount == 40
+	})).Return(nil)
+	mockUserMerchRepo.On("Transfer", ctx, []int64{20}, aliceID).Return(nil)
+	mockTradeRepo.On("SetStatus", ctx, int64(5), models.TradeAccepted).Return(nil)
+
+	// Вызываем тестируемый метод
+	_, err := service.ProposeTrade(ctx, aliceID, models.CreateTradeRequest{
+		ToUser:     "bob",
+		TradeTerms: models.TradeTerms{OfferCoins: 40, RequestItems: []int64{20}},
+	})
+	assert.NoError(t, err)
+
+	_, err = service.AcceptTrade(ctx, bobID, 5)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockHoldRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "UpdateCoins", ctx, aliceID, int64(-40))
+	mockTransactionRepo.AssertExpectations(t)
+	mockTradeRepo.AssertExpectations(t)
+}
+
+func TestTradeService_RejectTrade_Expired(t *testing.T) {
+	mockTradeRepo := new(MockTradeRepository)
+
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+	service := NewTradeService(new(MockTransactor), new(MockUserRepository), new(MockUserMerchRepository), new(MockOrderRepository), new(MockTransactionRepository), mockTradeRepo, policy, NewEscrowService(new(MockTransactor), new(MockHoldRepository)), 72*time.Hour)
+
+	ctx := context.Background()
+
+	// Настраиваем моки
+	mockTradeRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&models.Trade{
+		ID: 5, ProposerID: 1, RecipientID: 2, Status: models.TradeExpired,
+	}, nil)
+
+	// Вызываем тестируемый метод
+	err := service.RejectTrade(ctx, 2, 5)
+
+	// Проверяем результаты
+	assert.ErrorContains(t, err, "already expired")
+	mockTradeRepo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+}
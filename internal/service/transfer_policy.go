@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+// Правила политики переводов
+const (
+	RuleSelfTransfer  = "self_transfer"
+	RuleMaxAmount     = "max_amount"
+	RuleDailyCap      = "daily_cap"
+	RuleWeeklyCap     = "weekly_cap"
+	RuleMaxRecipients = "max_recipients"
+	RuleNewAccount    = "new_account"
+	RuleAccountOnHold = "account_on_hold"
+)
+
+// PolicyViolationError возвращается, когда перевод нарушает правило политики
+type PolicyViolationError struct {
+	Rule    string
+	Message string
+}
+
+func (e *PolicyViolationError) Error() string {
+	return fmt.Sprintf("transfer rejected by %s policy: %s", e.Rule, e.Message)
+}
+
+type transferPolicyImpl struct {
+	transactionRepo repository.TransactionRepository
+	limits          config.TransferLimits
+}
+
+func NewTransferPolicy(transactionRepo repository.TransactionRepository, limits config.TransferLimits) TransferPolicy {
+	return &transferPolicyImpl{
+		transactionRepo: transactionRepo,
+		limits:          limits,
+	}
+}
+
+// Check проверяет перевод amount монет от sender к recipient.
+// Правила с нулевым лимитом пропускаются. Системные кошельки (например, кошельки команд)
+// только что созданы и платят многим участникам, поэтому правила нового аккаунта
+// и числа получателей к ним не применяются
+func (p *transferPolicyImpl) Check(ctx context.Context, sender, recipient *models.User, amount int64) error {
+	if sender.ID == recipient.ID {
+		return &PolicyViolationError{Rule: RuleSelfTransfer, Message: "cannot send coins to yourself"}
+	}
+
+	if sender.OnHold {
+		return &PolicyViolationError{Rule: RuleAccountOnHold, Message: "outgoing transfers are on hold pending review"}
+	}
+
+	if p.limits.MaxAmount > 0 && amount > p.limits.MaxAmount {
+		return &PolicyViolationError{
+			Rule:    RuleMaxAmount,
+			Message: fmt.Sprintf("amount %d exceeds the per-transfer maximum of %d", amount, p.limits.MaxAmount),
+		}
+	}
+
+	if p.limits.NewAccountCooldown > 0 && !sender.IsSystem {
+		allowedAt := sender.CreatedAt.Add(p.limits.NewAccountCooldown)
+		if time.Now().Before(allowedAt) {
+			return &PolicyViolationError{
+				Rule:    RuleNewAccount,
+				Message: fmt.Sprintf("new accounts can send coins after %s", allowedAt.Format(time.RFC3339)),
+			}
+		}
+	}
+
+	now := time.Now()
+
+	if p.limits.DailyCap > 0 || p.limits.MaxRecipientsPerDay > 0 {
+		daily, err := p.transactionRepo.GetOutgoingStats(ctx, sender.ID, recipient.ID, now.Add(-24*time.Hour))
+		if err != nil {
+			return fmt.Errorf("failed to get daily transfer stats: %w", err)
+		}
+
+		if p.limits.DailyCap > 0 && daily.Total+amount > p.limits.DailyCap {
+			return &PolicyViolationError{
+				Rule:    RuleDailyCap,
+				Message: fmt.Sprintf("daily limit of %d coins exceeded, %d left", p.limits.DailyCap, remaining(p.limits.DailyCap, daily.Total)),
+			}
+		}
+
+		if p.limits.MaxRecipientsPerDay > 0 && !sender.IsSystem && !daily.HasRecipient && daily.Recipients >= p.limits.MaxRecipientsPerDay {
+			return &PolicyViolationError{
+				Rule:    RuleMaxRecipients,
+				Message: fmt.Sprintf("cannot send coins to more than %d different users per day", p.limits.MaxRecipientsPerDay),
+			}
+		}
+	}
+
+	if p.limits.WeeklyCap > 0 {
+		weekly, err := p.transactionRepo.GetOutgoingStats(ctx, sender.ID, recipient.ID, now.AddDate(0, 0, -7))
+		if err != nil {
+			return fmt.Errorf("failed to get weekly transfer stats: %w", err)
+		}
+
+		if weekly.Total+amount > p.limits.WeeklyCap {
+			return &PolicyViolationError{
+				Rule:    RuleWeeklyCap,
+				Message: fmt.Sprintf("weekly limit of %d coins exceeded, %d left", p.limits.WeeklyCap, remaining(p.limits.WeeklyCap, weekly.Total)),
+			}
+		}
+	}
+
+	return nil
+}
+
+// remaining возвращает остаток лимита, не опускаясь ниже нуля
+func remaining(limit, used int64) int64 {
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
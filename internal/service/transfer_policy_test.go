@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTransferPolicy_SelfTransfer(t *testing.T) {
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+
+	user := &models.User{ID: 1, Username: "user"}
+
+	// Вызываем тестируемый метод
+	err := policy.Check(context.Background(), user, user, 10)
+
+	// Проверяем результаты
+	var violation *PolicyViolationError
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, RuleSelfTransfer, violation.Rule)
+}
+
+func TestTransferPolicy_MaxAmount(t *testing.T) {
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{MaxAmount: 100})
+
+	sender := &models.User{ID: 1}
+	recipient := &models.User{ID: 2}
+
+	// Вызываем тестируемый метод
+	err := policy.Check(context.Background(), sender, recipient, 101)
+
+	// Проверяем результаты
+	var violation *PolicyViolationError
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, RuleMaxAmount, violation.Rule)
+}
+
+func TestTransferPolicy_NewAccount(t *testing.T) {
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{NewAccountCooldown: 24 * time.Hour})
+
+	sender := &models.User{ID: 1, CreatedAt: time.Now().Add(-time.Hour)}
+	recipient := &models.User{ID: 2}
+
+	// Вызываем тестируемый метод
+	err := policy.Check(context.Background(), sender, recipient, 10)
+
+	// Проверяем результаты
+	var violation *PolicyViolationError
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, RuleNewAccount, violation.Rule)
+}
+
+func TestTransferPolicy_DailyCap(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{DailyCap: 500})
+
+	ctx := context.Background()
+	sender := &models.User{ID: 1}
+	recipient := &models.User{ID: 2}
+
+	// Настраиваем моки: за сутки уже отправлено 450 монет
+	mockTransactionRepo.On("GetOutgoingStats", ctx, sender.ID, recipient.ID, mock.AnythingOfType("time.Time")).
+		Return(&models.TransferStats{Total: 450, Recipients: 1}, nil)
+
+	// Вызываем тестируемый метод
+	err := policy.Check(ctx, sender, recipient, 100)
+
+	// Проверяем результаты
+	var violation *PolicyViolationError
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, RuleDailyCap, violation.Rule)
+	assert.Contains(t, violation.Message, "50 left")
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestTransferPolicy_MaxRecipients(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{MaxRecipientsPerDay: 3})
+
+	ctx := context.Background()
+	sender := &models.User{ID: 1}
+	known := &models.User{ID: 2}
+	stranger := &models.User{ID: 5}
+
+	// Настраиваем моки: за сутки уже три получателя, среди них known
+	mockTransactionRepo.On("GetOutgoingStats", ctx, sender.ID, known.ID, mock.AnythingOfType("time.Time")).
+		Return(&models.TransferStats{Total: 30, Recipients: 3, HasRecipient: true}, nil)
+	mockTransactionRepo.On("GetOutgoingStats", ctx, sender.ID, stranger.ID, mock.AnythingOfType("time.Time")).
+		Return(&models.TransferStats{Total: 30, Recipients: 3}, nil)
+
+	// Вызываем тестируемый метод
+	errKnown := policy.Check(ctx, sender, known, 10)
+	errStranger := policy.Check(ctx, sender, stranger, 10)
+
+	// Проверяем результаты
+	assert.NoError(t, errKnown)
+	var violation *PolicyViolationError
+	assert.True(t, errors.As(errStranger, &violation))
+	assert.Equal(t, RuleMaxRecipients, violation.Rule)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestTransferPolicy_SystemWallet(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	policy := NewTransferPolicy(mockTransactionRepo, config.TransferLimits{NewAccountCooldown: 24 * time.Hour, MaxRecipientsPerDay: 3})
+
+	ctx := context.Background()
+	// Кошелек только что созданной команды уже платил троим участникам
+	wallet := &models.User{ID: 1, IsSystem: true, CreatedAt: time.Now()}
+	member := &models.User{ID: 5}
+
+	// Настраиваем моки
+	mockTransactionRepo.On("GetOutgoingStats", ctx, wallet.ID, member.ID, mock.AnythingOfType("time.Time")).
+		Return(&models.TransferStats{Total: 30, Recipients: 3}, nil)
+
+	// Вызываем тестируемый метод
+	err := policy.Check(ctx, wallet, member, 10)
+
+	// Проверяем результаты
+	assert.NoError(t, err)
+	mockTransactionRepo.AssertExpectations(t)
+}
+
+func TestTransferPolicy_AccountOnHold(t *testing.T) {
+	policy := NewTransferPolicy(new(MockTransactionRepository), config.TransferLimits{})
+
+	sender := &models.User{ID: 1, OnHold: true}
+	recipient := &models.User{ID: 2}
+
+	// Вызываем тестируемый метод
+	err := policy.Check(context.Background(), sender, recipient, 10)
+
+	// Проверяем результаты
+	var violation *PolicyViolationError
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, RuleAccountOnHold, violation.Rule)
+}
@@ -5,21 +5,30 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/haqer0002/avito-shop/internal/events"
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/haqer0002/avito-shop/internal/repository"
 )
 
 type userServiceImpl struct {
+	transactor      repository.Transactor
 	userRepo        repository.UserRepository
 	transactionRepo repository.TransactionRepository
 	userMerchRepo   repository.UserMerchRepository
+	policy          TransferPolicy
+	expiry          CoinExpiryService
+	publisher       events.Publisher
 }
 
-func NewUserService(userRepo repository.UserRepository, transactionRepo repository.TransactionRepository, userMerchRepo repository.UserMerchRepository) UserService {
+func NewUserService(transactor repository.Transactor, userRepo repository.UserRepository, transactionRepo repository.TransactionRepository, userMerchRepo repository.UserMerchRepository, policy TransferPolicy, expiry CoinExpiryService, publisher events.Publisher) UserService {
 	return &userServiceImpl{
+		transactor:      transactor,
 		userRepo:        userRepo,
 		transactionRepo: transactionRepo,
 		userMerchRepo:   userMerchRepo,
+		policy:          policy,
+		expiry:          expiry,
+		publisher:       publisher,
 	}
 }
 
@@ -50,6 +59,20 @@ func (s *userServiceImpl) GetUserInfo(ctx context.Context, userID int64) (*model
 	}
 	log.Printf("Successfully got user merch: %+v", userMerch)
 
+	// Получаем полученные и отправленные подарки
+	gifts, err := s.userMerchRepo.GetGifts(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting gifts for user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to get gifts: %w", err)
+	}
+
+	// Получаем монеты, которые скоро сгорят
+	expiring, err := s.expiry.GetExpiringSoon(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting expiring coins for user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to get expiring coins: %w", err)
+	}
+
 	// Формируем историю транзакций
 	coinHistory := models.CoinTransactionHistory{
 		Received: make([]models.CoinTransaction, 0),
@@ -57,37 +80,76 @@ func (s *userServiceImpl) GetUserInfo(ctx context.Context, userID int64) (*model
 	}
 
 	for _, t := range transactions {
+		// Тип указывается только для операций, отличных от обычного перевода
+		kind := ""
+		if t.Kind != models.TransactionTransfer {
+			kind = t.Kind
+		}
+
 		if t.ToUserID == userID {
 			coinHistory.Received = append(coinHistory.Received, models.CoinTransaction{
+				ID:       t.ID,
 				FromUser: fmt.Sprint(t.FromUserID),
 				Amount:   t.Amount,
+				Kind:     kind,
 			})
 		} else {
 			coinHistory.Sent = append(coinHistory.Sent, models.CoinTransaction{
+				ID:     t.ID,
 				ToUser: fmt.Sprint(t.ToUserID),
 				Amount: t.Amount,
+				Kind:   kind,
 			})
 		}
 	}
 
-	// Формируем инвентарь
-	inventory := make(map[int64]int)
+	// Формируем инвентарь, разные варианты одного товара учитываются отдельно
+	type inventoryKey struct {
+		merchID int64
+		variant string
+	}
+	inventory := make(map[inventoryKey]int)
+	inventoryItems := make([]models.InventoryItem, 0)
 	for _, m := range userMerch {
-		inventory[m.MerchID]++
+		key := inventoryKey{merchID: m.MerchID}
+		if m.VariantSKU != nil {
+			key.variant = *m.VariantSKU
+		}
+
+		index, ok := inventory[key]
+		if !ok {
+			index = len(inventoryItems)
+			inventory[key] = index
+			inventoryItems = append(inventoryItems, models.InventoryItem{
+				Type:       fmt.Sprint(m.MerchID),
+				Variant:    key.variant,
+				Attributes: m.Attributes,
+			})
+		}
+		inventoryItems[index].Quantity++
+		inventoryItems[index].ItemIDs = append(inventoryItems[index].ItemIDs, m.ID)
 	}
 
-	inventoryItems := make([]models.InventoryItem, 0, len(inventory))
-	for merchID, quantity := range inventory {
-		inventoryItems = append(inventoryItems, models.InventoryItem{
-			Type:     fmt.Sprint(merchID),
-			Quantity: quantity,
-		})
+	giftHistory := models.GiftHistory{
+		Received: make([]models.Gift, 0),
+		Sent:     make([]models.Gift, 0),
+	}
+	for _, g := range gifts {
+		if g.ToUserID == userID {
+			giftHistory.Received = append(giftHistory.Received, g)
+		} else {
+			giftHistory.Sent = append(giftHistory.Sent, g)
+		}
 	}
 
 	response := &models.InfoResponse{
-		Coins:       user.Coins,
-		Inventory:   inventoryItems,
-		CoinHistory: coinHistory,
+		Coins:          user.Coins,
+		AvailableCoins: user.AvailableCoins(),
+		HeldCoins:      user.HeldCoins,
+		ExpiringSoon:   expiring,
+		Inventory:      inventoryItems,
+		CoinHistory:    coinHistory,
+		Gifts:          giftHistory,
 	}
 	log.Printf("Successfully prepared response: %+v", response)
 	return response, nil
@@ -100,32 +162,50 @@ func (s *userServiceImpl) SendCoins(ctx context.Context, fromUserID int64, toUse
 		return fmt.Errorf("recipient not found: %w", err)
 	}
 
-	// Проверяем достаточность средств и списываем монеты у отправителя
-	err = s.userRepo.UpdateCoins(ctx, fromUserID, -amount)
-	if err != nil {
-		return fmt.Errorf("failed to deduct coins from sender: %w", err)
-	}
+	// Списание, начисление и запись о транзакции выполняются атомарно
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Блокируем отправителя, чтобы параллельные переводы не обошли лимиты
+		fromUser, err := s.userRepo.GetByIDForUpdate(ctx, fromUserID)
+		if err != nil {
+			return fmt.Errorf("sender not found: %w", err)
+		}
 
-	// Начисляем монеты получателю
-	err = s.userRepo.UpdateCoins(ctx, toUser.ID, amount)
-	if err != nil {
-		// В случае ошибки возвращаем монеты отправителю
-		_ = s.userRepo.UpdateCoins(ctx, fromUserID, amount)
-		return fmt.Errorf("failed to add coins to recipient: %w", err)
-	}
+		// Проверяем перевод по правилам политики
+		if err := s.policy.Check(ctx, fromUser, toUser, amount); err != nil {
+			return err
+		}
 
-	// Создаем запись о транзакции
-	transaction := &models.Transaction{
-		FromUserID:  fromUserID,
-		ToUserID:    toUser.ID,
-		Amount:      amount,
-		Description: fmt.Sprintf("Transfer from user %d to user %s", fromUserID, toUsername),
-	}
+		// Проверяем достаточность средств и списываем монеты у отправителя
+		err = s.userRepo.UpdateCoins(ctx, fromUserID, -amount)
+		if err != nil {
+			return fmt.Errorf("failed to deduct coins from sender: %w", err)
+		}
+
+		// Начисляем монеты получателю
+		err = s.userRepo.UpdateCoins(ctx, toUser.ID, amount)
+		if err != nil {
+			return fmt.Errorf("failed to add coins to recipient: %w", err)
+		}
+
+		// Создаем запись о транзакции
+		transaction := &models.Transaction{
+			FromUserID:  fromUserID,
+			ToUserID:    toUser.ID,
+			Amount:      amount,
+			Description: fmt.Sprintf("Transfer from user %d to user %s", fromUserID, toUsername),
+		}
+
+		err = s.transactionRepo.Create(ctx, transaction)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction record: %w", err)
+		}
 
-	err = s.transactionRepo.Create(ctx, transaction)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create transaction record: %w", err)
+		return err
 	}
 
+	s.publisher.Publish(ctx, events.CoinsSent{FromUserID: fromUserID, ToUserID: toUser.ID, Amount: amount})
 	return nil
 }
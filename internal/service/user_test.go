@@ -5,6 +5,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/haqer0002/avito-shop/internal/config"
+	"github.com/haqer0002/avito-shop/internal/events"
 	"github.com/haqer0002/avito-shop/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -15,7 +17,7 @@ func TestUserService_SendCoins(t *testing.T) {
 	mockTransactionRepo := new(MockTransactionRepository)
 	mockUserMerchRepo := new(MockUserMerchRepository)
 
-	service := NewUserService(mockUserRepo, mockTransactionRepo, mockUserMerchRepo)
+	service := NewUserService(new(MockTransactor), mockUserRepo, mockTransactionRepo, mockUserMerchRepo, NewTransferPolicy(mockTransactionRepo, config.TransferLimits{}), noCoinExpiry(), events.NewBus())
 
 	ctx := context.Background()
 	fromUserID := int64(1)
@@ -31,6 +33,7 @@ func TestUserService_SendCoins(t *testing.T) {
 
 	// Настраиваем моки
 	mockUserRepo.On("GetByUsername", ctx, toUsername).Return(recipient, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, fromUserID).Return(&models.User{ID: fromUserID, Username: "sender"}, nil)
 	mockUserRepo.On("UpdateCoins", ctx, fromUserID, -amount).Return(nil)
 	mockUserRepo.On("UpdateCoins", ctx, recipient.ID, amount).Return(nil)
 	mockTransactionRepo.On("Create", ctx, mock.AnythingOfType("*models.Transaction")).Return(nil)
@@ -49,7 +52,7 @@ func TestUserService_SendCoins_InsufficientFunds(t *testing.T) {
 	mockTransactionRepo := new(MockTransactionRepository)
 	mockUserMerchRepo := new(MockUserMerchRepository)
 
-	service := NewUserService(mockUserRepo, mockTransactionRepo, mockUserMerchRepo)
+	service := NewUserService(new(MockTransactor), mockUserRepo, mockTransactionRepo, mockUserMerchRepo, NewTransferPolicy(mockTransactionRepo, config.TransferLimits{}), noCoinExpiry(), events.NewBus())
 
 	ctx := context.Background()
 	fromUserID := int64(1)
@@ -65,6 +68,7 @@ func TestUserService_SendCoins_InsufficientFunds(t *testing.T) {
 
 	// Настраиваем моки
 	mockUserRepo.On("GetByUsername", ctx, toUsername).Return(recipient, nil)
+	mockUserRepo.On("GetByIDForUpdate", ctx, fromUserID).Return(&models.User{ID: fromUserID, Username: "sender"}, nil)
 	mockUserRepo.On("UpdateCoins", ctx, fromUserID, -amount).Return(errors.New("insufficient funds"))
 
 	// Вызываем тестируемый метод
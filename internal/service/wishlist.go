@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/haqer0002/avito-shop/internal/repository"
+)
+
+type wishlistServiceImpl struct {
+	transactor       repository.Transactor
+	userRepo         repository.UserRepository
+	merchRepo        repository.MerchRepository
+	wishlistRepo     repository.WishlistRepository
+	discountRepo     repository.DiscountRepository
+	notificationRepo repository.NotificationRepository
+}
+
+func NewWishlistService(transactor repository.Transactor, userRepo repository.UserRepository, merchRepo repository.MerchRepository, wishlistRepo repository.WishlistRepository, discountRepo repository.DiscountRepository, notificationRepo repository.NotificationRepository) WishlistService {
+	return &wishlistServiceImpl{
+		transactor:       transactor,
+		userRepo:         userRepo,
+		merchRepo:        merchRepo,
+		wishlistRepo:     wishlistRepo,
+		discountRepo:     discountRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+func (s *wishlistServiceImpl) AddItem(ctx context.Context, userID int64, merchName string) error {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return fmt.Errorf("merch not found: %w", err)
+	}
+
+	return s.wishlistRepo.Add(ctx, userID, merch.ID)
+}
+
+func (s *wishlistServiceImpl) RemoveItem(ctx context.Context, userID int64, merchName string) error {
+	merch, err := s.merchRepo.GetByName(ctx, merchName)
+	if err != nil {
+		return fmt.Errorf("merch not found: %w", err)
+	}
+
+	return s.wishlistRepo.Remove(ctx, userID, merch.ID)
+}
+
+// GetWishlist возвращает список желаний с ценами по действующим скидкам
+// и числом монет, которых не хватает до покупки каждого товара
+func (s *wishlistServiceImpl) GetWishlist(ctx context.Context, userID int64) (*models.Wishlist, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.wishlistRepo.GetItems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+
+	discounts, err := s.discountRepo.GetActive(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active discounts: %w", err)
+	}
+
+	for i := range items {
+		item := &items[i]
+
+		price := item.Price
+		if off, id := bestDiscount(discounts, wishlistOrderItem(item)); id != nil {
+			price -= off
+			item.SalePrice = &price
+		}
+
+		if available := user.AvailableCoins(); price > available {
+			item.CoinsNeeded = price - available
+		}
+	}
+
+	return &models.Wishlist{Balance: user.AvailableCoins(), Items: items}, nil
+}
+
+// Notify уведомляет пользователей о товарах из списков желаний,
+// которые снова появились в наличии или на которые началась скидка
+func (s *wishlistServiceImpl) Notify(ctx context.Context) error {
+	if err := s.notifyRestocked(ctx); err != nil {
+		return err
+	}
+
+	return s.notifySales(ctx)
+}
+
+// notifyRestocked запоминает изменения наличия и уведомляет о вернувшихся товарах
+func (s *wishlistServiceImpl) notifyRestocked(ctx context.Context) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		changed, err := s.wishlistRepo.SyncStock(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to sync wishlist stock: %w", err)
+		}
+
+		for _, item := range changed {
+			if !item.InStock {
+				continue
+			}
+
+			err := s.notify(ctx, item, models.NotificationBackInStock, fmt.Sprintf("%s is back in stock", item.DisplayName))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// notifySales уведомляет о скидках на товары из списков желаний.
+// О каждой скидке пользователь уведомляется один раз, после окончания скидки
+// о следующей скидке на тот же товар он будет уведомлен снова
+func (s *wishlistServiceImpl) notifySales(ctx context.Context) error {
+	discounts, err := s.discountRepo.GetActive(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get active discounts: %w", err)
+	}
+
+	items, err := s.wishlistRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get wishlists: %w", err)
+	}
+
+	for i := range items {
+		item := &items[i]
+
+		off, discountID := bestDiscount(discounts, wishlistOrderItem(item))
+		if sameID(discountID, item.NotifiedDiscountID) {
+			continue
+		}
+
+		err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			if discountID != nil {
+				message := fmt.Sprintf("%s is on sale: %d coins instead of %d", item.DisplayName, item.Price-off, item.Price)
+				if err := s.notify(ctx, *item, models.NotificationOnSale, message); err != nil {
+					return err
+				}
+			}
+
+			return s.wishlistRepo.SetNotifiedDiscount(ctx, item.UserID, item.MerchID, discountID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to notify about sale: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *wishlistServiceImpl) notify(ctx context.Context, item models.WishlistItem, kind, message string) error {
+	notification := &models.Notification{
+		UserID:  item.UserID,
+		Kind:    kind,
+		MerchID: &item.MerchID,
+		Message: message,
+	}
+
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+// wishlistOrderItem представляет товар из списка желаний позицией заказа для расчета скидки
+func wishlistOrderItem(item *models.WishlistItem) *models.OrderItem {
+	orderItem := &models.OrderItem{
+		MerchID:   item.MerchID,
+		BasePrice: item.Price,
+	}
+	if item.Category != nil {
+		orderItem.Category = *item.Category
+	}
+	return orderItem
+}
+
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haqer0002/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWishlistService_GetWishlist(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockDiscountRepo := new(MockDiscountRepository)
+
+	service := NewWishlistService(new(MockTransactor), mockUserRepo, new(MockMerchRepository), mockWishlistRepo, mockDiscountRepo, new(MockNotificationRepository))
+
+	ctx := context.Background()
+	userID := int64(1)
+	apparel := "apparel"
+	percent := 50
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(&models.User{ID: userID, Coins: 300}, nil)
+	mockWishlistRepo.On("GetItems", ctx, userID).Return([]models.WishlistItem{
+		{UserID: userID, MerchID: 10, Name: "pink-hoody", Category: &apparel, Price: 500, InStock: true},
+		{UserID: userID, MerchID: 2, Name: "cup", Price: 20, InStock: false},
+		{UserID: userID, MerchID: 5, Name: "powerbank", Price: 400, InStock: true},
+	}, nil)
+	mockDiscountRepo.On("GetActive", ctx, mock.Anything).Return([]models.Discount{
+		{ID: 7, Category: &apparel, Percent: &percent},
+	}, nil)
+
+	wishlist, err := service.GetWishlist(ctx, userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), wishlist.Balance)
+	assert.Equal(t, int64(250), *wishlist.Items[0].SalePrice)
+	assert.Equal(t, int64(0), wishlist.Items[0].CoinsNeeded)
+	assert.Equal(t, int64(0), wishlist.Items[1].CoinsNeeded)
+	assert.Nil(t, wishlist.Items[2].SalePrice)
+	assert.Equal(t, int64(100), wishlist.Items[2].CoinsNeeded)
+}
+
+func TestWishlistService_Notify(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockDiscountRepo := new(MockDiscountRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+
+	service := NewWishlistService(new(MockTransactor), new(MockUserRepository), new(MockMerchRepository), mockWishlistRepo, mockDiscountRepo, mockNotificationRepo)
+
+	ctx := context.Background()
+	amount := int64(100)
+	hoodyID := int64(10)
+	discountID := int64(3)
+	otherDiscountID := int64(1)
+
+	// Худи вернулось в наличие, кружка закончилась
+	mockWishlistRepo.On("SyncStock", ctx).Return([]models.WishlistItem{
+		{UserID: 1, MerchID: 10, DisplayName: "Розовое худи", InStock: true},
+		{UserID: 2, MerchID: 2, DisplayName: "Кружка", InStock: false},
+	}, nil)
+	mockDiscountRepo.On("GetActive", ctx, mock.Anything).Return([]models.Discount{
+		{ID: discountID, MerchID: &hoodyID, Amount: &amount},
+	}, nil)
+	mockWishlistRepo.On("GetAll", ctx).Return([]models.WishlistItem{
+		// Уже уведомлен об этой скидке
+		{UserID: 1, MerchID: 10, DisplayName: "Розовое худи", Price: 500, NotifiedDiscountID: &discountID},
+		// Новая скидка
+		{UserID: 3, MerchID: 10, DisplayName: "Розовое худи", Price: 500, NotifiedDiscountID: &otherDiscountID},
+		// Скидка закончилась
+		{UserID: 2, MerchID: 2, DisplayName: "Кружка", Price: 20, NotifiedDiscountID: &otherDiscountID},
+	}, nil)
+
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(n *models.Notification) bool {
+		return n.UserID == 1 && n.Kind == models.NotificationBackInStock && n.Message == "Розовое худи is back in stock"
+	})).Return(nil).Once()
+	mockNotificationRepo.On("Create", ctx, mock.MatchedBy(func(n *models.Notification) bool {
+		return n.UserID == 3 && n.Kind == models.NotificationOnSale && n.Message == "Розовое худи is on sale: 400 coins instead of 500"
+	})).Return(nil).Once()
+	mockWishlistRepo.On("SetNotifiedDiscount", ctx, int64(3), int64(10), &discountID).Return(nil).Once()
+	mockWishlistRepo.On("SetNotifiedDiscount", ctx, int64(2), int64(2), (*int64)(nil)).Return(nil).Once()
+
+	err := service.Notify(ctx)
+
+	assert.NoError(t, err)
+	mockNotificationRepo.AssertExpectations(t)
+	mockWishlistRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertNumberOfCalls(t, "Create", 2)
+}
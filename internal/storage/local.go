@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore хранит объекты в каталоге локальной файловой системы.
+// Объекты раздаются самим сервером по префиксу urlPrefix
+type LocalStore struct {
+	dir       string
+	urlPrefix string
+}
+
+// NewLocalStore создает новый экземпляр LocalStore
+func NewLocalStore(dir, urlPrefix string) *LocalStore {
+	return &LocalStore{
+		dir:       dir,
+		urlPrefix: strings.TrimSuffix(urlPrefix, "/"),
+	}
+}
+
+// Put сохраняет объект, атомарно заменяя существующий
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Пишем во временный файл, чтобы клиенты не увидели частично записанный объект
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// Open открывает объект для чтения
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete удаляет объект, отсутствие объекта не считается ошибкой
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL возвращает адрес объекта на сервере
+func (s *LocalStore) URL(key string) string {
+	return s.urlPrefix + "/" + key
+}
+
+// path преобразует ключ в путь внутри каталога хранилища, не позволяя выйти за его пределы
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
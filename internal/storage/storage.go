@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound возвращается, если объект с указанным ключом не существует
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore определяет хранилище двоичных объектов, например изображений товаров.
+// Ключ — относительный путь вида merch/1/abc.jpg
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL возвращает постоянный адрес, по которому объект доступен клиентам
+	URL(key string) string
+}
@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Run периодически выполняет fn, пока не будет отменен контекст.
+// Ошибки запуска логируются и не останавливают цикл
+func Run(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	log.Printf("Starting worker %s with interval %s", name, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Worker %s stopped", name)
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Printf("Worker %s failed: %v", name, err)
+			}
+		}
+	}
+}
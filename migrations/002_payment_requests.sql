@@ -0,0 +1,15 @@
+-- Создание таблицы запросов на перевод монет
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id SERIAL PRIMARY KEY,
+    requester_id BIGINT NOT NULL REFERENCES users(id),
+    payer_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    comment TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer ON payment_requests (payer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester ON payment_requests (requester_id, created_at DESC);
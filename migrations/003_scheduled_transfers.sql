@@ -0,0 +1,19 @@
+-- Создание таблицы запланированных и регулярных переводов
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id SERIAL PRIMARY KEY,
+    from_user_id BIGINT NOT NULL REFERENCES users(id),
+    to_user_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    comment TEXT NOT NULL DEFAULT '',
+    recurrence VARCHAR(16) NOT NULL DEFAULT 'once',
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    next_run_at TIMESTAMP NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индекс для выборки переводов, которые пора выполнить
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers (next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_user ON scheduled_transfers (from_user_id, created_at DESC);
@@ -0,0 +1,9 @@
+-- Дата регистрации нужна для ограничения переводов с новых аккаунтов.
+-- Существующие пользователи не считаются новыми
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMP;
+UPDATE users SET created_at = TIMESTAMP 'epoch' WHERE created_at IS NULL;
+ALTER TABLE users ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ALTER COLUMN created_at SET NOT NULL;
+
+-- Индекс для подсчета исходящих переводов за период
+CREATE INDEX IF NOT EXISTS idx_transactions_from_user_created ON transactions (from_user_id, created_at);
@@ -0,0 +1,23 @@
+-- Роль администратора и блокировка исходящих переводов
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS transfers_on_hold BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Создание таблицы подозрительных операций
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    transaction_id BIGINT REFERENCES transactions(id),
+    rule VARCHAR(32) NOT NULL,
+    score INT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    hold_applied BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_by BIGINT REFERENCES users(id),
+    reviewed_at TIMESTAMP
+);
+
+-- По каждому правилу у пользователя может быть только одно открытое оповещение
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_open_user_rule ON alerts (user_id, rule) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions (created_at);
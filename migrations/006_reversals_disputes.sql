@@ -0,0 +1,23 @@
+-- Тип транзакции и ссылка на отменяемую транзакцию
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS kind VARCHAR(32) NOT NULL DEFAULT 'transfer';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of BIGINT REFERENCES transactions(id);
+
+-- Транзакцию можно отменить только один раз
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of ON transactions (reversal_of) WHERE reversal_of IS NOT NULL;
+
+-- Создание таблицы споров по переводам
+CREATE TABLE IF NOT EXISTS disputes (
+    id SERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id),
+    opened_by BIGINT NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    resolution TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_by BIGINT REFERENCES users(id),
+    resolved_at TIMESTAMP
+);
+
+-- По транзакции может быть открыт только один спор
+CREATE UNIQUE INDEX IF NOT EXISTS idx_disputes_open_transaction ON disputes (transaction_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_disputes_status ON disputes (status, created_at);
@@ -0,0 +1,26 @@
+-- Создание таблицы заказов
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(32) NOT NULL DEFAULT 'placed',
+    total BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы позиций заказа
+CREATE TABLE IF NOT EXISTS order_items (
+    id SERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    price BIGINT NOT NULL
+);
+
+-- Купленный мерч привязывается к заказу; отмененные позиции не удаляются
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS order_id BIGINT REFERENCES orders(id);
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'owned';
+
+CREATE INDEX IF NOT EXISTS idx_orders_user ON orders (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS idx_user_merch_user ON user_merch (user_id) WHERE status = 'owned';
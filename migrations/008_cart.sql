@@ -0,0 +1,11 @@
+-- Остаток товара на складе; NULL означает неограниченное количество
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS stock INT CHECK (stock >= 0);
+
+-- Создание таблицы корзины
+CREATE TABLE IF NOT EXISTS cart_items (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, merch_id)
+);
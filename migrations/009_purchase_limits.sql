@@ -0,0 +1,7 @@
+-- Ограничение покупок товара одним пользователем; NULL означает отсутствие ограничения.
+-- Период: day, week или month; NULL означает ограничение на все время
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS purchase_limit INT CHECK (purchase_limit > 0);
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS purchase_limit_period VARCHAR(16)
+    CHECK (purchase_limit_period IN ('day', 'week', 'month'));
+
+CREATE INDEX IF NOT EXISTS idx_user_merch_user_merch ON user_merch (user_id, merch_id, created_at) WHERE status = 'owned';
@@ -0,0 +1,15 @@
+-- Создание таблицы вариантов товара (размер, цвет и т.д.)
+-- price переопределяет цену товара, stock учитывается отдельно для каждого варианта
+CREATE TABLE IF NOT EXISTS merch_variants (
+    id SERIAL PRIMARY KEY,
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    sku VARCHAR(64) UNIQUE NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    price BIGINT CHECK (price > 0),
+    stock INT CHECK (stock >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_variants_merch ON merch_variants (merch_id);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES merch_variants(id);
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES merch_variants(id);
@@ -0,0 +1,57 @@
+-- Категория товара для скидок на группу товаров
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS category VARCHAR(64);
+
+-- Создание таблицы скидок. Скидка действует на товар, на категорию или на весь каталог,
+-- если не указано ни то, ни другое. Задается либо процент, либо фиксированная сумма
+CREATE TABLE IF NOT EXISTS discounts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    merch_id BIGINT REFERENCES merch_items(id),
+    category VARCHAR(64),
+    percent INT CHECK (percent BETWEEN 1 AND 100),
+    amount BIGINT CHECK (amount > 0),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((percent IS NULL) <> (amount IS NULL)),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_discounts_period ON discounts (starts_at, ends_at);
+
+-- Создание таблицы промокодов с ограничением числа использований
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(64) UNIQUE NOT NULL,
+    merch_id BIGINT REFERENCES merch_items(id),
+    category VARCHAR(64),
+    percent INT CHECK (percent BETWEEN 1 AND 100),
+    amount BIGINT CHECK (amount > 0),
+    max_uses INT CHECK (max_uses > 0),
+    max_uses_per_user INT CHECK (max_uses_per_user > 0),
+    used INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((percent IS NULL) <> (amount IS NULL))
+);
+
+-- Создание таблицы использований промокодов
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id BIGINT NOT NULL REFERENCES promo_codes(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_user ON promo_redemptions (promo_code_id, user_id);
+
+-- Позиция заказа хранит базовую цену и скидку на единицу, price — фактически списанная цена
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS base_price BIGINT;
+UPDATE order_items SET base_price = price WHERE base_price IS NULL;
+ALTER TABLE order_items ALTER COLUMN base_price SET NOT NULL;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_id BIGINT REFERENCES discounts(id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS promo_code_id BIGINT REFERENCES promo_codes(id);
@@ -0,0 +1,36 @@
+-- Цена единицы, фактически уплаченная за купленный мерч
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS price_paid BIGINT;
+
+UPDATE user_merch um
+SET price_paid = oi.price
+FROM order_items oi
+WHERE um.price_paid IS NULL
+  AND oi.order_id = um.order_id
+  AND oi.merch_id = um.merch_id
+  AND oi.variant_id IS NOT DISTINCT FROM um.variant_id;
+
+-- Для покупок до появления заказов известна только текущая цена товара
+UPDATE user_merch um
+SET price_paid = m.price
+FROM merch_items m
+WHERE um.price_paid IS NULL AND m.id = um.merch_id;
+
+ALTER TABLE user_merch ALTER COLUMN price_paid SET NOT NULL;
+
+-- Создание таблицы истории цен товаров
+CREATE TABLE IF NOT EXISTS merch_price_history (
+    id SERIAL PRIMARY KEY,
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    old_price BIGINT,
+    new_price BIGINT NOT NULL,
+    changed_by BIGINT REFERENCES users(id),
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_price_history_merch ON merch_price_history (merch_id, changed_at);
+
+-- Начальная цена существующих товаров
+INSERT INTO merch_price_history (merch_id, new_price)
+SELECT m.id, m.price
+FROM merch_items m
+WHERE NOT EXISTS (SELECT 1 FROM merch_price_history h WHERE h.merch_id = m.id);
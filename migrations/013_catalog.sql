@@ -0,0 +1,33 @@
+-- Создание таблицы категорий товаров
+CREATE TABLE IF NOT EXISTS merch_categories (
+    name VARCHAR(64) PRIMARY KEY,
+    title VARCHAR(255) NOT NULL
+);
+
+INSERT INTO merch_categories (name, title) VALUES
+    ('apparel', 'Одежда'),
+    ('office', 'Для офиса'),
+    ('accessories', 'Аксессуары')
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE merch_items SET category = 'apparel' WHERE category IS NULL AND name IN ('t-shirt', 'hoody', 'pink-hoody', 'socks');
+UPDATE merch_items SET category = 'office' WHERE category IS NULL AND name IN ('cup', 'book', 'pen');
+UPDATE merch_items SET category = 'accessories' WHERE category IS NULL AND name IN ('powerbank', 'umbrella', 'wallet');
+
+-- Категории, уже заданные у товаров, тоже должны существовать
+INSERT INTO merch_categories (name, title)
+SELECT DISTINCT category, category FROM merch_items WHERE category IS NOT NULL
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE merch_items ADD CONSTRAINT fk_merch_items_category
+    FOREIGN KEY (category) REFERENCES merch_categories(name);
+
+-- Произвольные теги товара
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Индексы для фильтрации и поиска по каталогу
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_merch_items_name_trgm ON merch_items USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_merch_items_tags ON merch_items USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_merch_items_category_price ON merch_items (category, price);
@@ -0,0 +1,35 @@
+-- Отображаемое название и описание товара; name остается идентификатором для URL
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS display_name VARCHAR(255);
+ALTER TABLE merch_items ADD COLUMN IF NOT EXISTS description TEXT;
+
+UPDATE merch_items SET display_name = CASE name
+    WHEN 't-shirt' THEN 'Футболка'
+    WHEN 'cup' THEN 'Кружка'
+    WHEN 'book' THEN 'Книга'
+    WHEN 'pen' THEN 'Ручка'
+    WHEN 'powerbank' THEN 'Пауэрбанк'
+    WHEN 'hoody' THEN 'Худи'
+    WHEN 'umbrella' THEN 'Зонт'
+    WHEN 'socks' THEN 'Носки'
+    WHEN 'wallet' THEN 'Кошелек'
+    WHEN 'pink-hoody' THEN 'Розовое худи'
+    ELSE name
+END
+WHERE display_name IS NULL;
+
+ALTER TABLE merch_items ALTER COLUMN display_name SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_merch_items_display_name_trgm ON merch_items USING GIN (display_name gin_trgm_ops);
+
+-- Изображения товара, файлы хранятся в хранилище объектов по ключам
+CREATE TABLE IF NOT EXISTS merch_images (
+    id SERIAL PRIMARY KEY,
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    key VARCHAR(255) NOT NULL,
+    thumbnail_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_images_merch ON merch_images (merch_id, position);
@@ -0,0 +1,26 @@
+-- Создание таблицы списка желаний.
+-- in_stock и notified_discount_id хранят последнее известное состояние товара,
+-- чтобы уведомлять только об изменениях
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    in_stock BOOLEAN NOT NULL DEFAULT TRUE,
+    notified_discount_id BIGINT REFERENCES discounts(id),
+    added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, merch_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_items_merch ON wishlist_items (merch_id);
+
+-- Создание таблицы уведомлений пользователей
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    kind VARCHAR(32) NOT NULL,
+    merch_id BIGINT REFERENCES merch_items(id),
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, created_at DESC);
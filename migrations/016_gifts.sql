@@ -0,0 +1,10 @@
+-- Заказ может быть оформлен в подарок другому пользователю:
+-- монеты списываются у покупателя (orders.user_id), мерч получает recipient_id
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS recipient_id BIGINT REFERENCES users(id);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS gift_note TEXT;
+
+-- Подаренный мерч хранит дарителя и записку
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS given_by BIGINT REFERENCES users(id);
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS gift_note TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_user_merch_given_by ON user_merch (given_by) WHERE given_by IS NOT NULL;
@@ -0,0 +1,25 @@
+-- Создание таблицы заявок на возврат и обмен купленного мерча.
+-- Возвращенный мерч не удаляется, а получает статус returned
+CREATE TABLE IF NOT EXISTS merch_returns (
+    id SERIAL PRIMARY KEY,
+    user_merch_id BIGINT NOT NULL REFERENCES user_merch(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('return', 'exchange')),
+    exchange_merch_id BIGINT REFERENCES merch_items(id),
+    exchange_variant_id BIGINT REFERENCES merch_variants(id),
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    refund BIGINT NOT NULL DEFAULT 0,
+    charge BIGINT NOT NULL DEFAULT 0,
+    transaction_id BIGINT REFERENCES transactions(id),
+    resolution TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_by BIGINT REFERENCES users(id),
+    resolved_at TIMESTAMP,
+    CHECK (kind = 'return' OR exchange_merch_id IS NOT NULL)
+);
+
+-- По предмету может быть только одна заявка на рассмотрении
+CREATE UNIQUE INDEX IF NOT EXISTS idx_merch_returns_pending ON merch_returns (user_merch_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_merch_returns_user ON merch_returns (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_merch_returns_status ON merch_returns (status, created_at);
@@ -0,0 +1,30 @@
+-- Время последней передачи предмета другому пользователю через обмен
+ALTER TABLE user_merch ADD COLUMN IF NOT EXISTS traded_at TIMESTAMP;
+
+-- Создание таблицы предложений обмена между пользователями.
+-- Просроченные предложения не обновляются, статус expired вычисляется при чтении
+CREATE TABLE IF NOT EXISTS trades (
+    id SERIAL PRIMARY KEY,
+    proposer_id BIGINT NOT NULL REFERENCES users(id),
+    recipient_id BIGINT NOT NULL REFERENCES users(id),
+    offered_coins BIGINT NOT NULL DEFAULT 0 CHECK (offered_coins >= 0),
+    requested_coins BIGINT NOT NULL DEFAULT 0 CHECK (requested_coins >= 0),
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    counter_of BIGINT REFERENCES trades(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    CHECK (proposer_id <> recipient_id)
+);
+
+-- Предметы, участвующие в обмене; owner_id — владелец предмета на момент предложения
+CREATE TABLE IF NOT EXISTS trade_items (
+    trade_id BIGINT NOT NULL REFERENCES trades(id),
+    user_merch_id BIGINT NOT NULL REFERENCES user_merch(id),
+    owner_id BIGINT NOT NULL REFERENCES users(id),
+    PRIMARY KEY (trade_id, user_merch_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trades_proposer ON trades (proposer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_trades_recipient ON trades (recipient_id, created_at DESC);
@@ -0,0 +1,34 @@
+-- Системные аккаунты помечаются явно, а не угадываются по имени
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_system BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Системный аккаунт площадки, на который зачисляются комиссии маркетплейса.
+-- Пароль не является хешем, поэтому войти под этим аккаунтом нельзя.
+-- Если имя уже занято обычным пользователем, миграция прерывается, а не присваивает его аккаунт
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE username = 'system' AND NOT is_system) THEN
+        RAISE EXCEPTION 'username "system" belongs to a regular user, rename the user before migrating';
+    END IF;
+END $$;
+
+INSERT INTO users (username, password, coins, is_system) VALUES ('system', '!', 0, TRUE)
+ON CONFLICT (username) DO NOTHING;
+
+-- Создание таблицы объявлений о продаже мерча между пользователями.
+-- Пока объявление активно, предмет в user_merch имеет статус listed
+CREATE TABLE IF NOT EXISTS market_listings (
+    id SERIAL PRIMARY KEY,
+    user_merch_id BIGINT NOT NULL REFERENCES user_merch(id),
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    price BIGINT NOT NULL CHECK (price > 0),
+    fee BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    buyer_id BIGINT REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+-- Предмет может быть выставлен только в одном активном объявлении
+CREATE UNIQUE INDEX IF NOT EXISTS idx_market_listings_active ON market_listings (user_merch_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_market_listings_browse ON market_listings (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_market_listings_seller ON market_listings (seller_id, created_at DESC);
@@ -0,0 +1,33 @@
+-- Создание таблицы аукционов. Разыгрываемые единицы товара списываются со склада при создании аукциона,
+-- непроданные возвращаются на склад при закрытии
+CREATE TABLE IF NOT EXISTS auctions (
+    id SERIAL PRIMARY KEY,
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    variant_id BIGINT REFERENCES merch_variants(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    min_bid BIGINT NOT NULL CHECK (min_bid > 0),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auctions_due ON auctions (ends_at) WHERE status = 'open';
+
+-- Создание таблицы ставок. У пользователя одна ставка на аукцион, повышение меняет ее сумму.
+-- Монеты активной ставки списаны с баланса до закрытия аукциона или перебития ставки
+CREATE TABLE IF NOT EXISTS auction_bids (
+    id SERIAL PRIMARY KEY,
+    auction_id BIGINT NOT NULL REFERENCES auctions(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (auction_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auction_bids_ranking ON auction_bids (auction_id, amount DESC, updated_at) WHERE status = 'active';
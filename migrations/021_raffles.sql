@@ -0,0 +1,44 @@
+-- Создание таблицы розыгрышей. Seed генерируется при создании и до розыгрыша известен только его хеш,
+-- после розыгрыша seed раскрывается, и любой может проверить выбор победителей
+CREATE TABLE IF NOT EXISTS raffles (
+    id SERIAL PRIMARY KEY,
+    merch_id BIGINT NOT NULL REFERENCES merch_items(id),
+    variant_id BIGINT REFERENCES merch_variants(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    ticket_price BIGINT NOT NULL CHECK (ticket_price > 0),
+    tickets_sold INT NOT NULL DEFAULT 0,
+    draw_at TIMESTAMP NOT NULL,
+    seed VARCHAR(64) NOT NULL,
+    seed_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    drawn_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_raffles_due ON raffles (draw_at) WHERE status = 'open';
+
+-- Покупки билетов. Билеты нумеруются подряд в порядке покупки:
+-- покупке принадлежат номера с first_ticket по first_ticket + tickets - 1
+CREATE TABLE IF NOT EXISTS raffle_entries (
+    id SERIAL PRIMARY KEY,
+    raffle_id BIGINT NOT NULL REFERENCES raffles(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    tickets INT NOT NULL CHECK (tickets > 0),
+    first_ticket INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (raffle_id, first_ticket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_raffle_entries_user ON raffle_entries (raffle_id, user_id);
+
+-- Выигравшие билеты; position — порядок выбора билета при розыгрыше.
+-- Каждый билет выигрывает одну единицу товара, поэтому пользователь с несколькими билетами
+-- может выиграть несколько единиц
+CREATE TABLE IF NOT EXISTS raffle_winners (
+    raffle_id BIGINT NOT NULL REFERENCES raffles(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    ticket INT NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (raffle_id, ticket)
+);
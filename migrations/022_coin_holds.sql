@@ -0,0 +1,46 @@
+-- Удержания монет. users.coins — общий баланс, held_coins — сумма активных удержаний,
+-- тратить можно только coins - held_coins
+ALTER TABLE users ADD COLUMN IF NOT EXISTS held_coins BIGINT NOT NULL DEFAULT 0 CHECK (held_coins >= 0);
+
+-- Создание таблицы удержаний. Активное удержание либо списывается (captured), либо снимается
+-- владельцем сделки (released) или по истечении срока (expired)
+CREATE TABLE IF NOT EXISTS coin_holds (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_holds_user ON coin_holds (user_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_coin_holds_due ON coin_holds (expires_at) WHERE status = 'active';
+
+-- Ставки аукционов удерживают монеты вместо списания
+ALTER TABLE auction_bids ADD COLUMN IF NOT EXISTS hold_id BIGINT REFERENCES coin_holds(id);
+
+-- Монеты активных ставок открытых аукционов были списаны с баланса: возвращаем их на баланс
+-- и переводим в удержания. У пользователя одна ставка на аукцион, поэтому причина однозначно
+-- определяет ставку
+INSERT INTO coin_holds (user_id, amount, reason, expires_at)
+SELECT b.user_id, b.amount, 'Bid on auction #' || b.auction_id, a.ends_at + INTERVAL '1 day'
+FROM auction_bids b
+JOIN auctions a ON a.id = b.auction_id
+WHERE b.status = 'active' AND a.status = 'open' AND b.hold_id IS NULL;
+
+UPDATE auction_bids b
+SET hold_id = h.id
+FROM coin_holds h
+WHERE b.status = 'active' AND b.hold_id IS NULL
+    AND h.user_id = b.user_id AND h.reason = 'Bid on auction #' || b.auction_id;
+
+UPDATE users u
+SET coins = u.coins + h.total, held_coins = u.held_coins + h.total
+FROM (
+    SELECT user_id, SUM(amount) AS total
+    FROM coin_holds
+    GROUP BY user_id
+) h
+WHERE u.id = h.user_id;
@@ -0,0 +1,19 @@
+-- Партии монет. Каждое зачисление создает партию с датой начисления, траты расходуют
+-- самые старые партии первыми (FIFO). Сумма остатков партий равна балансу пользователя
+CREATE TABLE IF NOT EXISTS coin_lots (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    remaining BIGINT NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    warned_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_lots_user ON coin_lots (user_id, granted_at, id) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS idx_coin_lots_granted ON coin_lots (granted_at) WHERE remaining > 0;
+
+-- Даты начисления существующих балансов неизвестны, поэтому они считаются начисленными в момент миграции
+INSERT INTO coin_lots (user_id, amount, remaining)
+SELECT id, coins, coins
+FROM users
+WHERE coins > 0 AND NOT EXISTS (SELECT 1 FROM coin_lots l WHERE l.user_id = users.id);
@@ -0,0 +1,25 @@
+-- Каталог достижений. Условия получения описаны правилами в коде, здесь хранятся
+-- название, описание и бонус в монетах, который начисляется вместе со значком
+CREATE TABLE IF NOT EXISTS achievements (
+    code VARCHAR(64) PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    bonus BIGINT NOT NULL DEFAULT 0 CHECK (bonus >= 0)
+);
+
+-- Выданные достижения. Повторяющиеся достижения (годовщина работы) выдаются с уровнем,
+-- первичный ключ делает повторную выдачу невозможной
+CREATE TABLE IF NOT EXISTS user_achievements (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    code VARCHAR(64) NOT NULL REFERENCES achievements(code),
+    level INT NOT NULL DEFAULT 1 CHECK (level > 0),
+    awarded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, code, level)
+);
+
+INSERT INTO achievements (code, title, description, bonus) VALUES
+    ('welcome', 'Welcome aboard', 'Joined the shop', 0),
+    ('first_purchase', 'First purchase', 'Bought merch for the first time', 50),
+    ('generous_colleague', 'Generous colleague', 'Sent coins to 10 different colleagues', 100),
+    ('work_anniversary', 'Work anniversary', 'Another year with the company', 200)
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,41 @@
+-- Пользователь может скрыть себя из рейтингов
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Агрегаты переводов для рейтингов за неделю, месяц и все время. Представление пересчитывается
+-- фоновой задачей, поэтому запросы рейтингов не сканируют таблицу transactions.
+-- Отмененные переводы не учитываются
+CREATE MATERIALIZED VIEW IF NOT EXISTS leaderboard_stats AS
+WITH periods (period, since) AS (
+    VALUES
+        ('week', NOW() - INTERVAL '7 days'),
+        ('month', NOW() - INTERVAL '30 days'),
+        ('all', '-infinity'::TIMESTAMPTZ)
+),
+transfers AS (
+    SELECT p.period, t.from_user_id, t.to_user_id, t.amount
+    FROM periods p
+    JOIN transactions t ON t.created_at >= p.since
+    WHERE t.kind = 'transfer'
+        AND NOT EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id)
+),
+sent AS (
+    SELECT period, from_user_id AS user_id, SUM(amount) AS given, COUNT(DISTINCT to_user_id) AS recipients
+    FROM transfers
+    GROUP BY period, from_user_id
+),
+received AS (
+    SELECT period, to_user_id AS user_id, SUM(amount) AS received
+    FROM transfers
+    GROUP BY period, to_user_id
+)
+SELECT
+    COALESCE(s.period, r.period) AS period,
+    COALESCE(s.user_id, r.user_id) AS user_id,
+    COALESCE(s.given, 0)::BIGINT AS given,
+    COALESCE(r.received, 0)::BIGINT AS received,
+    COALESCE(s.recipients, 0)::BIGINT AS recipients
+FROM sent s
+FULL JOIN received r ON r.period = s.period AND r.user_id = s.user_id;
+
+-- Уникальный индекс нужен для REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leaderboard_stats_user ON leaderboard_stats (period, user_id);
@@ -0,0 +1,20 @@
+-- Команды. Кошелек команды — системный аккаунт в users (is_system) с паролем, который не является хешем,
+-- поэтому войти под ним нельзя. Так гранты, переводы и покупки команды проходят через
+-- те же балансы, партии монет и историю транзакций, что и у пользователей
+CREATE TABLE IF NOT EXISTS teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    wallet_user_id BIGINT UNIQUE NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Участники команд. Менеджеры распоряжаются кошельком команды
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id BIGINT NOT NULL REFERENCES teams(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'manager')),
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_members_user ON team_members (user_id);
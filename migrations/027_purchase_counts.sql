@@ -0,0 +1,3 @@
+-- Ограничение покупок считается по заказам: покупатель или получатель подарка
+CREATE INDEX IF NOT EXISTS idx_orders_owner ON orders ((COALESCE(recipient_id, user_id)), created_at);
+CREATE INDEX IF NOT EXISTS idx_user_merch_order ON user_merch (order_id, merch_id) WHERE order_id IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Списания из партий монет. По ним возврат (отмена заказа, возврат мерча, отмена перевода)
+-- зачисляет монеты обратно в те партии, из которых они были потрачены, с прежним сроком сгорания
+CREATE TABLE IF NOT EXISTS coin_lot_spends (
+    id SERIAL PRIMARY KEY,
+    lot_id BIGINT NOT NULL REFERENCES coin_lots(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    spent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_lot_spends_user ON coin_lot_spends (user_id, spent_at) WHERE amount > 0;
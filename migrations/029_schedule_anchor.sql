@@ -0,0 +1,5 @@
+-- Первый плановый запуск регулярного перевода. Следующие запуски отсчитываются от него,
+-- а не от next_run_at, который сдвигается повторами после неудачных попыток
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS anchor_at TIMESTAMP;
+UPDATE scheduled_transfers SET anchor_at = next_run_at WHERE anchor_at IS NULL;
+ALTER TABLE scheduled_transfers ALTER COLUMN anchor_at SET NOT NULL;
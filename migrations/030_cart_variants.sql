@@ -0,0 +1,5 @@
+-- Корзина хранит вариант товара: один товар может лежать в корзине в нескольких вариантах
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES merch_variants(id);
+ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS cart_items_pkey;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_line ON cart_items (user_id, merch_id, (COALESCE(variant_id, 0)));
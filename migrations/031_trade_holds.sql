@@ -0,0 +1,3 @@
+-- Предложенные в обмене монеты удерживаются до принятия, отклонения или истечения предложения.
+-- У ранее созданных предложений удержания нет: при принятии монеты списываются напрямую
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS hold_id BIGINT REFERENCES coin_holds(id);
@@ -43,7 +43,7 @@ func TestMain(m *testing.M) {
 
 	repos := postgres.NewRepository(testDB)
 
-	services := service.NewService(repos)
+	services := service.NewService(repos, cfg)
 
 	handler = handlers.NewHandler(services)
 
@@ -40,7 +40,7 @@ func TestLoadBuyMerch(t *testing.T) {
 
 	// Инициализация репозиториев и сервисов
 	repos := postgres.NewRepository(db)
-	services := service.NewService(repos)
+	services := service.NewService(repos, cfg)
 	handler := handlers.NewHandler(services)
 
 	// Создание тестового сервера